@@ -3,9 +3,16 @@
  * 
  * Caretaker endpoints for reviewing, approving, and rejecting
  * memory and identity proposals generated by learning sessions.
+ * The review queue lives in the RAG engine, so these work with
+ * whichever storage backend it runs on.
  */
 
-import { healthCheck as ragHealth } from '../services/ragClient.js';
+import {
+    healthCheck as ragHealth,
+    listReviews,
+    decideReview,
+    submitReviewDecisions,
+} from '../services/ragClient.js';
 import logger from '../utils/logger.js';
 import { authenticateToken } from '../middleware/auth.js';
 
@@ -23,37 +30,20 @@ export default async function reviewRoutes(server) {
     server.get('/reviews', {
         preHandler: [authenticateToken],
     }, async (request, reply) => {
-        try {
-            const userId = request.user.id || request.user._id;
-
-            // Forward to RAG engine (which queries review_queue)
-            const axios = (await import('axios')).default;
-            const ragUrl = process.env.RAG_ENGINE_URL || 'http://localhost:8081';
-
-            // For now, we'll use a direct search — the Go service doesn't have
-            // a dedicated list-reviews endpoint yet, so we use the MongoDB fallback
-            // This will be improved when the Go service gets a reviews endpoint
-            const { default: mongoose } = await import('mongoose');
-            const db = mongoose.connection.db;
-
-            if (!db) {
-                return reply.code(503).send({ success: false, message: 'Database not available' });
-            }
-
-            const reviews = await db.collection('review_queue')
-                .find({ user_id: userId.toString(), status: 'pending' })
-                .sort({ created_at: -1 })
-                .toArray();
-
-            return reply.send({
-                success: true,
-                reviews: reviews || [],
-                count: reviews?.length || 0,
-            });
-        } catch (err) {
-            logger.error('Error listing reviews:', err.message);
-            return reply.code(500).send({ success: false, error: err.message });
+        const userId = (request.user.id || request.user._id).toString();
+
+        const result = await listReviews(userId);
+        if (!result.success) {
+            return reply.code(result.status || 500).send({ success: false, error: result.error });
         }
+
+        // Newest first for the caretaker dashboard
+        const reviews = [...result.reviews].reverse();
+        return reply.send({
+            success: true,
+            reviews,
+            count: reviews.length,
+        });
     });
 
     /**
@@ -63,65 +53,57 @@ export default async function reviewRoutes(server) {
     server.post('/reviews/:sessionId/approve', {
         preHandler: [authenticateToken],
     }, async (request, reply) => {
-        try {
-            const { sessionId } = request.params;
-            const userId = request.user.id || request.user._id;
-            const { default: mongoose } = await import('mongoose');
-            const db = mongoose.connection.db;
-
-            if (!db) {
-                return reply.code(503).send({ success: false, message: 'Database not available' });
-            }
-
-            // Find the review
-            const review = await db.collection('review_queue').findOne({
-                session_id: sessionId,
-                user_id: userId.toString(),
-            });
-
-            if (!review) {
-                return reply.code(404).send({ success: false, message: 'Review not found' });
-            }
-
-            if (review.status !== 'pending') {
-                return reply.code(400).send({ success: false, message: `Review already ${review.status}` });
-            }
-
-            // Commit proposed memories via RAG engine
-            const { storeMemory } = await import('../services/ragClient.js');
-            const commitResults = [];
-
-            if (review.proposed_memories?.length) {
-                for (const mem of review.proposed_memories) {
-                    const result = await storeMemory(
-                        userId.toString(),
-                        mem.content,
-                        mem.importance || 0.5,
-                        mem.source || 'conversation',
-                        sessionId
-                    );
-                    commitResults.push(result);
-                }
-            }
-
-            // Update review status
-            await db.collection('review_queue').updateOne(
-                { session_id: sessionId },
-                { $set: { status: 'approved', reviewed_at: new Date() } }
-            );
-
-            logger.info(`Review ${sessionId} approved by user ${userId} — committed ${commitResults.length} memories`);
-
-            return reply.send({
-                success: true,
-                sessionId,
-                committed: commitResults.length,
-                message: 'Review approved and memories committed',
-            });
-        } catch (err) {
-            logger.error('Error approving review:', err.message);
-            return reply.code(500).send({ success: false, error: err.message });
+        const { sessionId } = request.params;
+        const userId = (request.user.id || request.user._id).toString();
+
+        // The RAG engine commits the proposals and records each outcome;
+        // approving twice is safe and does not commit again.
+        const decided = await decideReview(userId, sessionId, 'approve');
+        if (!decided.success) {
+            return reply.code(decided.status || 500).send({ success: false, message: decided.error });
+        }
+
+        const results = decided.review.results || [];
+        const committed = results.filter(r => r.outcome === 'applied').length;
+        logger.info(`Review ${sessionId} approved by user ${userId} — applied ${committed} of ${results.length} proposals`);
+
+        return reply.send({
+            success: true,
+            sessionId,
+            committed,
+            results,
+            message: 'Review approved and proposals committed',
+        });
+    });
+
+    /**
+     * POST /api/reviews/:sessionId/decisions
+     * Accept, reject or edit individual proposals. Body:
+     * { decisions: [{ proposal_id, decision: 'accept'|'reject'|'edit', edited_value }] }
+     */
+    server.post('/reviews/:sessionId/decisions', {
+        preHandler: [authenticateToken],
+    }, async (request, reply) => {
+        const { sessionId } = request.params;
+        const userId = (request.user.id || request.user._id).toString();
+        const decisions = request.body?.decisions;
+
+        if (!Array.isArray(decisions) || decisions.length === 0) {
+            return reply.code(400).send({ success: false, message: 'decisions must be a non-empty array' });
         }
+
+        const decided = await submitReviewDecisions(userId, sessionId, decisions);
+        if (!decided.success) {
+            return reply.code(decided.status || 500).send({ success: false, message: decided.error });
+        }
+
+        logger.info(`Review ${sessionId}: ${decisions.length} decisions by user ${userId} — status ${decided.review.status}`);
+
+        return reply.send({
+            success: true,
+            sessionId,
+            review: decided.review,
+        });
     });
 
     /**
@@ -131,36 +113,21 @@ export default async function reviewRoutes(server) {
     server.post('/reviews/:sessionId/reject', {
         preHandler: [authenticateToken],
     }, async (request, reply) => {
-        try {
-            const { sessionId } = request.params;
-            const userId = request.user.id || request.user._id;
-            const { default: mongoose } = await import('mongoose');
-            const db = mongoose.connection.db;
-
-            if (!db) {
-                return reply.code(503).send({ success: false, message: 'Database not available' });
-            }
-
-            const result = await db.collection('review_queue').updateOne(
-                { session_id: sessionId, user_id: userId.toString() },
-                { $set: { status: 'rejected', reviewed_at: new Date() } }
-            );
-
-            if (result.matchedCount === 0) {
-                return reply.code(404).send({ success: false, message: 'Review not found' });
-            }
-
-            logger.info(`Review ${sessionId} rejected by user ${userId}`);
-
-            return reply.send({
-                success: true,
-                sessionId,
-                message: 'Review rejected',
-            });
-        } catch (err) {
-            logger.error('Error rejecting review:', err.message);
-            return reply.code(500).send({ success: false, error: err.message });
+        const { sessionId } = request.params;
+        const userId = (request.user.id || request.user._id).toString();
+
+        const decided = await decideReview(userId, sessionId, 'reject');
+        if (!decided.success) {
+            return reply.code(decided.status || 500).send({ success: false, message: decided.error });
         }
+
+        logger.info(`Review ${sessionId} rejected by user ${userId}`);
+
+        return reply.send({
+            success: true,
+            sessionId,
+            message: 'Review rejected',
+        });
     });
 
     /**
@@ -6,6 +6,7 @@
  */
 
 import axios from 'axios';
+import crypto from 'crypto';
 import logger from '../utils/logger.js';
 
 const RAG_ENGINE_URL = process.env.RAG_ENGINE_URL || 'http://localhost:8081';
@@ -38,6 +39,16 @@ async function withRetry(fn, retries = MAX_RETRIES) {
     throw lastError;
 }
 
+/**
+ * Retry wrapper for requests that create something. fn receives one
+ * idempotency key shared by every attempt, so a retry after a timeout
+ * replays the first attempt instead of creating a second copy.
+ */
+function withIdempotentRetry(fn, retries = MAX_RETRIES) {
+    const idempotencyKey = crypto.randomUUID();
+    return withRetry(() => fn(idempotencyKey), retries);
+}
+
 /**
  * Get a structured identity fact for a user.
  * @param {string} userId - User ID
@@ -93,7 +104,7 @@ export const searchMemory = async (userId, query, topK = 3, replicaId = '') => {
  */
 export const storeMemory = async (userId, content, importance = 0.5, source = 'conversation', sessionId = '', replicaId = '') => {
     try {
-        const { data } = await withRetry(() =>
+        const { data } = await withIdempotentRetry((idempotencyKey) =>
             client.post('/memory/store', {
                 user_id: userId,
                 replica_id: replicaId,
@@ -101,6 +112,7 @@ export const storeMemory = async (userId, content, importance = 0.5, source = 'c
                 importance,
                 source,
                 session_id: sessionId,
+                idempotency_key: idempotencyKey,
             })
         );
         return data;
@@ -115,12 +127,13 @@ export const storeMemory = async (userId, content, importance = 0.5, source = 'c
  * Results go into the review queue for caretaker approval.
  * @param {string} userId - User ID
  * @param {Array<{role: string, content: string}>} messages - Transcript
- * @returns {Promise<object>} Process response with session_id
+ * @param {{async?: boolean}} [options] - Queue the transcript and return a job_id instead of waiting
+ * @returns {Promise<object>} Process response with session_id, or job_id when async
  */
-export const processSession = async (userId, messages) => {
+export const processSession = async (userId, messages, { async = false } = {}) => {
     try {
-        const { data } = await withRetry(() =>
-            client.post('/session/process', { user_id: userId, messages })
+        const { data } = await withIdempotentRetry((idempotencyKey) =>
+            client.post('/session/process', { user_id: userId, messages, async, idempotency_key: idempotencyKey })
         );
         return data;
     } catch (err) {
@@ -129,6 +142,183 @@ export const processSession = async (userId, messages) => {
     }
 };
 
+/**
+ * Get the status of a queued session-processing job.
+ * @param {string} userId - User ID
+ * @param {string} jobId - Job ID returned by an async processSession
+ * @returns {Promise<object>} Job status response with session_id once succeeded
+ */
+export const getJob = async (userId, jobId) => {
+    try {
+        const { data } = await withRetry(() =>
+            client.get(`/jobs/${encodeURIComponent(jobId)}`, { params: { user_id: userId } })
+        );
+        return data;
+    } catch (err) {
+        logger.error('RAG getJob failed:', err.message);
+        return failure(err);
+    }
+};
+
+/**
+ * Ingest a whole document (letter, eulogy, journal) as chunked memories.
+ * Markdown may start with a front-matter block giving title, date and people.
+ * @param {string} userId - User ID
+ * @param {string} content - Document text
+ * @param {{format?: 'text'|'markdown'|'pdf_text', title?: string, date?: string, people?: string[], importance?: number, replicaId?: string}} [options]
+ * @returns {Promise<object>} Document response with document_id and chunk_ids
+ */
+export const ingestDocument = async (userId, content, { format = 'text', title, date, people, importance, replicaId = '' } = {}) => {
+    try {
+        const { data } = await withIdempotentRetry((idempotencyKey) =>
+            client.post('/documents/ingest', {
+                user_id: userId,
+                replica_id: replicaId,
+                content,
+                format,
+                title,
+                date,
+                people,
+                importance,
+                idempotency_key: idempotencyKey,
+            })
+        );
+        return data;
+    } catch (err) {
+        logger.error('RAG ingestDocument failed:', err.message);
+        return failure(err);
+    }
+};
+
+/**
+ * Get an ingested document with its chunks in order.
+ * @param {string} userId - User ID
+ * @param {string} documentId - Document ID returned by ingestDocument
+ * @returns {Promise<object>} Document response
+ */
+export const getDocument = async (userId, documentId) => {
+    try {
+        const { data } = await withRetry(() =>
+            client.get(`/documents/${encodeURIComponent(documentId)}`, { params: { user_id: userId } })
+        );
+        return data;
+    } catch (err) {
+        logger.error('RAG getDocument failed:', err.message);
+        return failure(err);
+    }
+};
+
+/**
+ * Delete an ingested document together with all of its chunks. Not retried:
+ * a retry after a lost response would report the document as not found.
+ * @param {string} userId - User ID
+ * @param {string} documentId - Document ID returned by ingestDocument
+ * @returns {Promise<object>} Delete response
+ */
+export const deleteDocument = async (userId, documentId) => {
+    try {
+        const { data } = await client.delete(
+            `/documents/${encodeURIComponent(documentId)}`,
+            { params: { user_id: userId } }
+        );
+        return data;
+    } catch (err) {
+        logger.error('RAG deleteDocument failed:', err.message);
+        return failure(err);
+    }
+};
+
+/**
+ * Build a failure result that keeps the RAG engine's status code, so routes
+ * can tell "not found" or "already decided" apart from an outage.
+ */
+function failure(err, extra = {}) {
+    return {
+        success: false,
+        status: err.response?.status || 502,
+        error: err.response?.data?.error || err.message,
+        ...extra,
+    };
+}
+
+/**
+ * List a user's pending reviews.
+ * @param {string} userId - User ID
+ * @returns {Promise<object>} Review list response
+ */
+export const listReviews = async (userId) => {
+    try {
+        const { data } = await withRetry(() =>
+            client.get('/reviews', { params: { user_id: userId } })
+        );
+        return data;
+    } catch (err) {
+        logger.error('RAG listReviews failed:', err.message);
+        return failure(err, { reviews: [], count: 0 });
+    }
+};
+
+/**
+ * Get one of a user's reviews.
+ * @param {string} userId - User ID
+ * @param {string} sessionId - Session ID of the review
+ * @returns {Promise<object>} Review response
+ */
+export const getReview = async (userId, sessionId) => {
+    try {
+        const { data } = await withRetry(() =>
+            client.get(`/reviews/${encodeURIComponent(sessionId)}`, { params: { user_id: userId } })
+        );
+        return data;
+    } catch (err) {
+        logger.error('RAG getReview failed:', err.message);
+        return failure(err);
+    }
+};
+
+/**
+ * Approve or reject a pending review. Not retried: a retry after a lost
+ * response would report the review as already decided.
+ * @param {string} userId - User ID
+ * @param {string} sessionId - Session ID of the review
+ * @param {'approve'|'reject'} decision - Decision to apply
+ * @returns {Promise<object>} Review response
+ */
+export const decideReview = async (userId, sessionId, decision) => {
+    try {
+        const { data } = await client.post(
+            `/reviews/${encodeURIComponent(sessionId)}/${decision}`,
+            { user_id: userId }
+        );
+        return data;
+    } catch (err) {
+        logger.error(`RAG ${decision} review failed:`, err.message);
+        return failure(err);
+    }
+};
+
+/**
+ * Submit caretaker decisions on individual proposals of a review.
+ * Once every proposal is decided the RAG engine commits the accepted ones.
+ * Not retried, for the same reason as decideReview.
+ * @param {string} userId - User ID
+ * @param {string} sessionId - Session ID of the review
+ * @param {Array<{proposal_id: string, decision: 'accept'|'reject'|'edit', edited_value?: any}>} decisions
+ * @returns {Promise<object>} Review response
+ */
+export const submitReviewDecisions = async (userId, sessionId, decisions) => {
+    try {
+        const { data } = await client.post(
+            `/reviews/${encodeURIComponent(sessionId)}/decisions`,
+            { user_id: userId, decisions }
+        );
+        return data;
+    } catch (err) {
+        logger.error('RAG submitReviewDecisions failed:', err.message);
+        return failure(err);
+    }
+};
+
 /**
  * Health check for the RAG engine.
  * @returns {Promise<object>} Health status
@@ -146,4 +336,18 @@ export const healthCheck = async () => {
     }
 };
 
-export default { getIdentity, searchMemory, storeMemory, processSession, healthCheck };
+export default {
+    getIdentity,
+    searchMemory,
+    storeMemory,
+    processSession,
+    getJob,
+    ingestDocument,
+    getDocument,
+    deleteDocument,
+    listReviews,
+    getReview,
+    decideReview,
+    submitReviewDecisions,
+    healthCheck,
+};
@@ -67,9 +67,14 @@ export const bufferMessage = (userId, role, content) => {
  * End the current learning session and send the transcript for processing.
  * The Go RAG engine will extract identity updates and memory proposals,
  * then store them in the review queue for caretaker approval.
+ *
+ * The transcript is queued rather than processed inline, so the result
+ * carries a jobId instead of the sessionId it used to return. Poll
+ * ragClient.getJob with it; the job reports the review's session_id once
+ * it has succeeded.
  * 
  * @param {string} userId
- * @returns {Promise<object>} Processing result
+ * @returns {Promise<{success: boolean, jobId?: string, messagesProcessed?: number, message?: string, error?: string}>} Queueing result
  */
 export const endSession = async (userId) => {
     const session = sessions.get(userId);
@@ -89,12 +94,16 @@ export const endSession = async (userId) => {
     logger.info(`Ending learning session for user ${userId} — processing ${messages.length} messages`);
 
     try {
-        const result = await processSession(userId, messages);
+        // Queued: LLM extraction can outlast an HTTP request.
+        const result = await processSession(userId, messages, { async: true });
+        if (!result.success) {
+            return { success: false, error: result.error };
+        }
         return {
             success: true,
-            sessionId: result.session_id,
+            jobId: result.job_id,
             messagesProcessed: messages.length,
-            message: 'Session transcript sent for review',
+            message: 'Session transcript queued for review',
         };
     } catch (err) {
         logger.error(`Failed to process session for user ${userId}:`, err.message);
@@ -7,11 +7,20 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/memory-lane/rag-engine/internal/api"
+	"github.com/memory-lane/rag-engine/internal/documents"
+	"github.com/memory-lane/rag-engine/internal/embedding"
+	"github.com/memory-lane/rag-engine/internal/idempotency"
+	"github.com/memory-lane/rag-engine/internal/index"
+	"github.com/memory-lane/rag-engine/internal/jobs"
 	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/review"
+	"github.com/memory-lane/rag-engine/internal/schema"
 	"github.com/memory-lane/rag-engine/internal/session"
 	"github.com/memory-lane/rag-engine/internal/storage"
 )
@@ -30,26 +39,54 @@ func main() {
 	log.Printf("✅ Storage backend: %s", store.BackendName())
 
 	// --- Build services ---
-	identitySvc := retrieval.NewIdentityService(store)
-	memorySvc := retrieval.NewMemoryService(store)
-	groqKey := os.Getenv("GROQ_API_KEY")
-	sessionProc := session.NewProcessor(store, groqKey)
+	identitySvc := retrieval.NewIdentityService(store, initSchema())
+	embedder := initEmbedder()
+	memorySvc := retrieval.NewMemoryService(store, embedder, initChunker())
+	sessionProc := session.NewProcessor(store, identitySvc, memorySvc, initExtractor())
+	reviewSvc := review.NewService(store, identitySvc, memorySvc)
+	documentSvc := documents.NewService(store, memorySvc)
 
-	if groqKey != "" {
-		log.Println("🧠 Groq API key detected — LLM extraction enabled")
-	} else {
-		log.Println("⚠️  No GROQ_API_KEY — using simple rule-based extraction")
+	// --- Background jobs ---
+	jobQueue := jobs.NewQueue(store, sessionProc.Process)
+	workers, _ := strconv.Atoi(os.Getenv("JOB_WORKERS"))
+	if workers <= 0 {
+		workers = 2
 	}
+	jobsDone := make(chan struct{})
+	go func() {
+		jobQueue.Run(ctx, workers)
+		close(jobsDone)
+	}()
+	log.Printf("⚙️  Job workers: %d", workers)
 
 	// --- HTTP router ---
-	handler := api.NewHandler(identitySvc, memorySvc, sessionProc, store.BackendName())
+	handler := api.NewHandler(identitySvc, memorySvc, sessionProc, reviewSvc, documentSvc, jobQueue, idempotency.NewGuard(store), store.BackendName())
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /health", handler.Health)
+	mux.HandleFunc("GET /identity/schema", handler.IdentitySchema)
 	mux.HandleFunc("POST /identity/get", handler.GetIdentity)
+	mux.HandleFunc("POST /identity/list", handler.ListIdentity)
+	mux.HandleFunc("POST /identity/batch", handler.BatchGetIdentity)
+	mux.HandleFunc("POST /identity/set", handler.SetIdentity)
+	mux.HandleFunc("DELETE /identity/{key}", handler.DeleteIdentity)
+	mux.HandleFunc("POST /identity/history", handler.IdentityHistory)
+	mux.HandleFunc("POST /identity/rollback", handler.RollbackIdentity)
 	mux.HandleFunc("POST /memory/search", handler.SearchMemory)
 	mux.HandleFunc("POST /memory/store", handler.StoreMemory)
+	mux.HandleFunc("POST /memory/list", handler.ListMemory)
+	mux.HandleFunc("PUT /memory/{chunk_id}", handler.UpdateMemory)
+	mux.HandleFunc("DELETE /memory/{chunk_id}", handler.DeleteMemory)
+	mux.HandleFunc("POST /documents/ingest", handler.IngestDocument)
+	mux.HandleFunc("GET /documents/{document_id}", handler.GetDocument)
+	mux.HandleFunc("DELETE /documents/{document_id}", handler.DeleteDocument)
 	mux.HandleFunc("POST /session/process", handler.ProcessSession)
+	mux.HandleFunc("GET /jobs/{job_id}", handler.GetJob)
+	mux.HandleFunc("GET /reviews", handler.ListReviews)
+	mux.HandleFunc("GET /reviews/{session_id}", handler.GetReview)
+	mux.HandleFunc("POST /reviews/{session_id}/approve", handler.ApproveReview)
+	mux.HandleFunc("POST /reviews/{session_id}/reject", handler.RejectReview)
+	mux.HandleFunc("POST /reviews/{session_id}/decisions", handler.DecideReview)
 
 	// --- Start server ---
 	port := os.Getenv("RAG_ENGINE_PORT")
@@ -83,6 +120,7 @@ func main() {
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("Server error: %v", err)
 	}
+	<-jobsDone // workers record the jobs they were running before exiting
 }
 
 // initStorage picks the right backend based on environment variables.
@@ -90,6 +128,28 @@ func initStorage(ctx context.Context) (storage.Storage, error) {
 	storageBackend := os.Getenv("STORAGE_BACKEND")
 	awsRegion := os.Getenv("AWS_REGION")
 
+	if storageBackend == "memory" {
+		log.Println("🧪 Using in-memory backend (data is lost on exit)")
+		return storage.NewMemoryStorage(), nil
+	}
+
+	// "sqlite" is accepted for the single-file backend as first specified;
+	// it is served by bbolt, which needs no cgo or external library.
+	if storageBackend == "bolt" || storageBackend == "sqlite" {
+		path := os.Getenv("BOLT_PATH")
+		if path == "" {
+			path = "rag-engine.db"
+		}
+		log.Printf("🗄️  Using embedded bbolt backend (%s)", path)
+		return storage.NewBoltStorage(path)
+	}
+
+	switch storageBackend {
+	case "", "mongodb", "dynamodb":
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (want mongodb, dynamodb, bolt, sqlite or memory)", storageBackend)
+	}
+
 	// If explicitly set to dynamodb, or if we have AWS keys
 	if storageBackend == "dynamodb" || (os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "") {
 		if awsRegion == "" {
@@ -97,7 +157,23 @@ func initStorage(ctx context.Context) (storage.Storage, error) {
 		}
 		endpoint := os.Getenv("DYNAMODB_ENDPOINT") // Optional for purely AWS cloud
 		log.Println("📦 Using DynamoDB backend (Region: " + awsRegion + ")")
-		return storage.NewDynamoStorage(ctx, awsRegion, endpoint)
+		store, err := storage.NewDynamoStorage(ctx, awsRegion, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		if os.Getenv("DYNAMODB_MIGRATE") == "true" {
+			n, err := store.MigrateAttributeNames(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("migrate attribute names: %w", err)
+			}
+			log.Printf("🏷️ Migrated %d items to the current attribute names", n)
+			n, err = store.BackfillMemoryKeys(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("backfill memory keys: %w", err)
+			}
+			log.Printf("🔑 Backfilled %d memory key items", n)
+		}
+		return store, nil
 	}
 
 	mongoURI := os.Getenv("MONGODB_URL")
@@ -108,6 +184,117 @@ func initStorage(ctx context.Context) (storage.Storage, error) {
 	return storage.NewMongoStorage(ctx, mongoURI)
 }
 
+// initEmbedder picks the embedder used for semantic search. The default is
+// the offline hash embedder; set EMBEDDER=http to call an OpenAI-compatible
+// /embeddings endpoint or EMBEDDER=none for lexical search only.
+func initEmbedder() embedding.Embedder {
+	switch os.Getenv("EMBEDDER") {
+	case "none":
+		log.Println("🔤 Embeddings disabled — lexical search only")
+		return nil
+	case "http":
+		url := os.Getenv("EMBEDDING_URL")
+		model := os.Getenv("EMBEDDING_MODEL")
+		if url == "" || model == "" {
+			log.Fatalf("❌ EMBEDDER=http requires EMBEDDING_URL and EMBEDDING_MODEL")
+		}
+		log.Printf("🧭 Using HTTP embedder (%s, model %s)", url, model)
+		return embedding.NewHTTPEmbedder(url, model, os.Getenv("EMBEDDING_API_KEY"))
+	default:
+		dims, _ := strconv.Atoi(os.Getenv("EMBEDDING_DIMENSIONS"))
+		e := embedding.NewHashEmbedder(dims)
+		log.Printf("🧭 Using offline hash embedder (%s)", e.Name())
+		return e
+	}
+}
+
+// initExtractor builds the extraction chain named by EXTRACTORS, a comma
+// separated list tried in order:
+//
+//	groq   Groq API (GROQ_API_KEY, GROQ_MODEL, GROQ_BASE_URL, GROQ_TIMEOUT)
+//	openai any OpenAI-compatible server (EXTRACTION_URL, EXTRACTION_MODEL,
+//	       EXTRACTION_API_KEY, EXTRACTION_TIMEOUT), e.g. llama.cpp or Ollama
+//	rules  the offline rule-based extractor
+//
+// The default is "groq,rules" when GROQ_API_KEY is set and "rules" otherwise.
+func initExtractor() session.Extractor {
+	names := os.Getenv("EXTRACTORS")
+	if names == "" {
+		names = "rules"
+		if os.Getenv("GROQ_API_KEY") != "" {
+			names = "groq,rules"
+		}
+	}
+
+	var chain []session.Extractor
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "groq":
+			key := os.Getenv("GROQ_API_KEY")
+			if key == "" {
+				log.Fatalf("❌ Extractor groq requires GROQ_API_KEY")
+			}
+			baseURL := os.Getenv("GROQ_BASE_URL")
+			if baseURL == "" {
+				baseURL = session.GroqBaseURL
+			}
+			model := os.Getenv("GROQ_MODEL")
+			if model == "" {
+				model = session.GroqDefaultModel
+			}
+			chain = append(chain, session.NewChatExtractor(baseURL, model, key, envMillis("GROQ_TIMEOUT")))
+		case "openai":
+			url := os.Getenv("EXTRACTION_URL")
+			model := os.Getenv("EXTRACTION_MODEL")
+			if url == "" || model == "" {
+				log.Fatalf("❌ Extractor openai requires EXTRACTION_URL and EXTRACTION_MODEL")
+			}
+			chain = append(chain, session.NewChatExtractor(url, model, os.Getenv("EXTRACTION_API_KEY"), envMillis("EXTRACTION_TIMEOUT")))
+		case "rules":
+			chain = append(chain, session.NewRuleExtractor())
+		default:
+			log.Fatalf("❌ Unknown extractor %q in EXTRACTORS", name)
+		}
+	}
+
+	extractor := session.NewChain(chain...)
+	log.Printf("🧠 Extraction chain: %s", extractor.Name())
+	return extractor
+}
+
+// initChunker sizes the splitting of uploaded files from CHUNK_SIZE and
+// CHUNK_OVERLAP, both in words. Unset values use the defaults.
+func initChunker() *index.Chunker {
+	size, _ := strconv.Atoi(os.Getenv("CHUNK_SIZE"))
+	overlap, _ := strconv.Atoi(os.Getenv("CHUNK_OVERLAP"))
+	c := index.NewChunker(size, overlap)
+	log.Printf("✂️  File chunks: %d words, %d overlap", c.Size, c.Overlap)
+	return c
+}
+
+// envMillis reads a duration given in milliseconds, as the Node backend
+// does for GROQ_TIMEOUT. Unset or invalid values give zero.
+func envMillis(key string) time.Duration {
+	ms, _ := strconv.Atoi(os.Getenv(key))
+	return time.Duration(ms) * time.Millisecond
+}
+
+// initSchema loads the identity schema from IDENTITY_SCHEMA_PATH, falling
+// back to the built-in registry.
+func initSchema() *schema.Registry {
+	path := os.Getenv("IDENTITY_SCHEMA_PATH")
+	if path == "" {
+		log.Println("📐 Using built-in identity schema")
+		return schema.Default()
+	}
+	reg, err := schema.Load(path)
+	if err != nil {
+		log.Fatalf("❌ Failed to load identity schema: %v", err)
+	}
+	log.Printf("📐 Loaded identity schema from %s (%d fields)", path, len(reg.Fields()))
+	return reg
+}
+
 // withLogging wraps an http.Handler with basic request logging.
 func withLogging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
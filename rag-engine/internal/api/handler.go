@@ -1,13 +1,23 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/memory-lane/rag-engine/internal/documents"
+	"github.com/memory-lane/rag-engine/internal/idempotency"
+	"github.com/memory-lane/rag-engine/internal/jobs"
 	"github.com/memory-lane/rag-engine/internal/models"
 	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/review"
+	"github.com/memory-lane/rag-engine/internal/schema"
 	"github.com/memory-lane/rag-engine/internal/session"
+	"github.com/memory-lane/rag-engine/internal/storage"
 )
 
 // Handler holds references to services and exposes HTTP handlers.
@@ -15,6 +25,10 @@ type Handler struct {
 	identity  *retrieval.IdentityService
 	memory    *retrieval.MemoryService
 	session   *session.Processor
+	reviews   *review.Service
+	documents *documents.Service
+	jobs      *jobs.Queue
+	idem      *idempotency.Guard
 	startTime time.Time
 	backend   string
 }
@@ -24,12 +38,20 @@ func NewHandler(
 	identity *retrieval.IdentityService,
 	memory *retrieval.MemoryService,
 	sess *session.Processor,
+	reviews *review.Service,
+	docs *documents.Service,
+	queue *jobs.Queue,
+	idem *idempotency.Guard,
 	backend string,
 ) *Handler {
 	return &Handler{
 		identity:  identity,
 		memory:    memory,
 		session:   sess,
+		reviews:   reviews,
+		documents: docs,
+		jobs:      queue,
+		idem:      idem,
 		startTime: time.Now(),
 		backend:   backend,
 	}
@@ -58,7 +80,153 @@ func (h *Handler) GetIdentity(w http.ResponseWriter, r *http.Request) {
 
 	fact, err := h.identity.Get(r.Context(), req.UserID, req.Key)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, models.IdentityResponse{
+		writeJSON(w, errorStatus(err), models.IdentityResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.IdentityResponse{
+		Success: true, Fact: fact,
+	})
+}
+
+// IdentitySchema handles GET /identity/schema
+func (h *Handler) IdentitySchema(w http.ResponseWriter, r *http.Request) {
+	resp := models.IdentitySchemaResponse{Success: true, Fields: []schema.Field{}}
+	if reg := h.identity.Schema(); reg != nil {
+		resp.Strict, resp.Fields = reg.Strict(), reg.Fields()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ListIdentity handles POST /identity/list
+func (h *Handler) ListIdentity(w http.ResponseWriter, r *http.Request) {
+	var req models.IdentityListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.IdentityListResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	facts, err := h.identity.List(r.Context(), req.UserID)
+	writeIdentityList(w, facts, err)
+}
+
+// BatchGetIdentity handles POST /identity/batch
+func (h *Handler) BatchGetIdentity(w http.ResponseWriter, r *http.Request) {
+	var req models.IdentityBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.IdentityListResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	facts, err := h.identity.GetMany(r.Context(), req.UserID, req.Keys)
+	writeIdentityList(w, facts, err)
+}
+
+func writeIdentityList(w http.ResponseWriter, facts []models.IdentityFact, err error) {
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.IdentityListResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+	if facts == nil {
+		facts = []models.IdentityFact{}
+	}
+
+	writeJSON(w, http.StatusOK, models.IdentityListResponse{
+		Success: true, Facts: facts, Count: len(facts),
+	})
+}
+
+// SetIdentity handles POST /identity/set
+func (h *Handler) SetIdentity(w http.ResponseWriter, r *http.Request) {
+	var req models.IdentitySetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.IdentityResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	fact := &models.IdentityFact{
+		UserID:    req.UserID,
+		Key:       req.Key,
+		Value:     req.Value,
+		Immutable: req.Immutable,
+		ChangedBy: req.ChangedBy,
+		Source:    retrieval.SourceAPI,
+	}
+	if err := h.identity.Set(r.Context(), fact); err != nil {
+		writeJSON(w, errorStatus(err), models.IdentityResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.IdentityResponse{
+		Success: true, Fact: fact,
+	})
+}
+
+// DeleteIdentity handles DELETE /identity/{key}
+func (h *Handler) DeleteIdentity(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	err := h.identity.Delete(r.Context(), q.Get("user_id"), r.PathValue("key"), q.Get("changed_by"))
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.IdentityDeleteResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.IdentityDeleteResponse{Success: true})
+}
+
+// IdentityHistory handles POST /identity/history
+func (h *Handler) IdentityHistory(w http.ResponseWriter, r *http.Request) {
+	var req models.IdentityHistoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.IdentityHistoryResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	revs, err := h.identity.History(r.Context(), req.UserID, req.Key)
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.IdentityHistoryResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+	if revs == nil {
+		revs = []models.IdentityRevision{}
+	}
+
+	writeJSON(w, http.StatusOK, models.IdentityHistoryResponse{
+		Success: true, Revisions: revs,
+	})
+}
+
+// RollbackIdentity handles POST /identity/rollback
+func (h *Handler) RollbackIdentity(w http.ResponseWriter, r *http.Request) {
+	var req models.IdentityRollbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.IdentityResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	fact, err := h.identity.Rollback(r.Context(), req.UserID, req.Key, req.Version, req.ChangedBy)
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.IdentityResponse{
 			Success: false, Error: err.Error(),
 		})
 		return
@@ -79,9 +247,17 @@ func (h *Handler) SearchMemory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := h.memory.Search(r.Context(), req.UserID, req.ReplicaID, req.Query, req.TopK)
+	opts := retrieval.SearchOptions{
+		Filter:              req.Filters,
+		Cursor:              req.Cursor,
+		RecencyWeight:       req.RecencyWeight,
+		RecencyHalfLife:     time.Duration(req.RecencyHalfLifeDays * float64(24*time.Hour)),
+		ReinforcementWeight: req.ReinforcementWeight,
+		Explain:             req.Explain,
+	}
+	results, next, err := h.memory.Search(r.Context(), req.UserID, req.ReplicaID, req.Query, req.TopK, opts)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, models.MemorySearchResponse{
+		writeJSON(w, errorStatus(err), models.MemorySearchResponse{
 			Success: false, Error: err.Error(),
 		})
 		return
@@ -89,9 +265,38 @@ func (h *Handler) SearchMemory(w http.ResponseWriter, r *http.Request) {
 	if results == nil {
 		results = []models.ScoredChunk{}
 	}
+	for i := range results {
+		results[i].Chunk = publicChunk(results[i].Chunk)
+	}
 
 	writeJSON(w, http.StatusOK, models.MemorySearchResponse{
-		Success: true, Results: results,
+		Success: true, Results: results, NextCursor: next,
+	})
+}
+
+// ListMemory handles POST /memory/list
+func (h *Handler) ListMemory(w http.ResponseWriter, r *http.Request) {
+	var req models.MemoryListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.MemoryListResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	chunks, next, err := h.memory.List(r.Context(), req.UserID, req.ReplicaID, req.Filters, req.Limit, req.Cursor)
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.MemoryListResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+	if chunks == nil {
+		chunks = []models.MemoryChunk{}
+	}
+
+	writeJSON(w, http.StatusOK, models.MemoryListResponse{
+		Success: true, Chunks: publicChunks(chunks), NextCursor: next,
 	})
 }
 
@@ -105,27 +310,144 @@ func (h *Handler) StoreMemory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chunkID, err := h.memory.Store(
-		r.Context(),
-		req.UserID,
-		req.ReplicaID,
-		req.Content,
-		req.Source,
-		req.SessionID,
-		req.Importance,
-	)
+	// The idempotency record keeps the whole result, as JSON.
+	result, replayed, err := h.idem.Do(r.Context(), req.UserID, idempotency.OpMemory, req.IdempotencyKey, func(ctx context.Context) (string, error) {
+		stored, err := h.memory.Store(ctx, req.UserID, req.ReplicaID, req.Content, req.Source, req.SessionID, req.Importance)
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(stored)
+		return string(data), err
+	})
+	var stored retrieval.StoreResult
+	if err == nil {
+		stored, err = decodeStoreResult(result)
+	}
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, models.MemoryStoreResponse{
+		writeJSON(w, errorStatus(err), models.MemoryStoreResponse{
 			Success: false, Error: err.Error(),
 		})
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, models.MemoryStoreResponse{
-		Success: true, ChunkID: chunkID,
+	writeJSON(w, createdStatus(replayed), models.MemoryStoreResponse{
+		Success:    true,
+		ChunkID:    stored.ChunkIDs[0],
+		ChunkIDs:   stored.ChunkIDs,
+		DocumentID: stored.DocumentID,
+		Replayed:   replayed,
 	})
 }
 
+// UpdateMemory handles PUT /memory/{chunk_id}
+func (h *Handler) UpdateMemory(w http.ResponseWriter, r *http.Request) {
+	var req models.MemoryUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.MemoryUpdateResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	// Document chunks change only through their document
+	chunkID := r.PathValue("chunk_id")
+	err := h.documents.EnsureStandalone(r.Context(), req.UserID, chunkID)
+	var chunk *models.MemoryChunk
+	if err == nil {
+		chunk, err = h.memory.Update(r.Context(), req.UserID, chunkID, req.Content, req.Importance)
+	}
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.MemoryUpdateResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	public := publicChunk(*chunk)
+	writeJSON(w, http.StatusOK, models.MemoryUpdateResponse{
+		Success: true, Chunk: &public,
+	})
+}
+
+// DeleteMemory handles DELETE /memory/{chunk_id}?user_id=...
+func (h *Handler) DeleteMemory(w http.ResponseWriter, r *http.Request) {
+	userID, chunkID := r.URL.Query().Get("user_id"), r.PathValue("chunk_id")
+	err := h.documents.EnsureStandalone(r.Context(), userID, chunkID)
+	if err == nil {
+		err = h.memory.Delete(r.Context(), userID, chunkID)
+	}
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.MemoryDeleteResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.MemoryDeleteResponse{Success: true})
+}
+
+// IngestDocument handles POST /documents/ingest
+func (h *Handler) IngestDocument(w http.ResponseWriter, r *http.Request) {
+	var req models.DocumentIngestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.DocumentResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	var doc *models.Document
+	documentID, replayed, err := h.idem.Do(r.Context(), req.UserID, idempotency.OpDocument, req.IdempotencyKey, func(ctx context.Context) (string, error) {
+		ingested, err := h.documents.Ingest(ctx, &req)
+		if err != nil {
+			return "", err
+		}
+		doc = ingested
+		return doc.DocumentID, nil
+	})
+	if err == nil && replayed {
+		doc, _, err = h.documents.Get(r.Context(), req.UserID, documentID)
+	}
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.DocumentResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, createdStatus(replayed), models.DocumentResponse{
+		Success: true, Document: doc, Replayed: replayed,
+	})
+}
+
+// GetDocument handles GET /documents/{document_id}?user_id=...
+func (h *Handler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	doc, chunks, err := h.documents.Get(r.Context(), r.URL.Query().Get("user_id"), r.PathValue("document_id"))
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.DocumentResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.DocumentResponse{
+		Success: true, Document: doc, Chunks: publicChunks(chunks),
+	})
+}
+
+// DeleteDocument handles DELETE /documents/{document_id}?user_id=...
+func (h *Handler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	err := h.documents.Delete(r.Context(), r.URL.Query().Get("user_id"), r.PathValue("document_id"))
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.DocumentDeleteResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.DocumentDeleteResponse{Success: true})
+}
+
 // ProcessSession handles POST /session/process
 func (h *Handler) ProcessSession(w http.ResponseWriter, r *http.Request) {
 	var req models.SessionProcessRequest
@@ -136,19 +458,212 @@ func (h *Handler) ProcessSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sessionID, err := h.session.Process(r.Context(), req.UserID, req.Messages)
+	if req.Async {
+		jobID, replayed, err := h.idem.Do(r.Context(), req.UserID, idempotency.OpSessionAsync, req.IdempotencyKey, func(ctx context.Context) (string, error) {
+			job, err := h.jobs.Enqueue(ctx, req.UserID, req.Messages)
+			if err != nil {
+				return "", err
+			}
+			return job.JobID, nil
+		})
+		if err != nil {
+			writeJSON(w, errorStatus(err), models.SessionProcessResponse{
+				Success: false, Error: err.Error(),
+			})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, models.SessionProcessResponse{
+			Success: true, JobID: jobID, Replayed: replayed,
+		})
+		return
+	}
+
+	sessionID, replayed, err := h.idem.Do(r.Context(), req.UserID, idempotency.OpSession, req.IdempotencyKey, func(ctx context.Context) (string, error) {
+		return h.session.Process(ctx, req.UserID, req.Messages)
+	})
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.SessionProcessResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, createdStatus(replayed), models.SessionProcessResponse{
+		Success: true, SessionID: sessionID, Replayed: replayed,
+	})
+}
+
+// GetJob handles GET /jobs/{job_id}?user_id=...
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(r.Context(), r.URL.Query().Get("user_id"), r.PathValue("job_id"))
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.JobStatusResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.JobStatusResponse{
+		Success:    true,
+		JobID:      job.JobID,
+		Status:     job.Status,
+		Attempts:   job.Attempts,
+		SessionID:  job.SessionID,
+		LastError:  job.LastError,
+		CreatedAt:  &job.CreatedAt,
+		UpdatedAt:  &job.UpdatedAt,
+		FinishedAt: job.FinishedAt,
+	})
+}
+
+// ListReviews handles GET /reviews?user_id=...
+func (h *Handler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	items, err := h.reviews.ListPending(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.ReviewListResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+	if items == nil {
+		items = []models.ReviewItem{}
+	}
+
+	writeJSON(w, http.StatusOK, models.ReviewListResponse{
+		Success: true, Reviews: items, Count: len(items),
+	})
+}
+
+// GetReview handles GET /reviews/{session_id}?user_id=...
+func (h *Handler) GetReview(w http.ResponseWriter, r *http.Request) {
+	item, err := h.reviews.Get(r.Context(), r.URL.Query().Get("user_id"), r.PathValue("session_id"))
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.ReviewResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ReviewResponse{
+		Success: true, Review: item,
+	})
+}
+
+// ApproveReview handles POST /reviews/{session_id}/approve
+func (h *Handler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	h.decideReview(w, r, h.reviews.Approve)
+}
+
+// RejectReview handles POST /reviews/{session_id}/reject
+func (h *Handler) RejectReview(w http.ResponseWriter, r *http.Request) {
+	h.decideReview(w, r, h.reviews.Reject)
+}
+
+// DecideReview handles POST /reviews/{session_id}/decisions
+func (h *Handler) DecideReview(w http.ResponseWriter, r *http.Request) {
+	var req models.ReviewDecisionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ReviewResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	item, err := h.reviews.Decide(r.Context(), req.UserID, r.PathValue("session_id"), req.Decisions)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, models.SessionProcessResponse{
+		writeJSON(w, errorStatus(err), models.ReviewResponse{
 			Success: false, Error: err.Error(),
 		})
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, models.SessionProcessResponse{
-		Success: true, SessionID: sessionID,
+	writeJSON(w, http.StatusOK, models.ReviewResponse{
+		Success: true, Review: item,
 	})
 }
 
+func (h *Handler) decideReview(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, userID, sessionID string) (*models.ReviewItem, error)) {
+	var req models.ReviewDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ReviewResponse{
+			Success: false, Error: "invalid request body",
+		})
+		return
+	}
+
+	item, err := decide(r.Context(), req.UserID, r.PathValue("session_id"))
+	if err != nil {
+		writeJSON(w, errorStatus(err), models.ReviewResponse{
+			Success: false, Error: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ReviewResponse{
+		Success: true, Review: item,
+	})
+}
+
+// decodeStoreResult reads the result of an OpMemory record. Records written
+// before stores could return several chunks hold the bare chunk ID.
+func decodeStoreResult(result string) (retrieval.StoreResult, error) {
+	var stored retrieval.StoreResult
+	if !strings.HasPrefix(result, "{") {
+		stored.ChunkIDs = []string{result}
+		return stored, nil
+	}
+	if err := json.Unmarshal([]byte(result), &stored); err != nil {
+		return stored, fmt.Errorf("corrupt idempotency record: %w", err)
+	}
+	if len(stored.ChunkIDs) == 0 {
+		return stored, errors.New("corrupt idempotency record: no chunk IDs")
+	}
+	return stored, nil
+}
+
+// errorStatus maps a service error to an HTTP status code.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrMissingField), errors.Is(err, models.ErrTooMany),
+		errors.Is(err, storage.ErrInvalidCursor), errors.Is(err, review.ErrInvalidDecision),
+		errors.Is(err, schema.ErrInvalidValue), errors.Is(err, schema.ErrUnknownKey),
+		errors.Is(err, idempotency.ErrInvalidKey), errors.Is(err, documents.ErrInvalidDocument):
+		return http.StatusBadRequest
+	case errors.Is(err, retrieval.ErrMemoryNotFound), errors.Is(err, retrieval.ErrRevisionNotFound),
+		errors.Is(err, retrieval.ErrIdentityNotFound), errors.Is(err, review.ErrNotFound),
+		errors.Is(err, jobs.ErrNotFound), errors.Is(err, documents.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, review.ErrAlreadyDecided), errors.Is(err, retrieval.ErrImmutable),
+		errors.Is(err, idempotency.ErrInProgress), errors.Is(err, documents.ErrDocumentChunk):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// createdStatus is 201 for a new resource and 200 when an idempotency key
+// replayed an earlier one.
+func createdStatus(replayed bool) int {
+	if replayed {
+		return http.StatusOK
+	}
+	return http.StatusCreated
+}
+
+// publicChunk drops the fields only search uses. The embedding vector and
+// term frequencies are large and mean nothing to clients.
+func publicChunk(c models.MemoryChunk) models.MemoryChunk {
+	c.Embedding, c.TermFreq = nil, nil
+	return c
+}
+
+// publicChunks applies publicChunk to each chunk, in place.
+func publicChunks(chunks []models.MemoryChunk) []models.MemoryChunk {
+	for i := range chunks {
+		chunks[i] = publicChunk(chunks[i])
+	}
+	return chunks
+}
+
 // writeJSON is a small helper to write JSON responses.
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
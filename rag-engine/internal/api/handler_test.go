@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/documents"
+	"github.com/memory-lane/rag-engine/internal/idempotency"
+	"github.com/memory-lane/rag-engine/internal/jobs"
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/review"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+// newServer serves the memory endpoints from an in-memory store.
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return newServerWith(t, storage.NewMemoryStorage())
+}
+
+// newServerWith serves the memory endpoints from store.
+func newServerWith(t *testing.T, store storage.Storage) *httptest.Server {
+	t.Helper()
+	identity := retrieval.NewIdentityService(store, nil)
+	memory := retrieval.NewMemoryService(store, nil, nil)
+	h := NewHandler(identity, memory, nil, review.NewService(store, identity, memory),
+		documents.NewService(store, memory), jobs.NewQueue(store, nil), idempotency.NewGuard(store), "memory")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /identity/get", h.GetIdentity)
+	mux.HandleFunc("POST /identity/batch", h.BatchGetIdentity)
+	mux.HandleFunc("POST /memory/search", h.SearchMemory)
+	mux.HandleFunc("POST /memory/store", h.StoreMemory)
+	mux.HandleFunc("POST /memory/list", h.ListMemory)
+	mux.HandleFunc("PUT /memory/{chunk_id}", h.UpdateMemory)
+	mux.HandleFunc("DELETE /memory/{chunk_id}", h.DeleteMemory)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// call sends body as JSON and decodes the response into out.
+func call(t *testing.T, srv *httptest.Server, method, path string, body, out any) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, srv.URL+path, &buf)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestMemoryEndpoints(t *testing.T) {
+	srv := newServer(t)
+
+	var stored models.MemoryStoreResponse
+	status := call(t, srv, "POST", "/memory/store", models.MemoryStoreRequest{
+		UserID: "u1", ReplicaID: "r1", Content: "We had a dog called Biscuit", Importance: 0.5, Source: "manual",
+	}, &stored)
+	if status != http.StatusCreated || !stored.Success || stored.ChunkID == "" {
+		t.Fatalf("store = %d %+v", status, stored)
+	}
+
+	var list models.MemoryListResponse
+	if status := call(t, srv, "POST", "/memory/list", models.MemoryListRequest{UserID: "u1", ReplicaID: "r1"}, &list); status != http.StatusOK || len(list.Chunks) != 1 {
+		t.Fatalf("list = %d %+v", status, list)
+	}
+
+	var updated models.MemoryUpdateResponse
+	status = call(t, srv, "PUT", "/memory/"+stored.ChunkID, models.MemoryUpdateRequest{UserID: "u1", Content: "We had a spaniel called Biscuit"}, &updated)
+	if status != http.StatusOK || updated.Chunk == nil || updated.Chunk.Content != "We had a spaniel called Biscuit" {
+		t.Fatalf("update = %d %+v", status, updated)
+	}
+
+	var found models.MemorySearchResponse
+	status = call(t, srv, "POST", "/memory/search", models.MemorySearchRequest{UserID: "u1", ReplicaID: "r1", Query: "spaniel"}, &found)
+	if status != http.StatusOK || len(found.Results) != 1 || found.Results[0].Chunk.ChunkID != stored.ChunkID {
+		t.Fatalf("search = %d %+v", status, found)
+	}
+	if c := found.Results[0].Chunk; c.TermFreq != nil || c.Embedding != nil {
+		t.Errorf("search returned the chunk's index data: %+v", c)
+	}
+
+	var deleted models.MemoryDeleteResponse
+	if status := call(t, srv, "DELETE", "/memory/"+stored.ChunkID+"?user_id=u1", nil, &deleted); status != http.StatusOK || !deleted.Success {
+		t.Fatalf("delete = %d %+v", status, deleted)
+	}
+	if status := call(t, srv, "DELETE", "/memory/"+stored.ChunkID+"?user_id=u1", nil, &deleted); status != http.StatusNotFound {
+		t.Errorf("second delete = %d, want 404", status)
+	}
+}
+
+func TestMissingFieldsAreBadRequest(t *testing.T) {
+	srv := newServer(t)
+	cases := []struct {
+		method, path string
+		body         any
+	}{
+		{"POST", "/identity/get", models.IdentityRequest{UserID: "u1"}},
+		{"POST", "/memory/store", models.MemoryStoreRequest{UserID: "u1"}},
+		{"POST", "/memory/search", models.MemorySearchRequest{UserID: "u1"}},
+		{"POST", "/memory/list", models.MemoryListRequest{}},
+		{"PUT", "/memory/c1", models.MemoryUpdateRequest{UserID: "u1"}},
+		{"DELETE", "/memory/c1", nil},
+	}
+	for _, tc := range cases {
+		var resp struct {
+			Success bool   `json:"success"`
+			Error   string `json:"error"`
+		}
+		if status := call(t, srv, tc.method, tc.path, tc.body, &resp); status != http.StatusBadRequest || resp.Success || resp.Error == "" {
+			t.Errorf("%s %s = %d %+v, want 400 with an error", tc.method, tc.path, status, resp)
+		}
+	}
+}
+
+func TestTooManyIdentityKeysIsBadRequest(t *testing.T) {
+	srv := newServer(t)
+	keys := make([]string, 101)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+	var resp models.IdentityListResponse
+	if status := call(t, srv, "POST", "/identity/batch", models.IdentityBatchRequest{UserID: "u1", Keys: keys}, &resp); status != http.StatusBadRequest || resp.Success {
+		t.Errorf("batch of %d keys = %d %+v, want 400", len(keys), status, resp)
+	}
+}
+
+func TestUnknownChunkIsNotFound(t *testing.T) {
+	srv := newServer(t)
+	var resp models.MemoryUpdateResponse
+	if status := call(t, srv, "PUT", "/memory/missing", models.MemoryUpdateRequest{UserID: "u1", Content: "x"}, &resp); status != http.StatusNotFound {
+		t.Errorf("update of an unknown chunk = %d, want 404", status)
+	}
+}
+
+func TestStoreReplaysChunkIDRecords(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	// Written before the result was a StoreResult: just the chunk ID.
+	rec := &models.IdempotencyRecord{UserID: "u1", Key: idempotency.OpMemory + ":k1", CreatedAt: time.Now()}
+	if _, err := store.ReserveIdempotencyKey(ctx, rec); err != nil {
+		t.Fatalf("ReserveIdempotencyKey: %v", err)
+	}
+	if err := store.CompleteIdempotencyKey(ctx, "u1", rec.Key, "u1-123"); err != nil {
+		t.Fatalf("CompleteIdempotencyKey: %v", err)
+	}
+	srv := newServerWith(t, store)
+
+	var stored models.MemoryStoreResponse
+	status := call(t, srv, "POST", "/memory/store", models.MemoryStoreRequest{
+		UserID: "u1", Content: "We had a dog called Biscuit", Source: "manual", IdempotencyKey: "k1",
+	}, &stored)
+	if status != http.StatusOK || !stored.Replayed || stored.ChunkID != "u1-123" || len(stored.ChunkIDs) != 1 {
+		t.Errorf("replayed store = %d %+v, want the recorded chunk", status, stored)
+	}
+}
+
+func TestStoreRejectsCorruptRecords(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	rec := &models.IdempotencyRecord{UserID: "u1", Key: idempotency.OpMemory + ":k1", CreatedAt: time.Now()}
+	if _, err := store.ReserveIdempotencyKey(ctx, rec); err != nil {
+		t.Fatalf("ReserveIdempotencyKey: %v", err)
+	}
+	if err := store.CompleteIdempotencyKey(ctx, "u1", rec.Key, `{"chunk_ids":[]}`); err != nil {
+		t.Fatalf("CompleteIdempotencyKey: %v", err)
+	}
+	srv := newServerWith(t, store)
+
+	var stored models.MemoryStoreResponse
+	status := call(t, srv, "POST", "/memory/store", models.MemoryStoreRequest{
+		UserID: "u1", Content: "We had a dog called Biscuit", Source: "manual", IdempotencyKey: "k1",
+	}, &stored)
+	if status != http.StatusInternalServerError || stored.Success {
+		t.Errorf("replay of a record without chunks = %d %+v, want 500", status, stored)
+	}
+}
+
+func TestDocumentChunksChangeOnlyWithTheDocument(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	srv := newServerWith(t, store)
+	docs := documents.NewService(store, retrieval.NewMemoryService(store, nil, nil))
+	doc, err := docs.Ingest(context.Background(), &models.DocumentIngestRequest{UserID: "u1", Content: "Dear Joan, we were married on Saturday."})
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	chunkID := doc.ChunkIDs[0]
+
+	var updated models.MemoryUpdateResponse
+	if status := call(t, srv, "PUT", "/memory/"+chunkID, models.MemoryUpdateRequest{UserID: "u1", Content: "Dear Joan"}, &updated); status != http.StatusConflict {
+		t.Errorf("update of a document chunk = %d %+v, want 409", status, updated)
+	}
+	var deleted models.MemoryDeleteResponse
+	if status := call(t, srv, "DELETE", "/memory/"+chunkID+"?user_id=u1", nil, &deleted); status != http.StatusConflict {
+		t.Errorf("delete of a document chunk = %d %+v, want 409", status, deleted)
+	}
+	if chunk, _ := store.GetMemory(context.Background(), "u1", chunkID); chunk == nil || chunk.Content != "Dear Joan, we were married on Saturday." {
+		t.Errorf("document chunk = %+v, want it unchanged", chunk)
+	}
+}
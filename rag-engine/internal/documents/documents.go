@@ -0,0 +1,211 @@
+// Package documents ingests whole documents — letters, eulogies, journals —
+// as memory. A document's text is cleaned up, chunked and stored as file
+// memories; a document record keeps its metadata and chunk IDs so the
+// chunks can be fetched and deleted together.
+package documents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/schema"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+var (
+	// ErrNotFound is returned for unknown documents and for documents that
+	// belong to another user.
+	ErrNotFound = errors.New("document not found")
+	// ErrInvalidDocument is returned for an unknown format, malformed front
+	// matter, an invalid date or a document without text.
+	ErrInvalidDocument = errors.New("invalid document")
+	// ErrDocumentChunk is returned by EnsureStandalone for a chunk that
+	// belongs to an ingested document.
+	ErrDocumentChunk = errors.New("chunk belongs to a document; delete the document instead")
+)
+
+// Formats accepted by Ingest.
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatPDFText  = "pdf_text" // text extracted from a PDF
+)
+
+const defaultImportance = 0.5
+
+// Service ingests, fetches and deletes documents.
+type Service struct {
+	store  storage.Storage
+	memory *retrieval.MemoryService
+}
+
+// NewService creates a new document service.
+func NewService(store storage.Storage, memory *retrieval.MemoryService) *Service {
+	return &Service{store: store, memory: memory}
+}
+
+// Ingest parses a document's front matter, converts its body to plain text
+// and stores it as chunks under a new document record. Fields set on the
+// request override those from the front matter.
+func (s *Service) Ingest(ctx context.Context, req *models.DocumentIngestRequest) (*models.Document, error) {
+	if req.UserID == "" || req.Content == "" {
+		return nil, models.Required("user_id", "content")
+	}
+	format := req.Format
+	if format == "" {
+		format = FormatText
+	}
+
+	meta, body, err := parseFrontMatter(req.Content)
+	if err != nil {
+		return nil, err
+	}
+	doc := &models.Document{
+		UserID:    req.UserID,
+		ReplicaID: req.ReplicaID,
+		Format:    format,
+		Title:     meta.Title,
+		Date:      meta.Date,
+		People:    meta.People,
+		Tags:      meta.Tags,
+		Metadata:  meta.Extra,
+	}
+	if req.Title != "" {
+		doc.Title = req.Title
+	}
+	if req.Date != "" {
+		doc.Date = req.Date
+	}
+	if len(req.People) > 0 {
+		doc.People = req.People
+	}
+	if doc.Date != "" {
+		date, ok := schema.ParseDate(doc.Date)
+		if !ok {
+			return nil, fmt.Errorf("%w: date %q is not YYYY, YYYY-MM or YYYY-MM-DD", ErrInvalidDocument, doc.Date)
+		}
+		doc.Date = date
+	}
+
+	var text string
+	switch format {
+	case FormatText:
+		text = cleanText(body)
+	case FormatMarkdown:
+		text = markdownToText(body)
+	case FormatPDFText:
+		text = cleanPDFText(body)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidDocument, format)
+	}
+	if text == "" {
+		return nil, fmt.Errorf("%w: no text after front matter", ErrInvalidDocument)
+	}
+
+	importance := defaultImportance
+	if req.Importance != nil {
+		importance = *req.Importance
+	}
+
+	now := time.Now()
+	doc.DocumentID = retrieval.NewDocumentID(req.UserID, now)
+	doc.CreatedAt = now
+	doc.ChunkIDs, err = s.memory.StoreDocument(ctx, req.UserID, req.ReplicaID, doc.DocumentID, text, importance)
+	if err != nil {
+		return nil, fmt.Errorf("store chunks: %w", err)
+	}
+	if err := s.store.StoreDocument(ctx, doc); err != nil {
+		s.deleteChunks(context.WithoutCancel(ctx), req.UserID, doc.ChunkIDs)
+		return nil, fmt.Errorf("store document: %w", err)
+	}
+	return doc, nil
+}
+
+// Get returns one of a user's documents and its chunks in order. Chunks
+// deleted individually since ingestion are left out.
+func (s *Service) Get(ctx context.Context, userID, documentID string) (*models.Document, []models.MemoryChunk, error) {
+	doc, err := s.get(ctx, userID, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	chunks := make([]models.MemoryChunk, 0, len(doc.ChunkIDs))
+	for _, id := range doc.ChunkIDs {
+		chunk, err := s.store.GetMemory(ctx, userID, id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get chunk %s: %w", id, err)
+		}
+		if chunk != nil {
+			chunks = append(chunks, *chunk)
+		}
+	}
+	return doc, chunks, nil
+}
+
+// Delete removes one of a user's documents together with its chunks. The
+// record goes last, so a failed delete can be retried.
+func (s *Service) Delete(ctx context.Context, userID, documentID string) error {
+	doc, err := s.get(ctx, userID, documentID)
+	if err != nil {
+		return err
+	}
+	for _, id := range doc.ChunkIDs {
+		if err := s.memory.Delete(ctx, userID, id); err != nil && !errors.Is(err, retrieval.ErrMemoryNotFound) {
+			return fmt.Errorf("delete chunk %s: %w", id, err)
+		}
+	}
+	if err := s.store.DeleteDocument(ctx, documentID); err != nil {
+		return fmt.Errorf("delete document: %w", err)
+	}
+	return nil
+}
+
+// EnsureStandalone returns ErrDocumentChunk when a user's chunk belongs to
+// one of their ingested documents. Such chunks are changed only through the
+// document, so its record keeps listing chunks that exist. Unknown chunks,
+// and pieces of split memories, which have no document record, pass.
+func (s *Service) EnsureStandalone(ctx context.Context, userID, chunkID string) error {
+	if userID == "" || chunkID == "" {
+		return nil // left for the memory service to reject
+	}
+	chunk, err := s.store.GetMemory(ctx, userID, chunkID)
+	if err != nil || chunk == nil || chunk.DocumentID == "" {
+		return err
+	}
+	doc, err := s.store.GetDocument(ctx, chunk.DocumentID)
+	if err != nil {
+		return err
+	}
+	if doc != nil && doc.UserID == userID {
+		return fmt.Errorf("%s: %w", chunkID, ErrDocumentChunk)
+	}
+	return nil
+}
+
+func (s *Service) get(ctx context.Context, userID, documentID string) (*models.Document, error) {
+	if userID == "" || documentID == "" {
+		return nil, models.Required("user_id", "document_id")
+	}
+	doc, err := s.store.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || doc.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return doc, nil
+}
+
+// deleteChunks removes the chunks of a document whose record could not be
+// written.
+func (s *Service) deleteChunks(ctx context.Context, userID string, chunkIDs []string) {
+	for _, id := range chunkIDs {
+		if err := s.memory.Delete(ctx, userID, id); err != nil {
+			log.Printf("⚠️  Failed to remove chunk %s of a failed ingest: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/memory-lane/rag-engine/internal/index"
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+const letter = `---
+title: "Letter to Joan"
+date: 1962-06-14
+people: [Joan, 'Frank']
+tags:
+  - wedding
+  - Leeds
+place: St. Mary's Church # where it was sent from
+---
+# Dear Joan
+
+We were married on **Saturday** at [St. Mary's](https://example.org/church).
+The _whole_ street came out.
+
+- Frank's mother cried
+- Dad wore his ` + "`best`" + ` suit
+`
+
+func TestParseFrontMatter(t *testing.T) {
+	fm, body, err := parseFrontMatter(letter)
+	if err != nil {
+		t.Fatalf("parseFrontMatter: %v", err)
+	}
+	want := frontMatter{
+		Title:  "Letter to Joan",
+		Date:   "1962-06-14",
+		People: []string{"Joan", "Frank"},
+		Tags:   []string{"wedding", "Leeds"},
+		Extra:  map[string]string{"place": "St. Mary's Church"},
+	}
+	if !reflect.DeepEqual(fm, want) {
+		t.Errorf("front matter = %+v, want %+v", fm, want)
+	}
+	if !strings.HasPrefix(body, "# Dear Joan\n") {
+		t.Errorf("body = %q, want it to start after the block", body)
+	}
+}
+
+func TestParseFrontMatterAbsent(t *testing.T) {
+	for _, content := range []string{"Dear Joan,\n---\nLove", "---\ntitle: never closed\n"} {
+		fm, body, err := parseFrontMatter(content)
+		if err != nil || body != content || fm.Title != "" {
+			t.Errorf("parseFrontMatter(%q) = %+v, %q, %v; want the content unchanged", content, fm, body, err)
+		}
+	}
+	if _, _, err := parseFrontMatter("---\njust some words\n---\nbody"); !errors.Is(err, ErrInvalidDocument) {
+		t.Errorf("malformed front matter = %v, want ErrInvalidDocument", err)
+	}
+}
+
+func TestMarkdownToText(t *testing.T) {
+	_, body, _ := parseFrontMatter(letter)
+	want := "Dear Joan\n\n" +
+		"We were married on Saturday at St. Mary's.\n" +
+		"The whole street came out.\n\n" +
+		"Frank's mother cried\n" +
+		"Dad wore his best suit"
+	if got := markdownToText(body); got != want {
+		t.Errorf("markdownToText =\n%s\nwant\n%s", got, want)
+	}
+
+	md := "Intro <!-- draft -->\n\n```\nkeep *this*\n```\n\n> quoted snake_case ![photo](a.jpg)\n\n***\n"
+	if got := markdownToText(md); got != "Intro\n\nkeep *this*\n\nquoted snake_case photo" {
+		t.Errorf("markdownToText(%q) = %q", md, got)
+	}
+}
+
+func TestCleanPDFText(t *testing.T) {
+	text := "We moved to Leeds in the sum-\nmer of 1962.\n\n3\n\fDad found work at the mill,\nPage 4 of 9\nin Armley."
+	want := "We moved to Leeds in the summer of 1962.\n\nDad found work at the mill,\n\nin Armley."
+	if got := cleanPDFText(text); got != want {
+		t.Errorf("cleanPDFText = %q, want %q", got, want)
+	}
+}
+
+func newService() (*Service, storage.Storage) {
+	store := storage.NewMemoryStorage()
+	memory := retrieval.NewMemoryService(store, nil, index.NewChunker(8, -1))
+	return NewService(store, memory), store
+}
+
+func TestIngestGetDelete(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newService()
+
+	doc, err := svc.Ingest(ctx, &models.DocumentIngestRequest{
+		UserID: "u1", ReplicaID: "r1", Content: letter, Format: FormatMarkdown,
+		People: []string{"Joan"},
+	})
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if doc.Title != "Letter to Joan" || doc.Date != "1962-06-14" || doc.Metadata["place"] != "St. Mary's Church" {
+		t.Errorf("document = %+v", doc)
+	}
+	if !reflect.DeepEqual(doc.People, []string{"Joan"}) {
+		t.Errorf("People = %v, want the request to override the front matter", doc.People)
+	}
+	if len(doc.ChunkIDs) < 2 {
+		t.Fatalf("ChunkIDs = %v, want the letter split into several", doc.ChunkIDs)
+	}
+
+	got, chunks, err := svc.Get(ctx, "u1", doc.DocumentID)
+	if err != nil || got.DocumentID != doc.DocumentID {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if len(chunks) != len(doc.ChunkIDs) {
+		t.Fatalf("Get returned %d chunks, want %d", len(chunks), len(doc.ChunkIDs))
+	}
+	for i, c := range chunks {
+		if c.ChunkID != doc.ChunkIDs[i] || c.Ordinal != i || c.DocumentID != doc.DocumentID || c.Source != retrieval.SourceFile {
+			t.Errorf("chunk %d = %+v", i, c)
+		}
+		if strings.Contains(c.Content, "**") {
+			t.Errorf("chunk %d kept Markdown: %q", i, c.Content)
+		}
+	}
+	if _, _, err := svc.Get(ctx, "u2", doc.DocumentID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(other user) = %v, want ErrNotFound", err)
+	}
+
+	// A chunk deleted on its own is skipped, and does not block the delete.
+	if err := store.DeleteMemory(ctx, "u1", doc.ChunkIDs[0]); err != nil {
+		t.Fatalf("DeleteMemory: %v", err)
+	}
+	if _, chunks, _ := svc.Get(ctx, "u1", doc.DocumentID); len(chunks) != len(doc.ChunkIDs)-1 {
+		t.Errorf("Get after a chunk delete returned %d chunks", len(chunks))
+	}
+	if err := svc.Delete(ctx, "u1", doc.DocumentID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for _, id := range doc.ChunkIDs {
+		if c, _ := store.GetMemory(ctx, "u1", id); c != nil {
+			t.Errorf("chunk %s survived the delete", id)
+		}
+	}
+	if _, _, err := svc.Get(ctx, "u1", doc.DocumentID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestIngestRejectsInvalid(t *testing.T) {
+	svc, _ := newService()
+	cases := map[string]models.DocumentIngestRequest{
+		"format":     {UserID: "u1", Content: "Dear Joan", Format: "docx"},
+		"date":       {UserID: "u1", Content: "Dear Joan", Date: "last summer"},
+		"empty body": {UserID: "u1", Content: "---\ntitle: Empty\n---\n\n"},
+	}
+	for name, req := range cases {
+		if _, err := svc.Ingest(context.Background(), &req); !errors.Is(err, ErrInvalidDocument) {
+			t.Errorf("%s: Ingest = %v, want ErrInvalidDocument", name, err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package documents
+
+import (
+	"fmt"
+	"strings"
+)
+
+// frontMatter is the metadata block at the top of a document.
+type frontMatter struct {
+	Title  string
+	Date   string
+	People []string
+	Tags   []string
+	Extra  map[string]string // other keys; lists are joined with ", "
+}
+
+// parseFrontMatter splits a leading front-matter block off content:
+//
+//	---
+//	title: Letter to Joan
+//	date: 1962-06-14
+//	people: [Joan, Frank]
+//	tags:
+//	  - wedding
+//	---
+//
+// It understands the simple subset of YAML such blocks use: "key: value"
+// lines, quoted values, inline "[a, b]" lists, "- item" lists and comments.
+// Content without a closed block is returned unchanged.
+func parseFrontMatter(content string) (frontMatter, string, error) {
+	var fm frontMatter
+	content = strings.TrimPrefix(content, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	rest, ok := strings.CutPrefix(strings.TrimLeft(content, "\n"), "---\n")
+	if !ok {
+		return fm, content, nil
+	}
+	var block []string
+	closed := false
+	for !closed && rest != "" {
+		line, tail, _ := strings.Cut(rest, "\n")
+		rest = tail
+		if t := strings.TrimRight(line, " \t"); t == "---" || t == "..." {
+			closed = true
+			break
+		}
+		block = append(block, line)
+	}
+	if !closed {
+		return fm, content, nil
+	}
+
+	values := make(map[string][]string)
+	var keys []string
+	var listKey string // key whose "- item" lines follow
+	for i, line := range block {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok || trimmed == "-" {
+			if listKey == "" {
+				return fm, "", fmt.Errorf("%w: front matter line %d: list item without a key", ErrInvalidDocument, i+2)
+			}
+			if v := unquote(item); v != "" {
+				values[listKey] = append(values[listKey], v)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return fm, "", fmt.Errorf("%w: front matter line %d: expected \"key: value\"", ErrInvalidDocument, i+2)
+		}
+		if _, seen := values[key]; !seen {
+			keys = append(keys, key)
+		}
+		value = strings.TrimSpace(value)
+		listKey = ""
+		switch {
+		case value == "":
+			values[key] = nil
+			listKey = key
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			values[key] = nil
+			for item := range strings.SplitSeq(value[1:len(value)-1], ",") {
+				if v := unquote(item); v != "" {
+					values[key] = append(values[key], v)
+				}
+			}
+		default:
+			values[key] = []string{unquote(value)}
+		}
+	}
+
+	for _, key := range keys {
+		vals := values[key]
+		switch key {
+		case "title":
+			fm.Title = strings.Join(vals, ", ")
+		case "date":
+			fm.Date = strings.Join(vals, ", ")
+		case "people":
+			fm.People = vals
+		case "tags":
+			fm.Tags = vals
+		default:
+			if len(vals) == 0 {
+				continue
+			}
+			if fm.Extra == nil {
+				fm.Extra = make(map[string]string)
+			}
+			fm.Extra[key] = strings.Join(vals, ", ")
+		}
+	}
+	return fm, rest, nil
+}
+
+// unquote trims a scalar and strips matching single or double quotes. An
+// unquoted value loses a trailing " # comment".
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
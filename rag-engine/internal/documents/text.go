@@ -0,0 +1,115 @@
+package documents
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	blankLines = regexp.MustCompile(`\n{3,}`)
+
+	// Markdown block syntax, matched per line.
+	mdFence      = regexp.MustCompile("^\\s*(```|~~~)")
+	mdHeading    = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	mdSetext     = regexp.MustCompile(`^\s{0,3}(=+|-+)\s*$`)
+	mdRule       = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	mdQuote      = regexp.MustCompile(`^\s*(>\s?)+`)
+	mdListItem   = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+	mdRefDef     = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*\S+`)
+	mdTableRule  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)+\|?\s*$`)
+	htmlComment  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlBreakTag = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+	// Markdown inline syntax.
+	mdImage     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink      = regexp.MustCompile(`\[([^\]]+)\](\([^)]*\)|\[[^\]]*\])`)
+	mdAutolink  = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	htmlTag     = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	mdCode      = regexp.MustCompile("`+([^`]+)`+")
+	mdStrong    = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	mdEmphStar  = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*`)
+	mdEmphUnder = regexp.MustCompile(`(^|[^\w])_(\S(?:[^_]*?\S)?)_([^\w]|$)`)
+	mdStrike    = regexp.MustCompile(`~~(.+?)~~`)
+	mdEscape    = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!>|~])")
+
+	// Artefacts of PDF text extraction.
+	hyphenBreak = regexp.MustCompile(`(\p{L})-\n[ \t]*(\p{Ll})`)
+	pageNumber  = regexp.MustCompile(`(?im)^[ \t]*(page[ \t]+)?\d+([ \t]+of[ \t]+\d+)?[ \t]*$`)
+)
+
+// cleanText normalises line endings and trailing space and trims runs of
+// blank lines down to one.
+func cleanText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	text = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// cleanPDFText undoes what PDF extraction does to running text: page breaks
+// become paragraph breaks, page-number lines are dropped and words
+// hyphenated across lines are joined again.
+func cleanPDFText(text string) string {
+	text = strings.ReplaceAll(text, "\f", "\n\n")
+	text = cleanText(text)
+	text = pageNumber.ReplaceAllString(text, "")
+	text = hyphenBreak.ReplaceAllString(text, "$1$2")
+	return cleanText(text)
+}
+
+// markdownToText strips Markdown syntax, keeping the text a reader would
+// see. Headings become paragraphs of their own, links and images keep their
+// text, and code blocks are kept verbatim.
+func markdownToText(md string) string {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	md = htmlComment.ReplaceAllString(md, "")
+
+	var out []string
+	inFence := false
+	for _, line := range strings.Split(md, "\n") {
+		if mdFence.MatchString(line) {
+			inFence = !inFence
+			out = append(out, "")
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		switch {
+		case mdRule.MatchString(line), mdSetext.MatchString(line),
+			mdRefDef.MatchString(line), mdTableRule.MatchString(line):
+			continue
+		case mdHeading.MatchString(line):
+			heading := mdHeading.FindStringSubmatch(line)[1]
+			out = append(out, "", inlineToText(heading), "")
+			continue
+		}
+		line = mdQuote.ReplaceAllString(line, "")
+		line = mdListItem.ReplaceAllString(line, "")
+		out = append(out, inlineToText(line))
+	}
+	return cleanText(strings.Join(out, "\n"))
+}
+
+// inlineToText strips Markdown and HTML inline syntax from one line.
+func inlineToText(s string) string {
+	s = htmlBreakTag.ReplaceAllString(s, " ")
+	s = mdImage.ReplaceAllString(s, "$1")
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdAutolink.ReplaceAllString(s, "$1")
+	s = htmlTag.ReplaceAllString(s, "")
+	s = mdCode.ReplaceAllString(s, "$1")
+	s = mdStrong.ReplaceAllString(s, "$2")
+	s = mdEmphStar.ReplaceAllString(s, "$1")
+	s = mdEmphUnder.ReplaceAllString(s, "$1$2$3")
+	s = mdStrike.ReplaceAllString(s, "$1")
+	s = mdEscape.ReplaceAllString(s, "$1")
+	s = strings.ReplaceAll(s, "|", " ")
+	return strings.TrimRight(s, " \t")
+}
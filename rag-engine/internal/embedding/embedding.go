@@ -0,0 +1,49 @@
+package embedding
+
+import (
+	"context"
+	"math"
+)
+
+// Embedder turns text into dense vectors for semantic search.
+type Embedder interface {
+	// Embed returns one vector per input text, in the same order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Name identifies the embedding space (model + dimensions). Vectors are
+	// only comparable when they were produced under the same name.
+	Name() string
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 when they have
+// different lengths or either is all zeros.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		na += x * x
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// normalize scales v to unit length in place.
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	inv := float32(1 / math.Sqrt(sum))
+	for i := range v {
+		v[i] *= inv
+	}
+}
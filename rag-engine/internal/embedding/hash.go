@@ -0,0 +1,106 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/memory-lane/rag-engine/internal/index"
+)
+
+// DefaultDimensions is the vector size used by HashEmbedder when none is given.
+const DefaultDimensions = 256
+
+// Feature weights for HashEmbedder.
+const (
+	wordWeight    = 1.0
+	trigramWeight = 0.4
+	conceptWeight = 1.0
+)
+
+// concepts maps family and everyday words onto shared concept features so
+// common paraphrases ("my boy" / "my son", "mum" / "mother") land close
+// together even though they share no characters.
+var concepts = map[string]string{
+	"son": "child-male", "boy": "child-male", "lad": "child-male",
+	"daughter": "child-female", "girl": "child-female", "lass": "child-female",
+	"kid": "child", "kids": "child", "child": "child", "children": "child",
+	"sons": "child", "daughters": "child", "boys": "child", "girls": "child",
+	"mother": "mother", "mum": "mother", "mom": "mother", "mam": "mother", "mummy": "mother", "mommy": "mother", "ma": "mother",
+	"father": "father", "dad": "father", "daddy": "father", "papa": "father", "pa": "father",
+	"grandmother": "grandmother", "grandma": "grandmother", "gran": "grandmother", "granny": "grandmother", "nan": "grandmother", "nana": "grandmother",
+	"grandfather": "grandfather", "grandpa": "grandfather", "grandad": "grandfather", "granddad": "grandfather", "gramps": "grandfather",
+	"wife": "spouse", "husband": "spouse", "spouse": "spouse", "partner": "spouse",
+	"brother": "sibling", "sister": "sibling", "sibling": "sibling", "siblings": "sibling",
+	"grandson": "grandchild", "granddaughter": "grandchild", "grandchild": "grandchild", "grandchildren": "grandchild", "grandkids": "grandchild",
+	"dog": "pet", "puppy": "pet", "cat": "pet", "kitten": "pet", "pet": "pet", "pets": "pet",
+	"house": "home", "home": "home", "flat": "home", "cottage": "home",
+	"job": "work", "work": "work", "career": "work", "worked": "work", "occupation": "work",
+	"wedding": "marriage", "married": "marriage", "marriage": "marriage", "wed": "marriage",
+	"holiday": "holiday", "vacation": "holiday", "trip": "holiday",
+	"garden": "garden", "gardening": "garden", "allotment": "garden",
+}
+
+// HashEmbedder is a deterministic, fully offline embedder. It hashes words,
+// character trigrams and concept features into a fixed number of buckets
+// (the "hashing trick") with a random sign per feature, then L2-normalises.
+// It captures shared vocabulary, morphology (garden/gardening) and a small
+// set of known paraphrases; it needs no model files and runs on any CPU.
+type HashEmbedder struct {
+	dims int
+}
+
+// NewHashEmbedder creates a hash embedder producing vectors of size dims.
+func NewHashEmbedder(dims int) *HashEmbedder {
+	if dims <= 0 {
+		dims = DefaultDimensions
+	}
+	return &HashEmbedder{dims: dims}
+}
+
+// Name identifies the embedding space.
+func (e *HashEmbedder) Name() string {
+	return fmt.Sprintf("hash-v1-%d", e.dims)
+}
+
+// Embed returns one vector per text.
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = e.embed(t)
+	}
+	return out, nil
+}
+
+func (e *HashEmbedder) embed(text string) []float32 {
+	v := make([]float32, e.dims)
+	freq, _ := index.TermFrequencies(text)
+	for word, n := range freq {
+		tf := float32(n)
+		e.add(v, "w:"+word, wordWeight*tf)
+
+		padded := "#" + word + "#"
+		for i := 0; i+3 <= len(padded); i++ {
+			e.add(v, "t:"+padded[i:i+3], trigramWeight*tf)
+		}
+
+		if c, ok := concepts[word]; ok {
+			e.add(v, "c:"+c, conceptWeight*tf)
+		}
+	}
+	normalize(v)
+	return v
+}
+
+// add hashes a feature into a bucket, using one hash bit for the sign so
+// collisions tend to cancel rather than accumulate.
+func (e *HashEmbedder) add(v []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	idx := int(sum % uint64(e.dims))
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	v[idx] += weight
+}
@@ -0,0 +1,110 @@
+// Package idempotency makes retried write requests safe. A client sends
+// the same key with every attempt of one logical request; the first attempt
+// does the work and the rest get its result back.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+var (
+	// ErrInProgress is returned while the first request with a key is running.
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+	// ErrInvalidKey is returned for keys that are too long.
+	ErrInvalidKey = errors.New("invalid idempotency key")
+)
+
+// Operations namespace keys, so one key may be reused across endpoints.
+const (
+	OpSession      = "session"       // POST /session/process; result is a session ID
+	OpSessionAsync = "session.async" // POST /session/process with async; result is a job ID
+	OpMemory       = "memory"        // POST /memory/store; result is StoreResult JSON (a bare chunk ID in older records)
+	OpDocument     = "document"      // POST /documents/ingest; result is a document ID
+)
+
+const (
+	maxKeyLength = 255
+	// staleAfter is how long a reservation may stay without a result before
+	// it is assumed abandoned (the server died mid-request) and taken over.
+	staleAfter = 10 * time.Minute
+)
+
+// Guard runs operations at most once per idempotency key.
+type Guard struct {
+	store storage.Storage
+}
+
+// NewGuard creates a guard that keeps its records in store.
+func NewGuard(store storage.Storage) *Guard {
+	return &Guard{store: store}
+}
+
+// Do runs fn unless the user already made operation op with key, and
+// returns the ID fn produced. For a repeat it returns the first ID with
+// replayed set. An empty key runs fn unguarded. A failed fn releases the
+// key so the client can retry.
+func (g *Guard) Do(ctx context.Context, userID, op, key string, fn func(context.Context) (string, error)) (id string, replayed bool, err error) {
+	if key == "" {
+		id, err = fn(ctx)
+		return id, false, err
+	}
+	if len(key) > maxKeyLength {
+		return "", false, fmt.Errorf("%w: longer than %d characters", ErrInvalidKey, maxKeyLength)
+	}
+	if userID == "" {
+		return "", false, models.Required("user_id")
+	}
+
+	scoped := op + ":" + key
+	existing, err := g.reserve(ctx, userID, scoped)
+	if err != nil {
+		return "", false, err
+	}
+	if existing != nil && existing.ResultID == "" && time.Since(existing.CreatedAt) > staleAfter {
+		log.Printf("⚠️  Taking over abandoned idempotency key %q for %s", scoped, userID)
+		if err := g.store.ReleaseIdempotencyKey(ctx, userID, scoped); err != nil {
+			return "", false, fmt.Errorf("release idempotency key: %w", err)
+		}
+		if existing, err = g.reserve(ctx, userID, scoped); err != nil {
+			return "", false, err
+		}
+	}
+	if existing != nil {
+		if existing.ResultID == "" {
+			return "", false, ErrInProgress
+		}
+		return existing.ResultID, true, nil
+	}
+
+	// The outcome is recorded even if the client has gone away meanwhile:
+	// it is the one most likely to retry.
+	recordCtx := context.WithoutCancel(ctx)
+	id, err = fn(ctx)
+	if err != nil {
+		if relErr := g.store.ReleaseIdempotencyKey(recordCtx, userID, scoped); relErr != nil {
+			log.Printf("⚠️  Failed to release idempotency key %q: %v", scoped, relErr)
+		}
+		return "", false, err
+	}
+	if err := g.store.CompleteIdempotencyKey(recordCtx, userID, scoped, id); err != nil {
+		// The work is done; a retry will wait out the stale reservation.
+		log.Printf("⚠️  Failed to record idempotency key %q: %v", scoped, err)
+	}
+	return id, false, nil
+}
+
+func (g *Guard) reserve(ctx context.Context, userID, key string) (*models.IdempotencyRecord, error) {
+	rec := &models.IdempotencyRecord{UserID: userID, Key: key, CreatedAt: time.Now().UTC()}
+	existing, err := g.store.ReserveIdempotencyKey(ctx, rec)
+	if err != nil {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+	return existing, nil
+}
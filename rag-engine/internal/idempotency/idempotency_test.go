@@ -0,0 +1,107 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+// counter returns an fn that yields a new ID on every call.
+func counter() (func(context.Context) (string, error), *int) {
+	calls := 0
+	return func(context.Context) (string, error) {
+		calls++
+		return fmt.Sprintf("id-%d", calls), nil
+	}, &calls
+}
+
+func TestDoReplaysResult(t *testing.T) {
+	ctx := context.Background()
+	g := NewGuard(storage.NewMemoryStorage())
+	fn, calls := counter()
+
+	id, replayed, err := g.Do(ctx, "u1", OpSession, "k1", fn)
+	if err != nil || id != "id-1" || replayed {
+		t.Fatalf("first Do = %q, %v, %v", id, replayed, err)
+	}
+	id, replayed, err = g.Do(ctx, "u1", OpSession, "k1", fn)
+	if err != nil || id != "id-1" || !replayed {
+		t.Fatalf("repeat Do = %q, %v, %v; want id-1 replayed", id, replayed, err)
+	}
+
+	// Keys are scoped by user and operation.
+	if id, _, _ := g.Do(ctx, "u2", OpSession, "k1", fn); id != "id-2" {
+		t.Errorf("other user got %q, want a new ID", id)
+	}
+	if id, _, _ := g.Do(ctx, "u1", OpMemory, "k1", fn); id != "id-3" {
+		t.Errorf("other operation got %q, want a new ID", id)
+	}
+	if *calls != 3 {
+		t.Errorf("fn ran %d times, want 3", *calls)
+	}
+}
+
+func TestDoWithoutKey(t *testing.T) {
+	g := NewGuard(storage.NewMemoryStorage())
+	fn, calls := counter()
+	for range 2 {
+		if _, replayed, err := g.Do(context.Background(), "u1", OpSession, "", fn); err != nil || replayed {
+			t.Fatalf("Do = %v, %v", replayed, err)
+		}
+	}
+	if *calls != 2 {
+		t.Errorf("fn ran %d times without a key, want 2", *calls)
+	}
+}
+
+func TestDoReleasesKeyOnFailure(t *testing.T) {
+	ctx := context.Background()
+	g := NewGuard(storage.NewMemoryStorage())
+	_, _, err := g.Do(ctx, "u1", OpSession, "k1", func(context.Context) (string, error) {
+		return "", errors.New("extractor down")
+	})
+	if err == nil {
+		t.Fatal("Do succeeded despite fn failing")
+	}
+
+	fn, _ := counter()
+	if id, replayed, err := g.Do(ctx, "u1", OpSession, "k1", fn); err != nil || id != "id-1" || replayed {
+		t.Errorf("retry after failure = %q, %v, %v; want a fresh run", id, replayed, err)
+	}
+}
+
+func TestDoInProgress(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	g := NewGuard(store)
+	fn, calls := counter()
+
+	// A concurrent request holds the key.
+	store.ReserveIdempotencyKey(ctx, &models.IdempotencyRecord{UserID: "u1", Key: "session:k1", CreatedAt: time.Now()})
+	if _, _, err := g.Do(ctx, "u1", OpSession, "k1", fn); !errors.Is(err, ErrInProgress) {
+		t.Errorf("Do while in progress = %v, want ErrInProgress", err)
+	}
+
+	// One that died long ago does not.
+	store.ReserveIdempotencyKey(ctx, &models.IdempotencyRecord{UserID: "u1", Key: "session:k2", CreatedAt: time.Now().Add(-time.Hour)})
+	if id, replayed, err := g.Do(ctx, "u1", OpSession, "k2", fn); err != nil || id != "id-1" || replayed {
+		t.Errorf("Do over an abandoned key = %q, %v, %v", id, replayed, err)
+	}
+	if *calls != 1 {
+		t.Errorf("fn ran %d times, want 1", *calls)
+	}
+}
+
+func TestDoRejectsLongKey(t *testing.T) {
+	fn, _ := counter()
+	_, _, err := NewGuard(storage.NewMemoryStorage()).Do(context.Background(), "u1", OpSession, strings.Repeat("k", 256), fn)
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Do(long key) = %v, want ErrInvalidKey", err)
+	}
+}
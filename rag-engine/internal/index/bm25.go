@@ -0,0 +1,70 @@
+package index
+
+import "math"
+
+// Default Okapi BM25 parameters.
+const (
+	DefaultK1 = 1.2
+	DefaultB  = 0.75
+)
+
+// BM25 scores documents against a query using the Okapi BM25 ranking
+// function. It rewards rare terms (IDF), saturates repeated terms (K1) and
+// normalises for document length (B).
+type BM25 struct {
+	K1        float64
+	B         float64
+	DocCount  int
+	AvgDocLen float64
+	DocFreq   map[string]int // token -> number of documents containing it
+}
+
+// NewBM25 builds a scorer from corpus statistics using the default parameters.
+func NewBM25(docCount, totalLength int, docFreq map[string]int) *BM25 {
+	avg := 0.0
+	if docCount > 0 {
+		avg = float64(totalLength) / float64(docCount)
+	}
+	return &BM25{
+		K1:        DefaultK1,
+		B:         DefaultB,
+		DocCount:  docCount,
+		AvgDocLen: avg,
+		DocFreq:   docFreq,
+	}
+}
+
+// IDF returns the inverse document frequency of a token. It uses the
+// "+1" variant so the value is never negative, even for tokens that appear
+// in every document.
+func (m *BM25) IDF(token string) float64 {
+	n := float64(m.DocCount)
+	df := float64(m.DocFreq[token])
+	if df > n {
+		n = df // stats may lag behind the candidate set
+	}
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+// Score computes the BM25 score of a document with the given term
+// frequencies and length for the (unique) query tokens.
+func (m *BM25) Score(queryTokens []string, termFreq map[string]int, docLen int) float64 {
+	avg := m.AvgDocLen
+	if avg <= 0 {
+		avg = float64(docLen)
+	}
+	norm := 1.0
+	if avg > 0 {
+		norm = 1 - m.B + m.B*float64(docLen)/avg
+	}
+
+	score := 0.0
+	for _, qt := range queryTokens {
+		tf := float64(termFreq[qt])
+		if tf == 0 {
+			continue
+		}
+		score += m.IDF(qt) * tf * (m.K1 + 1) / (tf + m.K1*norm)
+	}
+	return score
+}
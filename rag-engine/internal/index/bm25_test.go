@@ -0,0 +1,55 @@
+package index
+
+import "testing"
+
+func TestTermFrequencies(t *testing.T) {
+	freq, length := TermFrequencies("Sarah's wedding. The wedding was in June, Sarah cried!")
+	want := map[string]int{"sarah": 2, "wedding": 2, "june": 1, "cried": 1}
+	if length != 6 {
+		t.Errorf("length = %d, want 6", length)
+	}
+	for tok, n := range want {
+		if freq[tok] != n {
+			t.Errorf("freq[%q] = %d, want %d", tok, freq[tok], n)
+		}
+	}
+	if len(freq) != len(want) {
+		t.Errorf("freq = %v, want %v", freq, want)
+	}
+}
+
+func TestBM25PrefersRareTerms(t *testing.T) {
+	// "wedding" appears in every document, "sarah" in only one.
+	m := NewBM25(10, 60, map[string]int{"wedding": 10, "sarah": 1})
+	query := []string{"sarah", "wedding"}
+
+	common := m.Score(query, map[string]int{"wedding": 1}, 6)
+	rare := m.Score(query, map[string]int{"sarah": 1}, 6)
+	if rare <= common {
+		t.Errorf("rare-term score %.3f should beat common-term score %.3f", rare, common)
+	}
+	if common <= 0 {
+		t.Errorf("IDF must stay positive for ubiquitous terms, got score %.3f", common)
+	}
+}
+
+func TestBM25LengthAndSaturation(t *testing.T) {
+	m := NewBM25(10, 100, map[string]int{"garden": 3})
+	query := []string{"garden"}
+
+	short := m.Score(query, map[string]int{"garden": 1}, 5)
+	long := m.Score(query, map[string]int{"garden": 1}, 40)
+	if short <= long {
+		t.Errorf("short doc %.3f should outscore long doc %.3f", short, long)
+	}
+
+	once := m.Score(query, map[string]int{"garden": 1}, 10)
+	twice := m.Score(query, map[string]int{"garden": 2}, 10)
+	many := m.Score(query, map[string]int{"garden": 20}, 10)
+	if twice <= once {
+		t.Errorf("tf=2 %.3f should beat tf=1 %.3f", twice, once)
+	}
+	if limit := m.IDF("garden") * (m.K1 + 1); many >= limit {
+		t.Errorf("tf saturation: score %.3f should stay below %.3f", many, limit)
+	}
+}
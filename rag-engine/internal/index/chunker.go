@@ -0,0 +1,162 @@
+package index
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Default chunk sizes, in words.
+const (
+	DefaultChunkSize    = 200
+	DefaultChunkOverlap = 40
+)
+
+// Chunker splits long text into chunks of about Size words. It breaks
+// between sentences, preferring paragraph ends, and starts each chunk with
+// up to Overlap words of whole sentences from the end of the previous one,
+// so a passage that straddles a boundary can be found from either side.
+type Chunker struct {
+	Size    int
+	Overlap int
+}
+
+// NewChunker returns a chunker with the given size and overlap in words.
+// Zero picks the defaults; a negative overlap disables it. The overlap is
+// capped at half the size so every chunk adds new text.
+func NewChunker(size, overlap int) *Chunker {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	switch {
+	case overlap == 0:
+		overlap = DefaultChunkOverlap
+	case overlap < 0:
+		overlap = 0
+	}
+	return &Chunker{Size: size, Overlap: min(overlap, size/2)}
+}
+
+// sentence is one unit the chunker packs; over-long sentences are cut into
+// several of at most Size words.
+type sentence struct {
+	text      string
+	words     int
+	paragraph bool // first unit of a paragraph
+}
+
+// Split returns the chunks of text in order. Text of at most Size words is
+// returned whole, with its formatting intact; longer text has whitespace
+// within sentences collapsed and paragraphs separated by a blank line.
+func (c *Chunker) Split(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if len(strings.Fields(text)) <= c.Size {
+		return []string{text}
+	}
+
+	var (
+		chunks []string
+		cur    []sentence
+		words  int
+		fresh  int // sentences in cur not carried over from the previous chunk
+	)
+	flush := func() {
+		chunks = append(chunks, joinSentences(cur))
+		keep, kept := 0, 0
+		for i := len(cur) - 1; i > 0 && kept+cur[i].words <= c.Overlap; i-- {
+			kept += cur[i].words
+			keep++
+		}
+		cur = append([]sentence(nil), cur[len(cur)-keep:]...)
+		words, fresh = kept, 0
+	}
+	for _, s := range c.sentences(text) {
+		if fresh > 0 && (words+s.words > c.Size || (s.paragraph && words >= c.Size/2)) {
+			flush()
+		}
+		cur = append(cur, s)
+		words += s.words
+		fresh++
+	}
+	if fresh > 0 {
+		chunks = append(chunks, joinSentences(cur))
+	}
+	return chunks
+}
+
+var (
+	paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+	// A sentence ends at terminal punctuation, possibly followed by closing
+	// quotes or brackets, and then whitespace.
+	sentenceEnd = regexp.MustCompile(`[.!?…]+["'”’)\]]*\s+`)
+)
+
+// abbreviations end in a period without ending the sentence.
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "st": true, "jr": true, "sr": true,
+	"prof": true, "rev": true, "mt": true, "vs": true, "etc": true, "e.g": true, "i.e": true,
+	"no": true, "approx": true, "dept": true, "gen": true, "capt": true, "col": true, "lt": true,
+}
+
+// sentences splits text into paragraphs and those into sentences.
+func (c *Chunker) sentences(text string) []sentence {
+	var out []sentence
+	for _, para := range paragraphBreak.Split(text, -1) {
+		first := true
+		for _, s := range splitSentences(para) {
+			fields := strings.Fields(s)
+			for len(fields) > 0 {
+				n := min(len(fields), c.Size)
+				out = append(out, sentence{text: strings.Join(fields[:n], " "), words: n, paragraph: first})
+				fields = fields[n:]
+				first = false
+			}
+		}
+	}
+	return out
+}
+
+// splitSentences cuts a paragraph after each sentence end that doesn't
+// follow an abbreviation or an initial ("J. R. R. Tolkien").
+func splitSentences(para string) []string {
+	var out []string
+	start := 0
+	for _, m := range sentenceEnd.FindAllStringIndex(para, -1) {
+		if para[m[0]] == '.' && !endsSentence(para[start:m[0]]) {
+			continue
+		}
+		out = append(out, para[start:m[1]])
+		start = m[1]
+	}
+	if start < len(para) {
+		out = append(out, para[start:])
+	}
+	return out
+}
+
+// endsSentence reports whether a period after text ends the sentence.
+func endsSentence(text string) bool {
+	i := strings.LastIndexFunc(text, func(r rune) bool { return r == ' ' || r == '\n' || r == '\t' || r == '(' })
+	word := text[i+1:]
+	if len([]rune(word)) == 1 && strings.ToUpper(word) == word && strings.ToLower(word) != word {
+		return false // an initial
+	}
+	return !abbreviations[strings.ToLower(word)]
+}
+
+func joinSentences(ss []sentence) string {
+	var b strings.Builder
+	for i, s := range ss {
+		if i > 0 {
+			if s.paragraph {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package index
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChunkerShortTextIsWhole(t *testing.T) {
+	text := "Dear Mum,\n  We arrived safely.\n\nLove, Joan"
+	got := NewChunker(50, 10).Split(text)
+	if !reflect.DeepEqual(got, []string{text}) {
+		t.Errorf("Split = %q, want the text unchanged", got)
+	}
+	if got := NewChunker(50, 10).Split("  \n "); got != nil {
+		t.Errorf("Split(blank) = %q, want nil", got)
+	}
+}
+
+func TestChunkerSentenceBoundariesAndOverlap(t *testing.T) {
+	// Ten four-word sentences, chunked at 12 words with 4 words of overlap.
+	var sentences []string
+	for i := range 10 {
+		sentences = append(sentences, fmt.Sprintf("Sentence %d goes here.", i))
+	}
+	got := NewChunker(12, 4).Split(strings.Join(sentences, " "))
+
+	want := []string{
+		"Sentence 0 goes here. Sentence 1 goes here. Sentence 2 goes here.",
+		"Sentence 2 goes here. Sentence 3 goes here. Sentence 4 goes here.",
+		"Sentence 4 goes here. Sentence 5 goes here. Sentence 6 goes here.",
+		"Sentence 6 goes here. Sentence 7 goes here. Sentence 8 goes here.",
+		"Sentence 8 goes here. Sentence 9 goes here.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestChunkerPrefersParagraphBreaks(t *testing.T) {
+	text := "We married in June. The church was full.\n\n" +
+		"Later we moved to Leeds. Dad found work at the mill. We stayed for years."
+	got := NewChunker(16, -1).Split(text)
+	want := []string{
+		"We married in June. The church was full.",
+		"Later we moved to Leeds. Dad found work at the mill. We stayed for years.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split = %q, want %q", got, want)
+	}
+}
+
+func TestChunkerKeepsAbbreviationsAndInitials(t *testing.T) {
+	text := "Mr. Hughes taught us. J. R. Smith was the head. Dr. Patel lived on St. Mary's Road."
+	got := splitSentences(text)
+	want := []string{"Mr. Hughes taught us. ", "J. R. Smith was the head. ", "Dr. Patel lived on St. Mary's Road."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitSentences = %q, want %q", got, want)
+	}
+}
+
+func TestChunkerCutsLongSentences(t *testing.T) {
+	text := strings.TrimSpace(strings.Repeat("word ", 25))
+	got := NewChunker(10, -1).Split(text)
+	if len(got) != 3 {
+		t.Fatalf("Split gave %d chunks, want 3: %q", len(got), got)
+	}
+	for i, chunk := range got {
+		if n := len(strings.Fields(chunk)); n > 10 {
+			t.Errorf("chunk %d has %d words, want at most 10", i, n)
+		}
+	}
+}
+
+func TestNewChunkerDefaults(t *testing.T) {
+	if c := NewChunker(0, 0); c.Size != DefaultChunkSize || c.Overlap != DefaultChunkOverlap {
+		t.Errorf("NewChunker(0, 0) = %+v", c)
+	}
+	if c := NewChunker(20, 50); c.Overlap != 10 {
+		t.Errorf("overlap = %d, want it capped at half the size", c.Overlap)
+	}
+	if c := NewChunker(20, -1); c.Overlap != 0 {
+		t.Errorf("overlap = %d, want 0 when disabled", c.Overlap)
+	}
+}
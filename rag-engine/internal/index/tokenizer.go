@@ -28,14 +28,42 @@ var stopWords = map[string]bool{
 // Tokenize splits text into lowercase keywords, removing stop words and
 // non-alphanumeric characters. Returns unique tokens.
 func Tokenize(text string) []string {
-	// Lowercase and split on non-alphanumeric boundaries
-	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
-		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
-	})
+	words := keywords(text)
 
 	seen := make(map[string]bool, len(words))
 	tokens := make([]string, 0, len(words))
 
+	for _, w := range words {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		tokens = append(tokens, w)
+	}
+
+	return tokens
+}
+
+// TermFrequencies counts how often each keyword occurs in text and returns
+// the counts together with the total number of keywords (the document
+// length used by BM25). Keywords are produced exactly as in Tokenize.
+func TermFrequencies(text string) (map[string]int, int) {
+	words := keywords(text)
+	freq := make(map[string]int, len(words))
+	for _, w := range words {
+		freq[w]++
+	}
+	return freq, len(words)
+}
+
+// keywords lowercases text, splits on non-alphanumeric boundaries and drops
+// stop words and single-character words, keeping repeats.
+func keywords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	kept := words[:0]
 	for _, w := range words {
 		if len(w) < 2 {
 			continue // skip single-char tokens
@@ -43,14 +71,50 @@ func Tokenize(text string) []string {
 		if stopWords[w] {
 			continue
 		}
-		if seen[w] {
+		kept = append(kept, w)
+	}
+	return kept
+}
+
+// Span is the position of a keyword in a text, as byte offsets [Start, End).
+type Span struct {
+	Token string
+	Start int
+	End   int
+}
+
+// Locate finds every occurrence of the given tokens in text. Words are
+// matched exactly as Tokenize produces them, so a token found by search can
+// always be located in the content it came from.
+func Locate(text string, tokens []string) []Span {
+	want := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		want[t] = true
+	}
+
+	var spans []Span
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		w := strings.ToLower(text[start:end])
+		if len(w) >= 2 && !stopWords[w] && want[w] {
+			spans = append(spans, Span{Token: w, Start: start, End: end})
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
 			continue
 		}
-		seen[w] = true
-		tokens = append(tokens, w)
+		flush(i)
 	}
-
-	return tokens
+	flush(len(text))
+	return spans
 }
 
 // TokenOverlap computes the fraction of queryTokens that appear in chunkTokens.
@@ -0,0 +1,24 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocate(t *testing.T) {
+	text := "Zoë's WEDDING: the wedding-cake, not the weddings."
+	got := Locate(text, []string{"wedding", "zoë", "the"})
+	want := []Span{
+		{Token: "zoë", Start: 0, End: 4},
+		{Token: "wedding", Start: 7, End: 14},
+		{Token: "wedding", Start: 20, End: 27},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Locate = %+v, want %+v", got, want)
+	}
+	for _, sp := range got {
+		if toks := Tokenize(text[sp.Start:sp.End]); len(toks) != 1 || toks[0] != sp.Token {
+			t.Errorf("span %+v covers %q", sp, text[sp.Start:sp.End])
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package jobs processes sessions in the background. Jobs are kept in
+// storage so they survive restarts; a pool of workers claims them under a
+// lease, retries failures with exponential backoff and records the outcome
+// for GET /jobs/{job_id}.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+// ErrNotFound is returned when a job does not exist for the user.
+var ErrNotFound = errors.New("job not found")
+
+const (
+	defaultMaxAttempts = 5
+	defaultLease       = 5 * time.Minute
+	defaultPoll        = 2 * time.Second
+	defaultBackoff     = 10 * time.Second
+	maxBackoff         = 5 * time.Minute
+)
+
+// ProcessFunc turns a transcript into a review and returns its session ID.
+// session.Processor.Process satisfies it.
+type ProcessFunc func(ctx context.Context, userID string, messages []models.TranscriptLine) (string, error)
+
+// Queue enqueues session-processing jobs and runs them.
+type Queue struct {
+	store   storage.Storage
+	process ProcessFunc
+	wake    chan struct{}
+
+	maxAttempts int
+	lease       time.Duration // how long a claim lasts; also the per-attempt timeout
+	poll        time.Duration // how often idle workers look for due jobs
+	backoff     time.Duration // first retry delay, doubled per attempt
+}
+
+// NewQueue creates a queue that runs jobs with process.
+func NewQueue(store storage.Storage, process ProcessFunc) *Queue {
+	return &Queue{
+		store:       store,
+		process:     process,
+		wake:        make(chan struct{}, 1),
+		maxAttempts: defaultMaxAttempts,
+		lease:       defaultLease,
+		poll:        defaultPoll,
+		backoff:     defaultBackoff,
+	}
+}
+
+// Enqueue stores a job for the transcript and wakes a worker.
+func (q *Queue) Enqueue(ctx context.Context, userID string, messages []models.TranscriptLine) (*models.Job, error) {
+	if userID == "" || len(messages) == 0 {
+		return nil, models.Required("user_id", "messages")
+	}
+
+	now := time.Now().UTC()
+	job := &models.Job{
+		JobID:       fmt.Sprintf("job-%s-%d", userID, now.UnixNano()),
+		UserID:      userID,
+		Status:      models.JobQueued,
+		Messages:    messages,
+		MaxAttempts: q.maxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := q.store.CreateJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("create job: %w", err)
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default: // a wake-up is already pending
+	}
+	return job, nil
+}
+
+// Get returns one of the user's jobs.
+func (q *Queue) Get(ctx context.Context, userID, jobID string) (*models.Job, error) {
+	if userID == "" || jobID == "" {
+		return nil, models.Required("user_id", "job_id")
+	}
+	job, err := q.store.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return job, nil
+}
+
+// Run starts workers and blocks until ctx is cancelled and they have
+// finished their current jobs.
+func (q *Queue) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work claims and runs due jobs until none are left, then sleeps until
+// the next poll or an Enqueue wakes it.
+func (q *Queue) work(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+		for ctx.Err() == nil {
+			ran, err := q.runNext(ctx)
+			if err != nil {
+				log.Printf("⚠️  Job queue: %v", err)
+			}
+			if !ran {
+				break
+			}
+		}
+		timer.Reset(q.poll)
+	}
+}
+
+// runNext claims one due job and runs it, reporting whether there was one.
+func (q *Queue) runNext(ctx context.Context) (bool, error) {
+	job, err := q.store.ClaimJob(ctx, time.Now().UTC(), q.lease)
+	if err != nil {
+		return false, fmt.Errorf("claim job: %w", err)
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, q.lease)
+	sessionID, err := q.process(runCtx, job.UserID, job.Messages)
+	cancel()
+
+	// Record the outcome even when shutting down, so the job isn't left
+	// running until its lease expires.
+	return true, q.finish(context.WithoutCancel(ctx), job, sessionID, err, ctx.Err() != nil)
+}
+
+// finish stores the outcome of an attempt. Failed attempts are retried
+// with backoff until MaxAttempts; one interrupted by shutdown is requeued
+// to run again straight away.
+func (q *Queue) finish(ctx context.Context, job *models.Job, sessionID string, err error, interrupted bool) error {
+	now := time.Now().UTC()
+	job.UpdatedAt = now
+	job.LeaseUntil = time.Time{}
+
+	switch {
+	case err == nil:
+		job.Status = models.JobSucceeded
+		job.SessionID = sessionID
+		job.LastError = ""
+		job.FinishedAt = &now
+	case interrupted:
+		job.Status = models.JobQueued
+		job.RunAt = now
+		job.LastError = err.Error()
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = models.JobFailed
+		job.LastError = err.Error()
+		job.FinishedAt = &now
+		log.Printf("❌ Job %s failed after %d attempts: %v", job.JobID, job.Attempts, err)
+	default:
+		job.Status = models.JobQueued
+		job.RunAt = now.Add(q.retryDelay(job.Attempts))
+		job.LastError = err.Error()
+		log.Printf("⚠️  Job %s attempt %d failed, retrying at %s: %v", job.JobID, job.Attempts, job.RunAt.Format(time.RFC3339), err)
+	}
+
+	if err := q.store.UpdateJob(ctx, job); err != nil {
+		return fmt.Errorf("record job %s: %w", job.JobID, err)
+	}
+	return nil
+}
+
+// retryDelay is the wait after the given attempt: backoff, doubled per
+// attempt, capped at maxBackoff.
+func (q *Queue) retryDelay(attempt int) time.Duration {
+	delay := q.backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
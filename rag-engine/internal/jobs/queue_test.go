@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+var transcript = []models.TranscriptLine{{Role: "user", Content: "I grew up in Leeds"}}
+
+// newQueue returns a queue with fast retries, running until the test ends.
+func newQueue(t *testing.T, process ProcessFunc) (*Queue, storage.Storage) {
+	t.Helper()
+	store := storage.NewMemoryStorage()
+	q := NewQueue(store, process)
+	q.maxAttempts = 3
+	q.poll = 5 * time.Millisecond
+	q.backoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx, 2)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return q, store
+}
+
+// waitFor polls until the job reaches a finished status.
+func waitFor(t *testing.T, store storage.Storage, jobID string) *models.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := store.GetJob(context.Background(), jobID)
+		if err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+		if job.Status == models.JobSucceeded || job.Status == models.JobFailed {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", jobID)
+	return nil
+}
+
+func TestJobSucceeds(t *testing.T) {
+	q, store := newQueue(t, func(_ context.Context, userID string, messages []models.TranscriptLine) (string, error) {
+		if userID != "u1" || len(messages) != 1 {
+			t.Errorf("process(%q, %+v)", userID, messages)
+		}
+		return "session-1", nil
+	})
+
+	job, err := q.Enqueue(context.Background(), "u1", transcript)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if job.Status != models.JobQueued {
+		t.Errorf("Enqueue status = %s, want queued", job.Status)
+	}
+
+	got := waitFor(t, store, job.JobID)
+	if got.Status != models.JobSucceeded || got.SessionID != "session-1" || got.Attempts != 1 {
+		t.Errorf("finished job = %+v", got)
+	}
+	if got.FinishedAt == nil || !got.LeaseUntil.IsZero() {
+		t.Errorf("FinishedAt = %v, LeaseUntil = %v", got.FinishedAt, got.LeaseUntil)
+	}
+}
+
+func TestJobRetriesThenSucceeds(t *testing.T) {
+	var calls atomic.Int32
+	q, store := newQueue(t, func(context.Context, string, []models.TranscriptLine) (string, error) {
+		if calls.Add(1) == 1 {
+			return "", errors.New("extractor overloaded")
+		}
+		return "session-2", nil
+	})
+
+	job, _ := q.Enqueue(context.Background(), "u1", transcript)
+	got := waitFor(t, store, job.JobID)
+	if got.Status != models.JobSucceeded || got.Attempts != 2 || got.LastError != "" {
+		t.Errorf("finished job = %+v", got)
+	}
+}
+
+func TestJobFailsAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	q, store := newQueue(t, func(context.Context, string, []models.TranscriptLine) (string, error) {
+		calls.Add(1)
+		return "", errors.New("extractor down")
+	})
+
+	job, _ := q.Enqueue(context.Background(), "u1", transcript)
+	got := waitFor(t, store, job.JobID)
+	if got.Status != models.JobFailed || got.Attempts != 3 || got.LastError != "extractor down" {
+		t.Errorf("failed job = %+v", got)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("process called %d times, want 3", n)
+	}
+}
+
+func TestInterruptedJobIsRequeued(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	started := make(chan struct{})
+	q := NewQueue(store, func(ctx context.Context, _ string, _ []models.TranscriptLine) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job, _ := q.Enqueue(ctx, "u1", transcript)
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx, 1)
+		close(done)
+	}()
+	<-started
+	cancel()
+	<-done
+
+	got, _ := store.GetJob(context.Background(), job.JobID)
+	if got.Status != models.JobQueued || got.Attempts != 1 || got.RunAt.After(time.Now()) {
+		t.Errorf("interrupted job = %+v, want queued to run again now", got)
+	}
+}
+
+func TestGetIsScopedToUser(t *testing.T) {
+	ctx := context.Background()
+	q := NewQueue(storage.NewMemoryStorage(), nil)
+	job, err := q.Enqueue(ctx, "u1", transcript)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if got, err := q.Get(ctx, "u1", job.JobID); err != nil || got.JobID != job.JobID {
+		t.Errorf("Get = %+v, %v", got, err)
+	}
+	if _, err := q.Get(ctx, "u2", job.JobID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(other user) = %v, want ErrNotFound", err)
+	}
+	if _, err := q.Get(ctx, "u1", "job-missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) = %v, want ErrNotFound", err)
+	}
+	if _, err := q.Enqueue(ctx, "u1", nil); err == nil {
+		t.Error("Enqueue accepted an empty transcript")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	q := NewQueue(nil, nil)
+	cases := map[int]time.Duration{
+		1: 10 * time.Second,
+		2: 20 * time.Second,
+		3: 40 * time.Second,
+		6: 5 * time.Minute,
+		9: 5 * time.Minute,
+	}
+	for attempt, want := range cases {
+		if got := q.retryDelay(attempt); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrMissingField matches, via errors.Is, every error returned for a
+// request that leaves a required field empty.
+var ErrMissingField = errors.New("missing required field")
+
+// ErrTooMany is wrapped by errors for a request listing more items than
+// an endpoint accepts.
+var ErrTooMany = errors.New("too many items")
+
+// MissingFieldError names the required fields of a request.
+type MissingFieldError struct {
+	Fields []string
+}
+
+// Required returns the error for a request missing one of fields.
+func Required(fields ...string) error {
+	return &MissingFieldError{Fields: fields}
+}
+
+// Error reads like "user_id and content are required".
+func (e *MissingFieldError) Error() string {
+	switch n := len(e.Fields); n {
+	case 0:
+		return ErrMissingField.Error()
+	case 1:
+		return e.Fields[0] + " is required"
+	default:
+		return strings.Join(e.Fields[:n-1], ", ") + " and " + e.Fields[n-1] + " are required"
+	}
+}
+
+// Is makes the error match ErrMissingField.
+func (e *MissingFieldError) Is(target error) bool {
+	return target == ErrMissingField
+}
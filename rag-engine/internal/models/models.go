@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/schema"
+)
 
 // IdentityFact represents a structured identity data point for a user.
 // Examples: children names, birthdate, favourite color.
@@ -11,19 +17,90 @@ type IdentityFact struct {
 	Version   int       `json:"version" bson:"version"`
 	Immutable bool      `json:"immutable" bson:"immutable"`
 	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+
+	// Provenance of the latest write, copied into the history.
+	ChangedBy string `json:"changed_by,omitempty" bson:"changed_by,omitempty"` // caretaker or service that made the change
+	Source    string `json:"source,omitempty" bson:"source,omitempty"`         // "review", "api", "rollback"
+	SessionID string `json:"session_id,omitempty" bson:"session_id,omitempty"` // session whose review proposed it
+}
+
+// IdentityRevision is one entry of the append-only history of an identity
+// key: a snapshot of the fact as written at Version.
+type IdentityRevision struct {
+	UserID          string    `json:"user_id" bson:"user_id"`
+	Key             string    `json:"key" bson:"key"`
+	Version         int       `json:"version" bson:"version"`
+	Value           any       `json:"value" bson:"value"`
+	Immutable       bool      `json:"immutable" bson:"immutable"`
+	ChangedBy       string    `json:"changed_by,omitempty" bson:"changed_by,omitempty"`
+	Source          string    `json:"source,omitempty" bson:"source,omitempty"`
+	SessionID       string    `json:"session_id,omitempty" bson:"session_id,omitempty"`
+	RestoredVersion int       `json:"restored_version,omitempty" bson:"restored_version,omitempty"` // set by rollbacks
+	Deleted         bool      `json:"deleted,omitempty" bson:"deleted,omitempty"`                   // the key was removed at this version
+	CreatedAt       time.Time `json:"created_at" bson:"created_at"`
 }
 
 // MemoryChunk represents a single piece of long-term memory.
 type MemoryChunk struct {
-	UserID     string    `json:"user_id" bson:"user_id"`
-	ReplicaID  string    `json:"replica_id" bson:"replica_id"`
-	ChunkID    string    `json:"chunk_id" bson:"chunk_id"`
-	Content    string    `json:"content" bson:"content"`
-	Tokens     []string  `json:"tokens" bson:"tokens"`
-	Importance float64   `json:"importance" bson:"importance"` // 0.0 – 1.0
-	Source     string    `json:"source" bson:"source"`         // "conversation", "file", "manual"
-	SessionID  string    `json:"session_id" bson:"session_id"`
-	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	UserID         string         `json:"user_id" bson:"user_id"`
+	ReplicaID      string         `json:"replica_id" bson:"replica_id"`
+	ChunkID        string         `json:"chunk_id" bson:"chunk_id"`
+	Content        string         `json:"content" bson:"content"`
+	Tokens         []string       `json:"tokens" bson:"tokens"`
+	TermFreq       map[string]int `json:"term_freq,omitempty" bson:"term_freq,omitempty"` // token -> occurrences
+	Length         int            `json:"length" bson:"length"`                           // token count incl. repeats
+	Embedding      []float32      `json:"embedding,omitempty" bson:"embedding,omitempty"`
+	EmbeddingModel string         `json:"embedding_model,omitempty" bson:"embedding_model,omitempty"` // embedder that produced Embedding
+	Importance     float64        `json:"importance" bson:"importance"`                               // 0.0 – 1.0
+	Source         string         `json:"source" bson:"source"`                                       // "conversation", "file", "manual"
+	SessionID      string         `json:"session_id" bson:"session_id"`
+	DocumentID     string         `json:"document_id,omitempty" bson:"document_id,omitempty"` // links the chunks of one split document
+	Ordinal        int            `json:"ordinal,omitempty" bson:"ordinal,omitempty"`         // position within the document, from 0
+	CreatedAt      time.Time      `json:"created_at" bson:"created_at"`
+	AccessCount    int            `json:"access_count" bson:"access_count"` // times returned by search
+	LastAccessedAt *time.Time     `json:"last_accessed_at,omitempty" bson:"last_accessed_at,omitempty"`
+}
+
+// MemoryFilter restricts which chunks a search may return. Zero values mean
+// "no restriction". CreatedAfter is inclusive, CreatedBefore is exclusive.
+type MemoryFilter struct {
+	Sources         []string   `json:"sources,omitempty"` // any of "conversation", "file", "manual"
+	SessionID       string     `json:"session_id,omitempty"`
+	CreatedAfter    *time.Time `json:"created_after,omitempty"`
+	CreatedBefore   *time.Time `json:"created_before,omitempty"`
+	MinImportance   float64    `json:"min_importance,omitempty"`
+	ExcludeChunkIDs []string   `json:"exclude_chunk_ids,omitempty"` // e.g. chunks already in the prompt
+}
+
+// Matches reports whether a chunk satisfies the filter. Backends that cannot
+// express the filter natively (in-process and embedded stores) use this.
+func (f MemoryFilter) Matches(c MemoryChunk) bool {
+	if len(f.Sources) > 0 && !slices.Contains(f.Sources, c.Source) {
+		return false
+	}
+	if f.SessionID != "" && c.SessionID != f.SessionID {
+		return false
+	}
+	if f.CreatedAfter != nil && c.CreatedAt.Before(*f.CreatedAfter) {
+		return false
+	}
+	if f.CreatedBefore != nil && !c.CreatedAt.Before(*f.CreatedBefore) {
+		return false
+	}
+	if c.Importance < f.MinImportance {
+		return false
+	}
+	if slices.Contains(f.ExcludeChunkIDs, c.ChunkID) {
+		return false
+	}
+	return true
+}
+
+// Page selects one page of a listing. Cursor is the NextCursor returned with
+// the previous page (empty for the first page); Limit <= 0 means no limit.
+type Page struct {
+	Limit  int
+	Cursor string
 }
 
 // TokenEntry maps a single token to the memory chunks it appears in.
@@ -35,6 +112,17 @@ type TokenEntry struct {
 	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
 }
 
+// CorpusStats holds the collection-level statistics BM25 needs for one
+// user/replica scope. An empty ReplicaID aggregates all of a user's replicas.
+// DocFreq only contains the tokens that were asked for.
+type CorpusStats struct {
+	UserID      string         `json:"user_id" bson:"user_id"`
+	ReplicaID   string         `json:"replica_id" bson:"replica_id"`
+	DocCount    int            `json:"doc_count" bson:"doc_count"`
+	TotalLength int            `json:"total_length" bson:"total_length"`
+	DocFreq     map[string]int `json:"doc_freq" bson:"doc_freq"`
+}
+
 // ReviewStatus enumerates the lifecycle of a review item.
 type ReviewStatus string
 
@@ -42,6 +130,17 @@ const (
 	ReviewPending  ReviewStatus = "pending"
 	ReviewApproved ReviewStatus = "approved"
 	ReviewRejected ReviewStatus = "rejected"
+	ReviewPartial  ReviewStatus = "partially_approved" // some proposals accepted, some rejected
+)
+
+// ProposalDecision is a caretaker's verdict on a single proposal. The zero
+// value means no decision has been made yet.
+type ProposalDecision string
+
+const (
+	DecisionAccept ProposalDecision = "accept"
+	DecisionReject ProposalDecision = "reject"
+	DecisionEdit   ProposalDecision = "edit" // accept with the edited value
 )
 
 // ReviewItem is a proposed set of changes waiting for caretaker approval.
@@ -53,20 +152,85 @@ type ReviewItem struct {
 	ProposedMemories        []MemoryProposal   `json:"proposed_memories" bson:"proposed_memories"`
 	CreatedAt               time.Time          `json:"created_at" bson:"created_at"`
 	ReviewedAt              *time.Time         `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+	Results                 []ProposalResult   `json:"results,omitempty" bson:"results,omitempty"` // set once an approval is applied
+}
+
+// AssignProposalIDs gives every proposal without an ID one derived from its
+// kind and position. Reviews queued before proposals had IDs get the same
+// IDs on every read, so decisions can still address them.
+func (r *ReviewItem) AssignProposalIDs() {
+	for i := range r.ProposedIdentityUpdates {
+		if r.ProposedIdentityUpdates[i].ID == "" {
+			r.ProposedIdentityUpdates[i].ID = "identity-" + strconv.Itoa(i)
+		}
+	}
+	for i := range r.ProposedMemories {
+		if r.ProposedMemories[i].ID == "" {
+			r.ProposedMemories[i].ID = "memory-" + strconv.Itoa(i)
+		}
+	}
+}
+
+// ProposalOutcome says what applying an approved review did with a proposal.
+type ProposalOutcome string
+
+const (
+	ProposalApplied ProposalOutcome = "applied"
+	ProposalSkipped ProposalOutcome = "skipped"
+)
+
+// ProposalResult records the outcome of one proposal of an approved review.
+// Index points into ProposedIdentityUpdates or ProposedMemories, by Kind.
+type ProposalResult struct {
+	ProposalID string          `json:"proposal_id" bson:"proposal_id"`
+	Kind       string          `json:"kind" bson:"kind"` // "identity" or "memory"
+	Index      int             `json:"index" bson:"index"`
+	Outcome    ProposalOutcome `json:"outcome" bson:"outcome"`
+	Reason     string          `json:"reason,omitempty" bson:"reason,omitempty"`     // why it was skipped
+	Key        string          `json:"key,omitempty" bson:"key,omitempty"`           // identity key
+	Version    int             `json:"version,omitempty" bson:"version,omitempty"`   // identity version written
+	ChunkID    string          `json:"chunk_id,omitempty" bson:"chunk_id,omitempty"` // memory chunk created
 }
 
 // IdentityProposal is one proposed identity fact change inside a review.
 type IdentityProposal struct {
-	Key        string  `json:"key" bson:"key"`
-	Value      any     `json:"value" bson:"value"`
-	Confidence float64 `json:"confidence" bson:"confidence"` // 0.0 – 1.0
+	ID          string           `json:"id" bson:"id"`
+	Key         string           `json:"key" bson:"key"`
+	Value       any              `json:"value" bson:"value"`
+	Confidence  float64          `json:"confidence" bson:"confidence"` // 0.0 – 1.0
+	Decision    ProposalDecision `json:"decision,omitempty" bson:"decision,omitempty"`
+	EditedValue any              `json:"edited_value,omitempty" bson:"edited_value,omitempty"` // replaces Value when Decision is "edit"
+
+	// How the proposal relates to the fact already stored for Key, as seen
+	// when the session was processed.
+	Classification  ProposalClass `json:"classification,omitempty" bson:"classification,omitempty"`
+	ExistingValue   any           `json:"existing_value,omitempty" bson:"existing_value,omitempty"`
+	ExistingVersion int           `json:"existing_version,omitempty" bson:"existing_version,omitempty"`
 }
 
+// ProposalClass compares an identity proposal with the stored fact.
+type ProposalClass string
+
+const (
+	ClassNew         ProposalClass = "new"         // no fact stored for the key
+	ClassConfirming  ProposalClass = "confirming"  // same value as the stored fact
+	ClassConflicting ProposalClass = "conflicting" // different value; approving replaces it
+	ClassBlocked     ProposalClass = "blocked"     // different value, but the fact is immutable
+)
+
 // MemoryProposal is one proposed memory chunk inside a review.
 type MemoryProposal struct {
-	Content    string  `json:"content" bson:"content"`
-	Importance float64 `json:"importance" bson:"importance"`
-	Source     string  `json:"source" bson:"source"`
+	ID            string           `json:"id" bson:"id"`
+	Content       string           `json:"content" bson:"content"`
+	Importance    float64          `json:"importance" bson:"importance"`
+	Source        string           `json:"source" bson:"source"`
+	Decision      ProposalDecision `json:"decision,omitempty" bson:"decision,omitempty"`
+	EditedContent string           `json:"edited_content,omitempty" bson:"edited_content,omitempty"` // replaces Content when Decision is "edit"
+
+	// Set when the proposal closely resembles an existing memory, so the
+	// caretaker can merge into that chunk instead of approving a copy.
+	DuplicateOf string  `json:"duplicate_of,omitempty" bson:"duplicate_of,omitempty"` // chunk ID
+	Similarity  float64 `json:"similarity,omitempty" bson:"similarity,omitempty"`
 }
 
 // SessionTranscript is the input to session processing.
@@ -81,6 +245,59 @@ type TranscriptLine struct {
 	Content string `json:"content"`
 }
 
+// JobStatus tracks a background job through the queue.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"    // waiting for RunAt
+	JobRunning   JobStatus = "running"   // claimed by a worker until LeaseUntil
+	JobSucceeded JobStatus = "succeeded" // SessionID holds the result
+	JobFailed    JobStatus = "failed"    // gave up after MaxAttempts
+)
+
+// Job is a queued session-processing request.
+type Job struct {
+	JobID       string           `json:"job_id" bson:"job_id"`
+	UserID      string           `json:"user_id" bson:"user_id"`
+	Status      JobStatus        `json:"status" bson:"status"`
+	Messages    []TranscriptLine `json:"messages" bson:"messages"`
+	Attempts    int              `json:"attempts" bson:"attempts"` // claims so far, including the current one
+	MaxAttempts int              `json:"max_attempts" bson:"max_attempts"`
+	RunAt       time.Time        `json:"run_at" bson:"run_at"`                               // earliest time the next attempt may start
+	LeaseUntil  time.Time        `json:"lease_until,omitempty" bson:"lease_until,omitempty"` // while running; expired leases are reclaimed
+	LastError   string           `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	SessionID   string           `json:"session_id,omitempty" bson:"session_id,omitempty"`
+	CreatedAt   time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" bson:"updated_at"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+// Document is an ingested letter, eulogy or journal. Its text is stored as
+// memory chunks linked back by DocumentID; the record keeps the metadata
+// and the chunk IDs in order.
+type Document struct {
+	DocumentID string            `json:"document_id" bson:"document_id"`
+	UserID     string            `json:"user_id" bson:"user_id"`
+	ReplicaID  string            `json:"replica_id" bson:"replica_id"`
+	Title      string            `json:"title,omitempty" bson:"title,omitempty"`
+	Format     string            `json:"format" bson:"format"`                 // "text", "markdown" or "pdf_text"
+	Date       string            `json:"date,omitempty" bson:"date,omitempty"` // when it was written: YYYY, YYYY-MM or YYYY-MM-DD
+	People     []string          `json:"people,omitempty" bson:"people,omitempty"`
+	Tags       []string          `json:"tags,omitempty" bson:"tags,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"` // other front-matter fields
+	ChunkIDs   []string          `json:"chunk_ids" bson:"chunk_ids"`
+	CreatedAt  time.Time         `json:"created_at" bson:"created_at"`
+}
+
+// IdempotencyRecord remembers the result of a request made with a client
+// idempotency key, so a retry returns it instead of repeating the work.
+type IdempotencyRecord struct {
+	UserID    string    `json:"user_id" bson:"user_id"`
+	Key       string    `json:"key" bson:"key"`                                 // operation + ":" + client key
+	ResultID  string    `json:"result_id,omitempty" bson:"result_id,omitempty"` // session or job ID, or stored chunks as JSON; empty while in progress
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
 // --- API request / response types ---
 
 // IdentityRequest is the JSON body for POST /identity/get.
@@ -96,25 +313,153 @@ type IdentityResponse struct {
 	Error   string        `json:"error,omitempty"`
 }
 
+// IdentityListRequest is the JSON body for POST /identity/list.
+type IdentityListRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// IdentityBatchRequest is the JSON body for POST /identity/batch.
+type IdentityBatchRequest struct {
+	UserID string   `json:"user_id"`
+	Keys   []string `json:"keys"`
+}
+
+// IdentityListResponse lists identity facts, ordered by key.
+type IdentityListResponse struct {
+	Success bool           `json:"success"`
+	Facts   []IdentityFact `json:"facts"`
+	Count   int            `json:"count"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// IdentitySetRequest is the JSON body for POST /identity/set.
+type IdentitySetRequest struct {
+	UserID    string `json:"user_id"`
+	Key       string `json:"key"`
+	Value     any    `json:"value"`
+	Immutable bool   `json:"immutable,omitempty"`
+	ChangedBy string `json:"changed_by,omitempty"`
+}
+
+// IdentityDeleteResponse wraps the result of DELETE /identity/{key}.
+type IdentityDeleteResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// IdentitySchemaResponse describes the declared identity keys.
+type IdentitySchemaResponse struct {
+	Success bool           `json:"success"`
+	Strict  bool           `json:"strict"`
+	Fields  []schema.Field `json:"fields"`
+}
+
+// IdentityHistoryRequest is the JSON body for POST /identity/history.
+type IdentityHistoryRequest struct {
+	UserID string `json:"user_id"`
+	Key    string `json:"key"`
+}
+
+// IdentityHistoryResponse lists the revisions of a key, oldest first.
+type IdentityHistoryResponse struct {
+	Success   bool               `json:"success"`
+	Revisions []IdentityRevision `json:"revisions"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// IdentityRollbackRequest is the JSON body for POST /identity/rollback.
+type IdentityRollbackRequest struct {
+	UserID    string `json:"user_id"`
+	Key       string `json:"key"`
+	Version   int    `json:"version"` // revision to restore
+	ChangedBy string `json:"changed_by,omitempty"`
+}
+
 // MemorySearchRequest is the JSON body for POST /memory/search.
 type MemorySearchRequest struct {
 	UserID    string `json:"user_id"`
 	ReplicaID string `json:"replica_id"`
 	Query     string `json:"query"`
 	TopK      int    `json:"top_k"`
+	Cursor    string `json:"cursor,omitempty"` // NextCursor of the previous page
+
+	Filters MemoryFilter `json:"filters"`
+
+	// Optional scoring terms; zero disables them.
+	RecencyWeight       float64 `json:"recency_weight,omitempty"`         // favour recently created chunks
+	RecencyHalfLifeDays float64 `json:"recency_half_life_days,omitempty"` // default 30
+	ReinforcementWeight float64 `json:"reinforcement_weight,omitempty"`   // favour frequently retrieved chunks
+
+	Explain bool `json:"explain,omitempty"` // attach a ScoreExplanation to each result
 }
 
 // ScoredChunk pairs a memory chunk with its relevance score.
 type ScoredChunk struct {
-	Chunk MemoryChunk `json:"chunk"`
-	Score float64     `json:"score"`
+	Chunk       MemoryChunk       `json:"chunk"`
+	Score       float64           `json:"score"`
+	Explanation *ScoreExplanation `json:"explanation,omitempty"`
 }
 
-// MemorySearchResponse wraps search results.
+// ScoreExplanation breaks a search score down into the terms that produced
+// it. The contributions of Components add up to the score.
+type ScoreExplanation struct {
+	Components    []ScoreComponent `json:"components"`
+	Lexical       float64          `json:"lexical"`            // raw BM25 score
+	Semantic      float64          `json:"semantic,omitempty"` // cosine similarity, hybrid search only
+	MatchedTokens []string         `json:"matched_tokens"`     // query tokens found in the content
+	Snippets      []Snippet        `json:"snippets"`
+}
+
+// ScoreComponent is one weighted term of a search score: "relevance",
+// "importance", "recency" or "reinforcement". Value is in 0.0 – 1.0.
+type ScoreComponent struct {
+	Name         string  `json:"name"`
+	Value        float64 `json:"value"`
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"`
+}
+
+// Snippet is an excerpt of a chunk's content around query matches. Start and
+// End locate Text in the content; highlight offsets are relative to Text.
+// All offsets count Unicode characters, not bytes.
+type Snippet struct {
+	Text       string      `json:"text"`
+	Start      int         `json:"start"`
+	End        int         `json:"end"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// Highlight marks one matched query token inside a snippet as [Start, End).
+type Highlight struct {
+	Token string `json:"token"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// MemorySearchResponse wraps search results. NextCursor is set when more
+// results are available; pass it back as Cursor to fetch them.
 type MemorySearchResponse struct {
-	Success bool          `json:"success"`
-	Results []ScoredChunk `json:"results"`
-	Error   string        `json:"error,omitempty"`
+	Success    bool          `json:"success"`
+	Results    []ScoredChunk `json:"results"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// MemoryListRequest is the JSON body for POST /memory/list.
+type MemoryListRequest struct {
+	UserID    string       `json:"user_id"`
+	ReplicaID string       `json:"replica_id"`
+	Filters   MemoryFilter `json:"filters"`
+	Limit     int          `json:"limit"`
+	Cursor    string       `json:"cursor,omitempty"`
+}
+
+// MemoryListResponse wraps one page of memory chunks.
+type MemoryListResponse struct {
+	Success    bool          `json:"success"`
+	Chunks     []MemoryChunk `json:"chunks"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	Error      string        `json:"error,omitempty"`
 }
 
 // MemoryStoreRequest is the JSON body for POST /memory/store.
@@ -125,12 +470,41 @@ type MemoryStoreRequest struct {
 	Importance float64 `json:"importance"`
 	Source     string  `json:"source"`
 	SessionID  string  `json:"session_id"`
+	// IdempotencyKey makes retries safe: a repeat returns the first chunk.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
-// MemoryStoreResponse wraps the result of a memory store.
+// MemoryStoreResponse wraps the result of a memory store. ChunkID is the
+// first chunk; long files split into several, listed in order in ChunkIDs
+// and linked by DocumentID. Replayed is set when an idempotency key matched
+// an earlier request.
 type MemoryStoreResponse struct {
+	Success    bool     `json:"success"`
+	ChunkID    string   `json:"chunk_id,omitempty"`
+	ChunkIDs   []string `json:"chunk_ids,omitempty"`
+	DocumentID string   `json:"document_id,omitempty"`
+	Replayed   bool     `json:"replayed,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// MemoryUpdateRequest is the JSON body for PUT /memory/{chunk_id}.
+// Importance is optional; omitting it keeps the current value.
+type MemoryUpdateRequest struct {
+	UserID     string   `json:"user_id"`
+	Content    string   `json:"content"`
+	Importance *float64 `json:"importance,omitempty"`
+}
+
+// MemoryUpdateResponse wraps the updated chunk.
+type MemoryUpdateResponse struct {
+	Success bool         `json:"success"`
+	Chunk   *MemoryChunk `json:"chunk,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// MemoryDeleteResponse wraps the result of DELETE /memory/{chunk_id}.
+type MemoryDeleteResponse struct {
 	Success bool   `json:"success"`
-	ChunkID string `json:"chunk_id,omitempty"`
 	Error   string `json:"error,omitempty"`
 }
 
@@ -138,15 +512,103 @@ type MemoryStoreResponse struct {
 type SessionProcessRequest struct {
 	UserID   string           `json:"user_id"`
 	Messages []TranscriptLine `json:"messages"`
+	Async    bool             `json:"async,omitempty"` // enqueue and return a job ID instead of waiting
+	// IdempotencyKey makes retries safe: a repeat returns the first
+	// session (or job) instead of creating another review.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
-// SessionProcessResponse wraps the result of session processing.
+// SessionProcessResponse wraps the result of session processing. Async
+// requests get a JobID to poll at GET /jobs/{job_id}. Replayed is set when
+// an idempotency key matched an earlier request.
 type SessionProcessResponse struct {
 	Success   bool   `json:"success"`
 	SessionID string `json:"session_id,omitempty"`
+	JobID     string `json:"job_id,omitempty"`
+	Replayed  bool   `json:"replayed,omitempty"`
 	Error     string `json:"error,omitempty"`
 }
 
+// JobStatusResponse wraps the result of GET /jobs/{job_id}.
+type JobStatusResponse struct {
+	Success    bool       `json:"success"`
+	JobID      string     `json:"job_id,omitempty"`
+	Status     JobStatus  `json:"status,omitempty"`
+	Attempts   int        `json:"attempts,omitempty"`
+	SessionID  string     `json:"session_id,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// DocumentIngestRequest is the JSON body for POST /documents/ingest.
+// Content may start with a front-matter block (between "---" lines) giving
+// title, date, people, tags and other metadata; fields set here win.
+type DocumentIngestRequest struct {
+	UserID         string   `json:"user_id"`
+	ReplicaID      string   `json:"replica_id"`
+	Content        string   `json:"content"`
+	Format         string   `json:"format,omitempty"` // "text" (default), "markdown" or "pdf_text"
+	Title          string   `json:"title,omitempty"`
+	Date           string   `json:"date,omitempty"`
+	People         []string `json:"people,omitempty"`
+	Importance     *float64 `json:"importance,omitempty"` // default 0.5
+	IdempotencyKey string   `json:"idempotency_key,omitempty"`
+}
+
+// DocumentResponse wraps a document and, from GET /documents/{document_id},
+// its chunks in order.
+type DocumentResponse struct {
+	Success  bool          `json:"success"`
+	Document *Document     `json:"document,omitempty"`
+	Chunks   []MemoryChunk `json:"chunks,omitempty"`
+	Replayed bool          `json:"replayed,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// DocumentDeleteResponse wraps the result of DELETE /documents/{document_id}.
+type DocumentDeleteResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReviewDecisionRequest is the JSON body for POST /reviews/{session_id}/approve
+// and /reject.
+type ReviewDecisionRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// ReviewDecisionsRequest is the JSON body for POST /reviews/{session_id}/decisions.
+type ReviewDecisionsRequest struct {
+	UserID    string                 `json:"user_id"`
+	Decisions []ProposalDecisionItem `json:"decisions"`
+}
+
+// ProposalDecisionItem decides one proposal. EditedValue is required for
+// "edit"; for memory proposals it must be the corrected content string.
+type ProposalDecisionItem struct {
+	ProposalID  string           `json:"proposal_id"`
+	Decision    ProposalDecision `json:"decision"`
+	EditedValue any              `json:"edited_value,omitempty"`
+}
+
+// ReviewResponse wraps a single review.
+type ReviewResponse struct {
+	Success bool        `json:"success"`
+	Review  *ReviewItem `json:"review,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// ReviewListResponse wraps the pending reviews of a user.
+type ReviewListResponse struct {
+	Success bool         `json:"success"`
+	Reviews []ReviewItem `json:"reviews"`
+	Count   int          `json:"count"`
+	Error   string       `json:"error,omitempty"`
+}
+
 // HealthResponse is the JSON body returned by GET /health.
 type HealthResponse struct {
 	Status         string `json:"status"`
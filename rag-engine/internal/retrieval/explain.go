@@ -0,0 +1,107 @@
+package retrieval
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/memory-lane/rag-engine/internal/index"
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+const (
+	// snippetContext is how many characters of context surround a match.
+	snippetContext = 40
+	// maxSnippets caps the snippets returned per chunk.
+	maxSnippets = 3
+)
+
+// explain describes why a candidate scored the way it did.
+func explain(c *candidate, comps []models.ScoreComponent, queryTokens []string) *models.ScoreExplanation {
+	termFreq, _ := chunkTermFreq(c.chunk)
+	matched := make([]string, 0, len(queryTokens))
+	for _, t := range queryTokens {
+		if termFreq[t] > 0 {
+			matched = append(matched, t)
+		}
+	}
+
+	return &models.ScoreExplanation{
+		Components:    comps,
+		Lexical:       c.lexical,
+		Semantic:      c.semantic,
+		MatchedTokens: matched,
+		Snippets:      snippets(c.chunk.Content, matched),
+	}
+}
+
+// snippets cuts excerpts of content around the occurrences of tokens.
+// Matches whose context windows overlap share a snippet, and snippet edges
+// are pulled in to word boundaries. Chunks matched only semantically have
+// no snippets.
+func snippets(content string, tokens []string) []models.Snippet {
+	out := []models.Snippet{}
+	spans := index.Locate(content, tokens)
+	if len(spans) == 0 {
+		return out
+	}
+
+	// Convert byte offsets to character offsets
+	runes := []rune(content)
+	highlights := make([]models.Highlight, len(spans))
+	pos, chars := 0, 0
+	for i, sp := range spans {
+		chars += utf8.RuneCountInString(content[pos:sp.Start])
+		start := chars
+		chars += utf8.RuneCountInString(content[sp.Start:sp.End])
+		pos = sp.End
+		highlights[i] = models.Highlight{Token: sp.Token, Start: start, End: chars}
+	}
+
+	// Group highlights whose context windows overlap
+	var groups [][]models.Highlight
+	end := -1
+	for _, h := range highlights {
+		if len(groups) > 0 && h.Start-snippetContext <= end {
+			groups[len(groups)-1] = append(groups[len(groups)-1], h)
+		} else {
+			if len(groups) == maxSnippets {
+				break
+			}
+			groups = append(groups, []models.Highlight{h})
+		}
+		end = h.End + snippetContext
+	}
+
+	for _, g := range groups {
+		first, last := g[0], g[len(g)-1]
+		start := max(first.Start-snippetContext, 0)
+		end := min(last.End+snippetContext, len(runes))
+
+		// Don't cut words in half
+		if start > 0 {
+			for i := start; i < first.Start; i++ {
+				if unicode.IsSpace(runes[i]) {
+					start = i + 1
+					break
+				}
+			}
+		}
+		if end < len(runes) {
+			for i := end - 1; i >= last.End; i-- {
+				if unicode.IsSpace(runes[i]) {
+					end = i
+					break
+				}
+			}
+		}
+
+		snip := models.Snippet{Text: string(runes[start:end]), Start: start, End: end}
+		for _, h := range g {
+			h.Start -= start
+			h.End -= start
+			snip.Highlights = append(snip.Highlights, h)
+		}
+		out = append(out, snip)
+	}
+	return out
+}
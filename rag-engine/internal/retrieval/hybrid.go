@@ -0,0 +1,162 @@
+package retrieval
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/memory-lane/rag-engine/internal/embedding"
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+const (
+	// rrfK dampens the advantage of top ranks in reciprocal rank fusion;
+	// 60 is the value from the original RRF paper.
+	rrfK = 60
+	// minSimilarity drops semantic candidates that are effectively unrelated.
+	minSimilarity = 0.1
+	// semanticPoolSize caps how many nearest neighbours join the fusion.
+	semanticPoolSize = 50
+	// semanticWindow is how many of the newest chunks are compared with the
+	// query besides the lexical hits, so paraphrases of recent memories are
+	// found without loading the whole corpus on every search.
+	semanticWindow = 500
+)
+
+// candidate is a chunk under consideration for a search, with its score and
+// 1-based rank in each retrieval list (0 = not retrieved by that list).
+type candidate struct {
+	chunk        models.MemoryChunk
+	lexical      float64
+	semantic     float64
+	lexicalRank  int
+	semanticRank int
+}
+
+// embed attaches a vector to the chunk. Failures only cost semantic recall,
+// so they are logged rather than failing the write.
+func (s *MemoryService) embed(ctx context.Context, chunk *models.MemoryChunk) {
+	if s.embedder == nil {
+		return
+	}
+	vectors, err := s.embedder.Embed(ctx, []string{chunk.Content})
+	if err != nil || len(vectors) != 1 {
+		log.Printf("⚠️  embedding chunk %s failed: %v", chunk.ChunkID, err)
+		return
+	}
+	chunk.Embedding = vectors[0]
+	chunk.EmbeddingModel = s.embedder.Name()
+}
+
+// semanticCandidates adds the chunks nearest to the query by cosine
+// similarity to candidates. It compares the lexical candidates and the
+// newest semanticWindow chunks, so older chunks sharing no token with the
+// query are not reached. Only chunks embedded by the current embedder are
+// comparable; the rest are reachable through lexical search only.
+func (s *MemoryService) semanticCandidates(ctx context.Context, userID, replicaID, query string, filter models.MemoryFilter, candidates map[string]*candidate) error {
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil || len(vectors) != 1 {
+		log.Printf("⚠️  embedding query failed, using lexical search only: %v", err)
+		return nil
+	}
+	queryVec := vectors[0]
+
+	recent, err := s.store.RecentMemory(ctx, userID, replicaID, filter, semanticWindow)
+	if err != nil {
+		return err
+	}
+	chunks := make([]models.MemoryChunk, 0, len(candidates)+len(recent))
+	for _, c := range candidates {
+		chunks = append(chunks, c.chunk)
+	}
+	for _, c := range recent {
+		if _, ok := candidates[c.ChunkID]; !ok {
+			chunks = append(chunks, c)
+		}
+	}
+
+	type neighbour struct {
+		chunk models.MemoryChunk
+		sim   float64
+	}
+	var nearest []neighbour
+	model := s.embedder.Name()
+	for _, c := range chunks {
+		if c.EmbeddingModel != model {
+			continue
+		}
+		if sim := embedding.Cosine(queryVec, c.Embedding); sim >= minSimilarity {
+			nearest = append(nearest, neighbour{chunk: c, sim: sim})
+		}
+	}
+	sort.Slice(nearest, func(i, j int) bool { return nearest[i].sim > nearest[j].sim })
+	if len(nearest) > semanticPoolSize {
+		nearest = nearest[:semanticPoolSize]
+	}
+
+	for _, n := range nearest {
+		c, ok := candidates[n.chunk.ChunkID]
+		if !ok {
+			c = &candidate{chunk: n.chunk}
+			candidates[n.chunk.ChunkID] = c
+		}
+		c.semantic = n.sim
+	}
+	rankBy(candidates, func(c *candidate) float64 { return c.semantic }, func(c *candidate, r int) { c.semanticRank = r })
+	return nil
+}
+
+// rankBy assigns 1-based ranks by descending score to every candidate with
+// a positive score.
+func rankBy(candidates map[string]*candidate, score func(*candidate) float64, setRank func(*candidate, int)) {
+	ranked := make([]*candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if score(c) > 0 {
+			ranked = append(ranked, c)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if score(ranked[i]) != score(ranked[j]) {
+			return score(ranked[i]) > score(ranked[j])
+		}
+		return ranked[i].chunk.ChunkID < ranked[j].chunk.ChunkID
+	})
+	for i, c := range ranked {
+		setRank(c, i+1)
+	}
+}
+
+// lexicalRelevance normalises BM25 scores against the best candidate.
+func lexicalRelevance(candidates map[string]*candidate) map[string]float64 {
+	return normalise(candidates, func(c *candidate) float64 { return c.lexical })
+}
+
+// fusedRelevance combines the lexical and semantic rankings with reciprocal
+// rank fusion, normalised against the best candidate.
+func fusedRelevance(candidates map[string]*candidate) map[string]float64 {
+	return normalise(candidates, func(c *candidate) float64 {
+		return rrf(c.lexicalRank) + rrf(c.semanticRank)
+	})
+}
+
+func rrf(rank int) float64 {
+	if rank == 0 {
+		return 0
+	}
+	return 1.0 / float64(rrfK+rank)
+}
+
+// normalise divides each candidate's score by the maximum score.
+func normalise(candidates map[string]*candidate, score func(*candidate) float64) map[string]float64 {
+	best := 0.0
+	for _, c := range candidates {
+		best = max(best, score(c))
+	}
+	out := make(map[string]float64, len(candidates))
+	for id, c := range candidates {
+		if best > 0 {
+			out[id] = score(c) / best
+		}
+	}
+	return out
+}
@@ -2,35 +2,95 @@ package retrieval
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/schema"
 	"github.com/memory-lane/rag-engine/internal/storage"
 )
 
+var (
+	// ErrImmutable is returned when writing to a key marked immutable.
+	ErrImmutable = errors.New("immutable and cannot be updated")
+	// ErrIdentityNotFound is returned when deleting a key that is not set.
+	ErrIdentityNotFound = errors.New("identity fact not found")
+	// ErrRevisionNotFound is returned when a rollback targets a version that
+	// is not in the key's history.
+	ErrRevisionNotFound = errors.New("identity revision not found")
+)
+
+// Identity write sources recorded in the history.
+const (
+	SourceAPI      = "api"
+	SourceReview   = "review"
+	SourceRollback = "rollback"
+)
+
 // IdentityService handles deterministic identity lookups.
 type IdentityService struct {
-	store storage.Storage
+	store  storage.Storage
+	schema *schema.Registry // nil = values are not validated
+}
+
+// NewIdentityService creates a new identity service. Writes are validated
+// against reg when it is non-nil.
+func NewIdentityService(store storage.Storage, reg *schema.Registry) *IdentityService {
+	return &IdentityService{store: store, schema: reg}
 }
 
-// NewIdentityService creates a new identity service.
-func NewIdentityService(store storage.Storage) *IdentityService {
-	return &IdentityService{store: store}
+// Schema returns the registry writes are validated against, or nil.
+func (s *IdentityService) Schema() *schema.Registry {
+	return s.schema
+}
+
+// Validate checks a value for key against the schema and returns it in
+// canonical form. Without a schema the value is returned unchanged.
+func (s *IdentityService) Validate(key string, value any) (any, error) {
+	if s.schema == nil {
+		return value, nil
+	}
+	return s.schema.Validate(key, value)
 }
 
 // Get retrieves a single identity fact for a user.
 // Returns nil (not an error) when the key doesn't exist.
 func (s *IdentityService) Get(ctx context.Context, userID, key string) (*models.IdentityFact, error) {
 	if userID == "" || key == "" {
-		return nil, fmt.Errorf("user_id and key are required")
+		return nil, models.Required("user_id", "key")
 	}
 	return s.store.GetIdentity(ctx, userID, key)
 }
 
-// Set creates or updates an identity fact with version bumping.
+// maxBatchKeys caps the keys accepted by GetMany.
+const maxBatchKeys = 100
+
+// List returns every identity fact of a user, ordered by key.
+func (s *IdentityService) List(ctx context.Context, userID string) ([]models.IdentityFact, error) {
+	if userID == "" {
+		return nil, models.Required("user_id")
+	}
+	return s.store.ListIdentity(ctx, userID)
+}
+
+// GetMany returns the facts for the given keys, ordered by key.
+// Keys that don't exist are omitted.
+func (s *IdentityService) GetMany(ctx context.Context, userID string, keys []string) ([]models.IdentityFact, error) {
+	if userID == "" || len(keys) == 0 {
+		return nil, models.Required("user_id", "keys")
+	}
+	if len(keys) > maxBatchKeys {
+		return nil, fmt.Errorf("%w: at most %d keys per request, got %d", models.ErrTooMany, maxBatchKeys, len(keys))
+	}
+	return s.store.GetIdentities(ctx, userID, keys)
+}
+
+// Set creates or updates an identity fact with version bumping, and
+// appends the new version to the key's history.
 func (s *IdentityService) Set(ctx context.Context, fact *models.IdentityFact) error {
 	if fact.UserID == "" || fact.Key == "" {
-		return fmt.Errorf("user_id and key are required")
+		return models.Required("user_id", "key")
 	}
 
 	// Check for immutability
@@ -39,15 +99,180 @@ func (s *IdentityService) Set(ctx context.Context, fact *models.IdentityFact) er
 		return err
 	}
 	if existing != nil && existing.Immutable {
-		return fmt.Errorf("identity key %q is immutable and cannot be updated", fact.Key)
+		return fmt.Errorf("identity key %q is %w", fact.Key, ErrImmutable)
+	}
+
+	value, err := s.Validate(fact.Key, fact.Value)
+	if err != nil {
+		return err
+	}
+	fact.Value = value
+	if existing == nil && s.schema != nil {
+		if f, ok := s.schema.Field(fact.Key); ok && f.Immutable {
+			fact.Immutable = true
+		}
+	}
+	return s.write(ctx, fact, existing, 0)
+}
+
+// Delete removes an identity fact. The removal is recorded in the history
+// as its own version, so it can be rolled back like any other change.
+func (s *IdentityService) Delete(ctx context.Context, userID, key, changedBy string) error {
+	if userID == "" || key == "" {
+		return models.Required("user_id", "key")
+	}
+	existing, err := s.store.GetIdentity(ctx, userID, key)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("%s: %w", key, ErrIdentityNotFound)
+	}
+	if existing.Immutable {
+		return fmt.Errorf("identity key %q is %w", key, ErrImmutable)
+	}
+	return s.remove(ctx, existing, &models.IdentityRevision{ChangedBy: changedBy, Source: SourceAPI})
+}
+
+// Restore undoes a write: it puts previous back as the key's fact, or
+// removes the key when previous is nil. The undo is recorded as a rollback
+// revision, so the history ends on the fact that is current. Unlike Set
+// and Delete it ignores immutability, since it only reverts.
+func (s *IdentityService) Restore(ctx context.Context, userID, key string, previous *models.IdentityFact, sessionID string) error {
+	existing, err := s.store.GetIdentity(ctx, userID, key)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		if existing == nil {
+			return nil
+		}
+		return s.remove(ctx, existing, &models.IdentityRevision{Source: SourceRollback, SessionID: sessionID})
+	}
+	fact := *previous
+	fact.ChangedBy, fact.Source, fact.SessionID = "", SourceRollback, sessionID
+	fact.UpdatedAt = time.Time{}
+	return s.write(ctx, &fact, existing, previous.Version)
+}
+
+// remove deletes existing and records the removal as the next version,
+// with the author and source taken from rev.
+func (s *IdentityService) remove(ctx context.Context, existing *models.IdentityFact, rev *models.IdentityRevision) error {
+	version, err := s.nextVersion(ctx, existing.UserID, existing.Key, existing.Version)
+	if err != nil {
+		return err
+	}
+	rev.UserID, rev.Key, rev.Version = existing.UserID, existing.Key, version
+	rev.Deleted = true
+	rev.CreatedAt = time.Now()
+	// Recorded first, as in write
+	if err := s.store.AppendIdentityRevision(ctx, rev); err != nil {
+		return fmt.Errorf("record %s version %d: %w", existing.Key, version, err)
+	}
+	return s.store.DeleteIdentity(ctx, existing.UserID, existing.Key)
+}
+
+// History returns every recorded revision of a key, oldest first.
+func (s *IdentityService) History(ctx context.Context, userID, key string) ([]models.IdentityRevision, error) {
+	if userID == "" || key == "" {
+		return nil, models.Required("user_id", "key")
+	}
+	return s.store.ListIdentityRevisions(ctx, userID, key)
+}
+
+// Rollback restores the value of a prior version. The restore is itself a
+// new version, so the history is never rewritten.
+func (s *IdentityService) Rollback(ctx context.Context, userID, key string, version int, changedBy string) (*models.IdentityFact, error) {
+	revs, err := s.History(ctx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+	var target *models.IdentityRevision
+	for i := range revs {
+		if revs[i].Version == version {
+			target = &revs[i]
+			break
+		}
+	}
+	if target == nil || target.Deleted {
+		return nil, fmt.Errorf("%s version %d: %w", key, version, ErrRevisionNotFound)
+	}
+
+	existing, err := s.store.GetIdentity(ctx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Immutable {
+		return nil, fmt.Errorf("identity key %q is %w", key, ErrImmutable)
 	}
 
-	// Bump version
+	fact := &models.IdentityFact{
+		UserID:    userID,
+		Key:       key,
+		Value:     target.Value,
+		Immutable: target.Immutable,
+		ChangedBy: changedBy,
+		Source:    SourceRollback,
+	}
+	if err := s.write(ctx, fact, existing, version); err != nil {
+		return nil, err
+	}
+	return fact, nil
+}
+
+// write stores fact as the next version and records it in the history.
+// The version follows both the current fact and the history, so facts
+// restored or removed outside the service never reuse a version.
+//
+// The revision is appended before the fact is written. The history rejects
+// a version it already holds, so of two concurrent writes that read the
+// same version only one goes through, and no change lands without a
+// history entry.
+func (s *IdentityService) write(ctx context.Context, fact, existing *models.IdentityFact, restored int) error {
+	current := 0
 	if existing != nil {
-		fact.Version = existing.Version + 1
-	} else {
-		fact.Version = 1
+		current = existing.Version
+	}
+	version, err := s.nextVersion(ctx, fact.UserID, fact.Key, current)
+	if err != nil {
+		return err
+	}
+	fact.Version = version
+	if fact.UpdatedAt.IsZero() {
+		fact.UpdatedAt = time.Now()
+	}
+	if fact.Source == "" {
+		fact.Source = SourceAPI
 	}
 
+	rev := &models.IdentityRevision{
+		UserID:          fact.UserID,
+		Key:             fact.Key,
+		Version:         fact.Version,
+		Value:           fact.Value,
+		Immutable:       fact.Immutable,
+		ChangedBy:       fact.ChangedBy,
+		Source:          fact.Source,
+		SessionID:       fact.SessionID,
+		RestoredVersion: restored,
+		CreatedAt:       fact.UpdatedAt,
+	}
+	if err := s.store.AppendIdentityRevision(ctx, rev); err != nil {
+		return fmt.Errorf("record %s version %d: %w", fact.Key, fact.Version, err)
+	}
 	return s.store.SetIdentity(ctx, fact)
 }
+
+// nextVersion returns the version after both the current fact's and the
+// latest one in the key's history.
+func (s *IdentityService) nextVersion(ctx context.Context, userID, key string, current int) (int, error) {
+	revs, err := s.store.ListIdentityRevisions(ctx, userID, key)
+	if err != nil {
+		return 0, err
+	}
+	latest := current
+	if n := len(revs); n > 0 && revs[n-1].Version > latest {
+		latest = revs[n-1].Version
+	}
+	return latest + 1, nil
+}
@@ -0,0 +1,188 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/schema"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+func setName(t *testing.T, svc *IdentityService, value string) *models.IdentityFact {
+	t.Helper()
+	fact := &models.IdentityFact{UserID: "u1", Key: "name", Value: value, ChangedBy: "carer"}
+	if err := svc.Set(context.Background(), fact); err != nil {
+		t.Fatalf("Set(%q): %v", value, err)
+	}
+	return fact
+}
+
+func TestIdentitySetRecordsHistory(t *testing.T) {
+	svc := NewIdentityService(storage.NewMemoryStorage(), nil)
+	setName(t, svc, "Margaret")
+	setName(t, svc, "Margret")
+
+	revs, err := svc.History(context.Background(), "u1", "name")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("History returned %d revisions, want 2", len(revs))
+	}
+	for i, want := range []string{"Margaret", "Margret"} {
+		r := revs[i]
+		if r.Version != i+1 || r.Value != want || r.Source != SourceAPI || r.ChangedBy != "carer" {
+			t.Errorf("revision %d = %+v", i, r)
+		}
+	}
+}
+
+func TestIdentityRollback(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	svc := NewIdentityService(store, nil)
+	setName(t, svc, "Margaret")
+	setName(t, svc, "Margret")
+
+	fact, err := svc.Rollback(ctx, "u1", "name", 1, "admin")
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if fact.Version != 3 || fact.Value != "Margaret" || fact.Source != SourceRollback {
+		t.Errorf("rolled back fact = %+v", fact)
+	}
+	if got, _ := svc.Get(ctx, "u1", "name"); got == nil || got.Value != "Margaret" {
+		t.Errorf("Get after rollback = %+v", got)
+	}
+
+	revs, _ := svc.History(ctx, "u1", "name")
+	if len(revs) != 3 || revs[2].RestoredVersion != 1 || revs[2].ChangedBy != "admin" {
+		t.Errorf("history after rollback = %+v", revs)
+	}
+
+	if _, err := svc.Rollback(ctx, "u1", "name", 7, "admin"); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("Rollback(missing) err = %v, want ErrRevisionNotFound", err)
+	}
+}
+
+func TestIdentityVersionsNeverReused(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	svc := NewIdentityService(store, nil)
+	setName(t, svc, "Margaret")
+
+	// A compensating delete outside the service leaves the history behind.
+	if err := store.DeleteIdentity(ctx, "u1", "name"); err != nil {
+		t.Fatalf("DeleteIdentity: %v", err)
+	}
+	if fact := setName(t, svc, "Maggie"); fact.Version != 2 {
+		t.Errorf("version after delete = %d, want 2", fact.Version)
+	}
+}
+
+func TestIdentityRollbackImmutable(t *testing.T) {
+	ctx := context.Background()
+	svc := NewIdentityService(storage.NewMemoryStorage(), nil)
+	setName(t, svc, "Margaret")
+	locked := &models.IdentityFact{UserID: "u1", Key: "name", Value: "Margaret Rose", Immutable: true}
+	if err := svc.Set(ctx, locked); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if _, err := svc.Rollback(ctx, "u1", "name", 1, "admin"); !errors.Is(err, ErrImmutable) {
+		t.Errorf("Rollback(immutable) err = %v, want ErrImmutable", err)
+	}
+}
+
+func TestIdentityDeleteRecordsHistory(t *testing.T) {
+	ctx := context.Background()
+	svc := NewIdentityService(storage.NewMemoryStorage(), nil)
+	setName(t, svc, "Margaret")
+
+	if err := svc.Delete(ctx, "u1", "name", "carer"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, _ := svc.Get(ctx, "u1", "name"); got != nil {
+		t.Errorf("Get after delete = %+v, want nil", got)
+	}
+	if err := svc.Delete(ctx, "u1", "name", "carer"); !errors.Is(err, ErrIdentityNotFound) {
+		t.Errorf("Delete(missing) err = %v, want ErrIdentityNotFound", err)
+	}
+
+	revs, _ := svc.History(ctx, "u1", "name")
+	if len(revs) != 2 || !revs[1].Deleted || revs[1].Version != 2 {
+		t.Fatalf("history after delete = %+v", revs)
+	}
+	if _, err := svc.Rollback(ctx, "u1", "name", 2, "carer"); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("Rollback(deletion) err = %v, want ErrRevisionNotFound", err)
+	}
+	fact, err := svc.Rollback(ctx, "u1", "name", 1, "carer")
+	if err != nil || fact.Version != 3 || fact.Value != "Margaret" {
+		t.Errorf("Rollback after delete = %+v, %v", fact, err)
+	}
+}
+
+func TestIdentityGetManyLimit(t *testing.T) {
+	svc := NewIdentityService(storage.NewMemoryStorage(), nil)
+	keys := make([]string, maxBatchKeys+1)
+	for i := range keys {
+		keys[i] = "k"
+	}
+	if _, err := svc.GetMany(context.Background(), "u1", keys); err == nil {
+		t.Error("GetMany accepted more than maxBatchKeys keys")
+	}
+}
+
+func TestIdentitySetValidatesSchema(t *testing.T) {
+	ctx := context.Background()
+	svc := NewIdentityService(storage.NewMemoryStorage(), schema.Default())
+
+	bad := &models.IdentityFact{UserID: "u1", Key: "children", Value: "Tom, Sarah"}
+	if err := svc.Set(ctx, bad); !errors.Is(err, schema.ErrInvalidValue) {
+		t.Errorf("Set(comma string) err = %v, want ErrInvalidValue", err)
+	}
+
+	birth := &models.IdentityFact{UserID: "u1", Key: "birthdate", Value: "1941-03-09T00:00:00Z"}
+	if err := svc.Set(ctx, birth); err != nil {
+		t.Fatalf("Set(birthdate): %v", err)
+	}
+	got, _ := svc.Get(ctx, "u1", "birthdate")
+	if got.Value != "1941-03-09" || !got.Immutable {
+		t.Errorf("birthdate = %+v, want normalised and immutable by default", got)
+	}
+}
+
+// staleHistoryStore hides the history, as if read before a concurrent write.
+type staleHistoryStore struct {
+	storage.Storage
+}
+
+func (staleHistoryStore) ListIdentityRevisions(ctx context.Context, userID, key string) ([]models.IdentityRevision, error) {
+	return nil, nil
+}
+
+func TestIdentitySetLosesVersionRace(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	setName(t, NewIdentityService(store, nil), "Margaret")
+
+	// Another writer has claimed version 2 but not yet written its fact.
+	rev := &models.IdentityRevision{UserID: "u1", Key: "name", Version: 2, Value: "Peggy", CreatedAt: time.Now()}
+	if err := store.AppendIdentityRevision(ctx, rev); err != nil {
+		t.Fatalf("AppendIdentityRevision: %v", err)
+	}
+
+	svc := NewIdentityService(staleHistoryStore{store}, nil)
+	if err := svc.Set(ctx, &models.IdentityFact{UserID: "u1", Key: "name", Value: "Margret"}); err == nil {
+		t.Fatal("Set reusing a claimed version succeeded")
+	}
+	if err := svc.Delete(ctx, "u1", "name", "carer"); err == nil {
+		t.Fatal("Delete reusing a claimed version succeeded")
+	}
+	if fact, _ := store.GetIdentity(ctx, "u1", "name"); fact == nil || fact.Value != "Margaret" {
+		t.Errorf("fact = %+v, want the losing writes to leave it alone", fact)
+	}
+}
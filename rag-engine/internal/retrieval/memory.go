@@ -2,110 +2,477 @@ package retrieval
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"sort"
 	"time"
 
+	"github.com/memory-lane/rag-engine/internal/embedding"
 	"github.com/memory-lane/rag-engine/internal/index"
 	"github.com/memory-lane/rag-engine/internal/models"
 	"github.com/memory-lane/rag-engine/internal/storage"
 )
 
+// ErrMemoryNotFound is returned when updating or deleting an unknown chunk.
+var ErrMemoryNotFound = errors.New("memory not found")
+
+// maxListLimit caps (and defaults) the page size of List.
+const maxListLimit = 100
+
+// SourceFile marks memories uploaded from files, which Store chunks.
+const SourceFile = "file"
+
 // MemoryService handles memory storage, retrieval, and scoring.
 type MemoryService struct {
-	store storage.Storage
+	store    storage.Storage
+	embedder embedding.Embedder // nil = lexical search only
+	chunker  *index.Chunker
+}
+
+// NewMemoryService creates a new memory service. embedder may be nil, in
+// which case chunks are stored without vectors and search is purely lexical.
+// A nil chunker uses the default sizes.
+func NewMemoryService(store storage.Storage, embedder embedding.Embedder, chunker *index.Chunker) *MemoryService {
+	if chunker == nil {
+		chunker = index.NewChunker(0, 0)
+	}
+	return &MemoryService{store: store, embedder: embedder, chunker: chunker}
 }
 
-// NewMemoryService creates a new memory service.
-func NewMemoryService(store storage.Storage) *MemoryService {
-	return &MemoryService{store: store}
+// StoreResult lists the chunks written by Store, in order. DocumentID links
+// them when long content was split.
+type StoreResult struct {
+	DocumentID string   `json:"document_id,omitempty"`
+	ChunkIDs   []string `json:"chunk_ids"`
 }
 
-// Store persists a memory chunk and indexes its tokens, scoped to a replica.
-func (s *MemoryService) Store(ctx context.Context, userID, replicaID, content, source, sessionID string, importance float64) (string, error) {
+// Store persists a memory and indexes its tokens, scoped to a replica.
+// Content from files longer than the chunker's size is split into chunks
+// that share a document ID and record their ordinal. If one fails, those
+// already written are removed again.
+func (s *MemoryService) Store(ctx context.Context, userID, replicaID, content, source, sessionID string, importance float64) (*StoreResult, error) {
 	if userID == "" || content == "" {
-		return "", fmt.Errorf("user_id and content are required")
+		return nil, models.Required("user_id", "content")
 	}
 
-	tokens := index.Tokenize(content)
-	now := time.Now()
-	chunkID := fmt.Sprintf("%s-%d", userID, now.UnixNano())
+	pieces := []string{content}
+	if source == SourceFile {
+		if split := s.chunker.Split(content); len(split) > 1 {
+			pieces = split
+		}
+	}
 
-	chunk := &models.MemoryChunk{
+	now := time.Now()
+	res := &StoreResult{}
+	if len(pieces) > 1 {
+		res.DocumentID = NewDocumentID(userID, now)
+	}
+	base := models.MemoryChunk{
 		UserID:     userID,
 		ReplicaID:  replicaID,
-		ChunkID:    chunkID,
-		Content:    content,
-		Tokens:     tokens,
 		Importance: importance,
 		Source:     source,
 		SessionID:  sessionID,
-		CreatedAt:  now,
+		DocumentID: res.DocumentID,
+	}
+	ids, err := s.storePieces(ctx, base, pieces, now)
+	if err != nil {
+		return nil, err
+	}
+	res.ChunkIDs = ids
+	return res, nil
+}
+
+// NewDocumentID returns the ID linking the chunks of one document. A random
+// suffix keeps documents stored at the same instant apart.
+func NewDocumentID(userID string, at time.Time) string {
+	return fmt.Sprintf("doc-%s-%d-%s", userID, at.UnixNano(), randomSuffix())
+}
+
+// newChunkID returns the ID of the piece at ordinal. Pieces of a document
+// are numbered within it; lone chunks get a random suffix, so concurrent
+// stores never share an ID.
+func newChunkID(base models.MemoryChunk, ordinal int, at time.Time) string {
+	if base.DocumentID != "" {
+		return fmt.Sprintf("%s-%d", base.DocumentID, ordinal)
+	}
+	return fmt.Sprintf("%s-%d-%s", base.UserID, at.UnixNano(), randomSuffix())
+}
+
+func randomSuffix() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// StoreDocument chunks the text of an ingested document and stores the
+// chunks as file memories under documentID, returning their IDs in order.
+// Unlike Store, it links the chunks even when the text fits in one.
+func (s *MemoryService) StoreDocument(ctx context.Context, userID, replicaID, documentID, text string, importance float64) ([]string, error) {
+	if userID == "" || documentID == "" {
+		return nil, models.Required("user_id", "document_id")
+	}
+	pieces := s.chunker.Split(text)
+	if len(pieces) == 0 {
+		return nil, fmt.Errorf("document has no text")
+	}
+	base := models.MemoryChunk{
+		UserID:     userID,
+		ReplicaID:  replicaID,
+		Importance: importance,
+		Source:     SourceFile,
+		DocumentID: documentID,
+	}
+	return s.storePieces(ctx, base, pieces, time.Now())
+}
+
+// storePieces stores one chunk per piece, copying the other fields from
+// base. If one fails, those already written are removed again.
+func (s *MemoryService) storePieces(ctx context.Context, base models.MemoryChunk, pieces []string, now time.Time) ([]string, error) {
+	ids := make([]string, 0, len(pieces))
+	for i, piece := range pieces {
+		// A nanosecond apart, so creation order follows the pieces.
+		at := now.Add(time.Duration(i))
+		chunk := base
+		chunk.ChunkID = newChunkID(base, i, at)
+		chunk.Content = piece
+		chunk.Ordinal = i
+		chunk.CreatedAt = at
+		if err := s.storeChunk(ctx, &chunk); err != nil {
+			for _, id := range ids {
+				if delErr := s.Delete(context.WithoutCancel(ctx), base.UserID, id); delErr != nil {
+					log.Printf("⚠️  Failed to remove chunk %s of a failed store: %v", id, delErr)
+				}
+			}
+			return nil, err
+		}
+		ids = append(ids, chunk.ChunkID)
 	}
+	return ids, nil
+}
+
+// storeChunk tokenizes, embeds and writes one chunk with its index entries
+// and corpus stats. If indexing fails, the chunk and its index entries are
+// removed again.
+func (s *MemoryService) storeChunk(ctx context.Context, chunk *models.MemoryChunk) error {
+	chunk.Tokens = index.Tokenize(chunk.Content)
+	chunk.TermFreq, chunk.Length = index.TermFrequencies(chunk.Content)
+	s.embed(ctx, chunk)
 
 	if err := s.store.StoreMemory(ctx, chunk); err != nil {
-		return "", err
+		return err
+	}
+	err := s.indexTokens(ctx, chunk, chunk.CreatedAt)
+	if err == nil {
+		err = s.updateStats(ctx, chunk, 1)
+	}
+	if err != nil {
+		s.unstore(context.WithoutCancel(ctx), chunk)
+		return err
+	}
+	return nil
+}
+
+// unstore removes a chunk, and its index entries, whose store failed after
+// the chunk was written.
+func (s *MemoryService) unstore(ctx context.Context, chunk *models.MemoryChunk) {
+	if err := s.store.DeleteMemory(ctx, chunk.UserID, chunk.ChunkID); err != nil {
+		log.Printf("⚠️  Failed to remove chunk %s of a failed store: %v", chunk.ChunkID, err)
+	}
+	if err := s.store.RemoveTokens(ctx, chunk.UserID, chunk.ChunkID, chunk.Tokens); err != nil {
+		log.Printf("⚠️  Failed to remove tokens of chunk %s of a failed store: %v", chunk.ChunkID, err)
+	}
+}
+
+// Update replaces a chunk's content and re-indexes it. A nil importance
+// keeps the current value. Returns ErrMemoryNotFound for unknown chunks.
+func (s *MemoryService) Update(ctx context.Context, userID, chunkID, content string, importance *float64) (*models.MemoryChunk, error) {
+	if userID == "" || chunkID == "" || content == "" {
+		return nil, models.Required("user_id", "chunk_id", "content")
+	}
+
+	old, err := s.store.GetMemory(ctx, userID, chunkID)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, ErrMemoryNotFound
+	}
+
+	chunk := *old
+	chunk.Content = content
+	chunk.Tokens = index.Tokenize(content)
+	chunk.TermFreq, chunk.Length = index.TermFrequencies(content)
+	chunk.Embedding, chunk.EmbeddingModel = nil, ""
+	if importance != nil {
+		chunk.Importance = *importance
+	}
+	s.embed(ctx, &chunk)
+
+	// Replace the chunk, then swap its index entries and its share of the
+	// corpus stats. If a step fails, the ones done are undone in reverse.
+	var undo []func(context.Context) error
+	fail := func(err error) (*models.MemoryChunk, error) {
+		ctx := context.WithoutCancel(ctx)
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](ctx); undoErr != nil {
+				log.Printf("⚠️  Failed to undo a failed update of chunk %s: %v", chunkID, undoErr)
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.store.UpdateMemory(ctx, &chunk); err != nil {
+		return nil, err
+	}
+	undo = append(undo, func(ctx context.Context) error { return s.store.UpdateMemory(ctx, old) })
+
+	if err := s.store.RemoveTokens(ctx, userID, chunkID, old.Tokens); err != nil {
+		return fail(fmt.Errorf("remove tokens: %w", err))
+	}
+	undo = append(undo, func(ctx context.Context) error { return s.indexTokens(ctx, old, old.CreatedAt) })
+
+	if err := s.indexTokens(ctx, &chunk, time.Now()); err != nil {
+		return fail(err)
+	}
+	undo = append(undo, func(ctx context.Context) error {
+		return s.store.RemoveTokens(ctx, userID, chunkID, chunk.Tokens)
+	})
+
+	if err := s.updateStats(ctx, old, -1); err != nil {
+		return fail(err)
+	}
+	undo = append(undo, func(ctx context.Context) error { return s.updateStats(ctx, old, 1) })
+
+	if err := s.updateStats(ctx, &chunk, 1); err != nil {
+		return fail(err)
+	}
+	return &chunk, nil
+}
+
+// Delete removes a chunk together with its index entries and corpus stats.
+// Returns ErrMemoryNotFound for unknown chunks.
+func (s *MemoryService) Delete(ctx context.Context, userID, chunkID string) error {
+	if userID == "" || chunkID == "" {
+		return models.Required("user_id", "chunk_id")
+	}
+
+	chunk, err := s.store.GetMemory(ctx, userID, chunkID)
+	if err != nil {
+		return err
+	}
+	if chunk == nil {
+		return ErrMemoryNotFound
+	}
+
+	if err := s.store.DeleteMemory(ctx, userID, chunkID); err != nil {
+		return err
+	}
+	if err := s.store.RemoveTokens(ctx, userID, chunkID, chunk.Tokens); err != nil {
+		return fmt.Errorf("remove tokens: %w", err)
 	}
+	return s.updateStats(ctx, chunk, -1)
+}
 
-	// Build token index entries
-	entries := make([]models.TokenEntry, len(tokens))
-	for i, t := range tokens {
+// indexTokens writes a token index entry per unique token of the chunk.
+func (s *MemoryService) indexTokens(ctx context.Context, chunk *models.MemoryChunk, at time.Time) error {
+	entries := make([]models.TokenEntry, len(chunk.Tokens))
+	for i, t := range chunk.Tokens {
 		entries[i] = models.TokenEntry{
 			Token:     t,
-			UserID:    userID,
-			ReplicaID: replicaID,
-			ChunkID:   chunkID,
-			Timestamp: now,
+			UserID:    chunk.UserID,
+			ReplicaID: chunk.ReplicaID,
+			ChunkID:   chunk.ChunkID,
+			Timestamp: at,
 		}
 	}
 	if err := s.store.IndexTokens(ctx, entries); err != nil {
-		return "", fmt.Errorf("index tokens: %w", err)
+		return fmt.Errorf("index tokens: %w", err)
 	}
+	return nil
+}
 
-	return chunkID, nil
+// updateStats applies a chunk to the BM25 corpus statistics of its replica
+// and of the user-wide scope (empty replica ID) used by unscoped searches.
+func (s *MemoryService) updateStats(ctx context.Context, chunk *models.MemoryChunk, delta int) error {
+	scopes := []string{""}
+	if chunk.ReplicaID != "" {
+		scopes = append(scopes, chunk.ReplicaID)
+	}
+	for _, replicaID := range scopes {
+		if err := s.store.UpdateCorpusStats(ctx, chunk.UserID, replicaID, chunk.Tokens, chunk.Length, delta); err != nil {
+			return fmt.Errorf("update corpus stats: %w", err)
+		}
+	}
+	return nil
 }
 
 // Search finds the most relevant memory chunks for a query, scoped to a replica.
-// Scoring: score = overlap * 0.7 + importance * 0.3
-func (s *MemoryService) Search(ctx context.Context, userID, replicaID, query string, topK int) ([]models.ScoredChunk, error) {
+// Scoring: score = relevance * 0.7 + importance * 0.3. Relevance is the BM25
+// score normalised to 0.0 – 1.0 against the best candidate; when an embedder
+// is configured it is the reciprocal rank fusion of the BM25 ranking and the
+// cosine-similarity ranking, normalised the same way. Optional recency and
+// reinforcement terms are described on SearchOptions.
+//
+// topK is the page size. When more results follow, the returned cursor can be
+// passed back as opts.Cursor to fetch the next page. Returned chunks have
+// their access count bumped.
+func (s *MemoryService) Search(ctx context.Context, userID, replicaID, query string, topK int, opts SearchOptions) ([]models.ScoredChunk, string, error) {
 	if userID == "" || query == "" {
-		return nil, fmt.Errorf("user_id and query are required")
+		return nil, "", models.Required("user_id", "query")
 	}
 	if topK <= 0 {
 		topK = 3
 	}
 
+	var pos searchPosition
+	if opts.Cursor != "" {
+		if err := storage.DecodeCursor(opts.Cursor, &pos); err != nil {
+			return nil, "", err
+		}
+	}
+
 	queryTokens := index.Tokenize(query)
 	if len(queryTokens) == 0 {
-		return nil, nil
+		return nil, "", nil
 	}
 
-	// Fetch candidate chunks scoped to replica
-	chunks, err := s.store.SearchMemoryByTokens(ctx, userID, replicaID, queryTokens)
+	candidates, err := s.lexicalCandidates(ctx, userID, replicaID, queryTokens, opts.Filter)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	if len(chunks) == 0 {
-		return nil, nil
+	if s.embedder != nil {
+		if err := s.semanticCandidates(ctx, userID, replicaID, query, opts.Filter, candidates); err != nil {
+			return nil, "", err
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, "", nil
+	}
+
+	relevance := lexicalRelevance
+	if s.embedder != nil {
+		relevance = fusedRelevance
 	}
+	rel := relevance(candidates)
 
 	// Score each chunk
-	scored := make([]models.ScoredChunk, len(chunks))
-	for i, c := range chunks {
-		overlap := index.TokenOverlap(queryTokens, c.Tokens)
-		score := overlap*0.7 + c.Importance*0.3
-		scored[i] = models.ScoredChunk{Chunk: c, Score: score}
+	now := time.Now()
+	scored := make([]models.ScoredChunk, 0, len(candidates))
+	for id, c := range candidates {
+		score := opts.score(rel[id], c.chunk, now)
+		scored = append(scored, models.ScoredChunk{Chunk: c.chunk, Score: score})
 	}
 
-	// Sort by score descending
+	// Sort by score descending, chunk ID as a stable tie-break
 	sort.Slice(scored, func(i, j int) bool {
-		return scored[i].Score > scored[j].Score
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Chunk.ChunkID < scored[j].Chunk.ChunkID
 	})
 
+	// Skip what earlier pages returned
+	if opts.Cursor != "" {
+		start := sort.Search(len(scored), func(i int) bool { return pos.before(scored[i]) })
+		scored = scored[start:]
+	}
+
 	// Trim to topK
+	var next string
 	if len(scored) > topK {
 		scored = scored[:topK]
+		last := scored[topK-1]
+		next = storage.EncodeCursor(searchPosition{Score: last.Score, ChunkID: last.Chunk.ChunkID})
 	}
 
-	return scored, nil
+	if opts.Explain {
+		for i := range scored {
+			id := scored[i].Chunk.ChunkID
+			scored[i].Explanation = explain(candidates[id], opts.components(rel[id], scored[i].Chunk, now), queryTokens)
+		}
+	}
+
+	// Reinforce what was retrieved. Bookkeeping failures must not fail the search.
+	ids := make([]string, len(scored))
+	for i, sc := range scored {
+		ids[i] = sc.Chunk.ChunkID
+	}
+	if err := s.store.RecordAccess(ctx, userID, ids, now); err != nil {
+		log.Printf("⚠️  recording memory access failed: %v", err)
+	}
+
+	return scored, next, nil
+}
+
+// searchPosition is the search cursor: the last result of the previous page.
+// Scores are recomputed on every call, so a page boundary can shift when
+// memories are added or recency and reinforcement move in between.
+type searchPosition struct {
+	Score   float64 `json:"s"`
+	ChunkID string  `json:"id"`
+}
+
+// before reports whether a result ranks strictly after the position.
+func (p searchPosition) before(sc models.ScoredChunk) bool {
+	if sc.Score != p.Score {
+		return sc.Score < p.Score
+	}
+	return sc.Chunk.ChunkID > p.ChunkID
+}
+
+// List returns one page of a replica's memories in creation order.
+func (s *MemoryService) List(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, limit int, cursor string) ([]models.MemoryChunk, string, error) {
+	if userID == "" {
+		return nil, "", models.Required("user_id")
+	}
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	return s.store.ListMemory(ctx, userID, replicaID, filter, models.Page{Limit: limit, Cursor: cursor})
+}
+
+// lexicalCandidates fetches chunks sharing a token with the query and
+// scores them with BM25.
+func (s *MemoryService) lexicalCandidates(ctx context.Context, userID, replicaID string, queryTokens []string, filter models.MemoryFilter) (map[string]*candidate, error) {
+	chunks, err := s.store.SearchMemoryByTokens(ctx, userID, replicaID, queryTokens, filter)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make(map[string]*candidate, len(chunks))
+	if len(chunks) == 0 {
+		return candidates, nil
+	}
+
+	stats, err := s.store.GetCorpusStats(ctx, userID, replicaID, queryTokens)
+	if err != nil {
+		return nil, fmt.Errorf("corpus stats: %w", err)
+	}
+	bm25 := index.NewBM25(stats.DocCount, stats.TotalLength, stats.DocFreq)
+
+	for _, c := range chunks {
+		termFreq, length := chunkTermFreq(c)
+		candidates[c.ChunkID] = &candidate{
+			chunk:   c,
+			lexical: bm25.Score(queryTokens, termFreq, length),
+		}
+	}
+	rankBy(candidates, func(c *candidate) float64 { return c.lexical }, func(c *candidate, r int) { c.lexicalRank = r })
+	return candidates, nil
+}
+
+// chunkTermFreq returns a chunk's term frequencies and length. Chunks stored
+// before term frequencies were recorded count each unique token once.
+func chunkTermFreq(c models.MemoryChunk) (map[string]int, int) {
+	if c.TermFreq != nil {
+		return c.TermFreq, c.Length
+	}
+	termFreq := make(map[string]int, len(c.Tokens))
+	for _, t := range c.Tokens {
+		termFreq[t] = 1
+	}
+	return termFreq, len(c.Tokens)
 }
@@ -0,0 +1,437 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/embedding"
+	"github.com/memory-lane/rag-engine/internal/index"
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+func storeAll(t *testing.T, svc *MemoryService, replicaID string, contents ...string) []string {
+	t.Helper()
+	ids := make([]string, len(contents))
+	for i, c := range contents {
+		res, err := svc.Store(context.Background(), "u1", replicaID, c, "manual", "", 0.5)
+		if err != nil {
+			t.Fatalf("Store(%q): %v", c, err)
+		}
+		ids[i] = res.ChunkIDs[0]
+	}
+	return ids
+}
+
+func TestSearchRanksRareTermsHigher(t *testing.T) {
+	svc := NewMemoryService(storage.NewMemoryStorage(), nil, nil)
+	ids := storeAll(t, svc, "r1",
+		"The wedding cake was enormous",
+		"We danced all night at the wedding",
+		"A summer wedding by the lake",
+		"Sarah looked radiant at her wedding",
+	)
+
+	results, _, err := svc.Search(context.Background(), "u1", "r1", "Sarah wedding", 4, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Search returned %d results, want 4", len(results))
+	}
+	if results[0].Chunk.ChunkID != ids[3] {
+		t.Errorf("top result = %q, want the chunk mentioning Sarah", results[0].Chunk.Content)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("rare-term match should score strictly higher: %.3f vs %.3f", results[0].Score, results[1].Score)
+	}
+}
+
+func TestSearchScopedToReplica(t *testing.T) {
+	svc := NewMemoryService(storage.NewMemoryStorage(), nil, nil)
+	storeAll(t, svc, "r1", "Gardening with roses every spring")
+	storeAll(t, svc, "r2", "Gardening tomatoes in the greenhouse")
+
+	results, _, err := svc.Search(context.Background(), "u1", "r2", "gardening", 5, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Chunk.ReplicaID != "r2" {
+		t.Fatalf("Search(r2) = %+v, want only the r2 chunk", results)
+	}
+
+	all, _, err := svc.Search(context.Background(), "u1", "", "gardening", 5, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Search(all replicas) returned %d results, want 2", len(all))
+	}
+}
+
+func TestHybridSearchFindsParaphrases(t *testing.T) {
+	lexicalOnly := NewMemoryService(storage.NewMemoryStorage(), nil, nil)
+	storeAll(t, lexicalOnly, "r1", "My son Tom scored the winning goal", "We baked bread on Sundays")
+	if res, _, _ := lexicalOnly.Search(context.Background(), "u1", "r1", "my boy", 3, SearchOptions{}); len(res) != 0 {
+		t.Fatalf("lexical search unexpectedly matched a paraphrase: %+v", res)
+	}
+
+	hybrid := NewMemoryService(storage.NewMemoryStorage(), embedding.NewHashEmbedder(0), nil)
+	ids := storeAll(t, hybrid, "r1", "My son Tom scored the winning goal", "We baked bread on Sundays")
+
+	results, _, err := hybrid.Search(context.Background(), "u1", "r1", "my boy", 3, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 || results[0].Chunk.ChunkID != ids[0] {
+		t.Fatalf("hybrid Search(my boy) = %+v, want the chunk about my son", results)
+	}
+	for _, r := range results {
+		if r.Chunk.ChunkID == ids[1] {
+			t.Errorf("unrelated chunk %q was returned", r.Chunk.Content)
+		}
+	}
+}
+
+// noListStore fails the test if search lists a whole memory scope.
+type noListStore struct {
+	storage.Storage
+	t *testing.T
+}
+
+func (s noListStore) ListMemory(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, page models.Page) ([]models.MemoryChunk, string, error) {
+	s.t.Errorf("search listed memory with page %+v", page)
+	return s.Storage.ListMemory(ctx, userID, replicaID, filter, page)
+}
+
+func TestHybridSearchReadsBoundedWindow(t *testing.T) {
+	hybrid := NewMemoryService(noListStore{storage.NewMemoryStorage(), t}, embedding.NewHashEmbedder(0), nil)
+	ids := storeAll(t, hybrid, "r1", "My son Tom scored the winning goal", "We baked bread on Sundays")
+	results, _, err := hybrid.Search(context.Background(), "u1", "r1", "my boy", 3, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 || results[0].Chunk.ChunkID != ids[0] {
+		t.Errorf("Search(my boy) = %+v, want the recent chunk about my son", results)
+	}
+}
+
+// storeAt writes an indexed chunk with an explicit creation time.
+func storeAt(t *testing.T, store storage.Storage, chunkID, content string, createdAt time.Time) {
+	t.Helper()
+	tokens := index.Tokenize(content)
+	chunk := &models.MemoryChunk{
+		UserID: "u1", ReplicaID: "r1", ChunkID: chunkID, Content: content,
+		Tokens: tokens, Importance: 0.5, CreatedAt: createdAt,
+	}
+	if err := store.StoreMemory(context.Background(), chunk); err != nil {
+		t.Fatalf("StoreMemory: %v", err)
+	}
+	entries := make([]models.TokenEntry, len(tokens))
+	for i, tok := range tokens {
+		entries[i] = models.TokenEntry{Token: tok, UserID: "u1", ReplicaID: "r1", ChunkID: chunkID, Timestamp: createdAt}
+	}
+	if err := store.IndexTokens(context.Background(), entries); err != nil {
+		t.Fatalf("IndexTokens: %v", err)
+	}
+}
+
+func TestSearchRecencyDecay(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	svc := NewMemoryService(store, nil, nil)
+	storeAt(t, store, "a-old", "Holiday in Cornwall", time.Now().AddDate(-2, 0, 0))
+	storeAt(t, store, "b-new", "Holiday in Cornwall", time.Now().AddDate(0, 0, -1))
+
+	plain, _, err := svc.Search(context.Background(), "u1", "r1", "holiday", 2, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if plain[0].Score != plain[1].Score {
+		t.Fatalf("without recency both chunks should tie: %.3f vs %.3f", plain[0].Score, plain[1].Score)
+	}
+
+	recent, _, err := svc.Search(context.Background(), "u1", "r1", "holiday", 2, SearchOptions{RecencyWeight: 0.5})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if recent[0].Chunk.ChunkID != "b-new" || recent[0].Score <= recent[1].Score {
+		t.Errorf("recency should rank the newer chunk first, got %s (%.3f) then %s (%.3f)",
+			recent[0].Chunk.ChunkID, recent[0].Score, recent[1].Chunk.ChunkID, recent[1].Score)
+	}
+}
+
+func TestSearchReinforcement(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	svc := NewMemoryService(store, nil, nil)
+	created := time.Now().AddDate(0, -1, 0)
+	storeAt(t, store, "a", "Holiday in Cornwall", created)
+	storeAt(t, store, "b", "Holiday in Cornwall", created)
+
+	// Searching records an access on every returned chunk.
+	if _, _, err := svc.Search(context.Background(), "u1", "r1", "holiday", 2, SearchOptions{}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	chunks, _, _ := store.ListMemory(context.Background(), "u1", "r1", models.MemoryFilter{}, models.Page{})
+	for _, c := range chunks {
+		if c.AccessCount != 1 || c.LastAccessedAt == nil {
+			t.Errorf("chunk %s access = (%d, %v), want (1, set)", c.ChunkID, c.AccessCount, c.LastAccessedAt)
+		}
+	}
+
+	for range 3 {
+		if err := store.RecordAccess(context.Background(), "u1", []string{"b"}, time.Now()); err != nil {
+			t.Fatalf("RecordAccess: %v", err)
+		}
+	}
+	results, _, err := svc.Search(context.Background(), "u1", "r1", "holiday", 1, SearchOptions{ReinforcementWeight: 0.5})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if results[0].Chunk.ChunkID != "b" {
+		t.Errorf("top result = %s, want the frequently accessed chunk b", results[0].Chunk.ChunkID)
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	svc := NewMemoryService(storage.NewMemoryStorage(), nil, nil)
+	storeAll(t, svc, "r1",
+		"Holiday in Cornwall",
+		"Holiday in Devon",
+		"Holiday in Wales",
+		"Holiday in Scotland",
+		"Holiday in Kent",
+	)
+
+	seen := make(map[string]bool)
+	var cursor string
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		results, next, err := svc.Search(context.Background(), "u1", "r1", "holiday", 2, SearchOptions{Cursor: cursor})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		for _, r := range results {
+			if seen[r.Chunk.ChunkID] {
+				t.Fatalf("chunk %s returned twice", r.Chunk.ChunkID)
+			}
+			seen[r.Chunk.ChunkID] = true
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(seen) != 5 {
+		t.Errorf("paged through %d chunks, want 5", len(seen))
+	}
+
+	if _, _, err := svc.Search(context.Background(), "u1", "r1", "holiday", 2, SearchOptions{Cursor: "%%%"}); !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("Search(bad cursor) error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestSearchExplain(t *testing.T) {
+	svc := NewMemoryService(storage.NewMemoryStorage(), nil, nil)
+	content := "Zoë remembers the lake house. Years later, at the very end of a long summer, we sold the lake house."
+	storeAll(t, svc, "r1", content, "A cabin in the mountains")
+
+	results, _, err := svc.Search(context.Background(), "u1", "r1", "lake summer", 1, SearchOptions{Explain: true, RecencyWeight: 0.2})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	ex := results[0].Explanation
+	if ex == nil {
+		t.Fatal("Explain set but no explanation returned")
+	}
+
+	sum := 0.0
+	var names []string
+	for _, c := range ex.Components {
+		sum += c.Contribution
+		names = append(names, c.Name)
+	}
+	if math.Abs(sum-results[0].Score) > 1e-9 {
+		t.Errorf("contributions sum to %.6f, score is %.6f", sum, results[0].Score)
+	}
+	if !reflect.DeepEqual(names, []string{"relevance", "importance", "recency"}) {
+		t.Errorf("components = %v", names)
+	}
+	if !reflect.DeepEqual(ex.MatchedTokens, []string{"lake", "summer"}) {
+		t.Errorf("matched tokens = %v", ex.MatchedTokens)
+	}
+	if ex.Lexical <= 0 {
+		t.Errorf("lexical score = %v, want > 0", ex.Lexical)
+	}
+
+	runes := []rune(content)
+	highlights := 0
+	for _, s := range ex.Snippets {
+		if string(runes[s.Start:s.End]) != s.Text {
+			t.Errorf("snippet %q does not match content[%d:%d]", s.Text, s.Start, s.End)
+		}
+		text := []rune(s.Text)
+		for _, h := range s.Highlights {
+			if got := strings.ToLower(string(text[h.Start:h.End])); got != h.Token {
+				t.Errorf("highlight %+v covers %q", h, got)
+			}
+			highlights++
+		}
+	}
+	if highlights != 3 {
+		t.Errorf("got %d highlights, want 3 (lake twice, summer once)", highlights)
+	}
+
+	plain, _, _ := svc.Search(context.Background(), "u1", "r1", "lake", 1, SearchOptions{})
+	if plain[0].Explanation != nil {
+		t.Error("explanation returned without Explain")
+	}
+}
+
+func TestUpdateAndDeleteMaintainIndex(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	svc := NewMemoryService(store, nil, nil)
+	ids := storeAll(t, svc, "r1", "Sunday roast with the family", "Walking the dog on Sunday")
+
+	imp := 0.9
+	updated, err := svc.Update(ctx, "u1", ids[0], "Christmas dinner with the family", &imp)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Importance != 0.9 || updated.ChunkID != ids[0] {
+		t.Errorf("Update returned %+v", updated)
+	}
+
+	if res, _, _ := svc.Search(ctx, "u1", "r1", "roast", 5, SearchOptions{}); len(res) != 0 {
+		t.Errorf("old content still searchable: %+v", res)
+	}
+	if res, _, _ := svc.Search(ctx, "u1", "r1", "christmas", 5, SearchOptions{}); len(res) != 1 || res[0].Chunk.ChunkID != ids[0] {
+		t.Errorf("Search(christmas) = %+v, want the updated chunk", res)
+	}
+
+	stats, _ := store.GetCorpusStats(ctx, "u1", "r1", []string{"sunday", "roast", "christmas"})
+	if stats.DocCount != 2 || stats.DocFreq["sunday"] != 1 || stats.DocFreq["roast"] != 0 || stats.DocFreq["christmas"] != 1 {
+		t.Errorf("stats after update = %+v", stats)
+	}
+
+	if err := svc.Delete(ctx, "u1", ids[1]); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if res, _, _ := svc.Search(ctx, "u1", "r1", "dog", 5, SearchOptions{}); len(res) != 0 {
+		t.Errorf("deleted chunk still searchable: %+v", res)
+	}
+	for _, scope := range []string{"", "r1"} {
+		stats, _ := store.GetCorpusStats(ctx, "u1", scope, []string{"sunday"})
+		if stats.DocCount != 1 || stats.DocFreq["sunday"] != 0 {
+			t.Errorf("stats(%q) after delete = %+v", scope, stats)
+		}
+	}
+
+	if err := svc.Delete(ctx, "u1", ids[1]); !errors.Is(err, ErrMemoryNotFound) {
+		t.Errorf("second Delete error = %v, want ErrMemoryNotFound", err)
+	}
+	if _, err := svc.Update(ctx, "u2", ids[0], "hijack", nil); !errors.Is(err, ErrMemoryNotFound) {
+		t.Errorf("Update by another user error = %v, want ErrMemoryNotFound", err)
+	}
+}
+
+func TestStoreChunksFiles(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	svc := NewMemoryService(store, nil, index.NewChunker(8, -1))
+	letter := "We married at St Anne's in June. It rained all day.\n\n" +
+		"Afterwards we moved to Leeds for Dad's work. The house had a blue door."
+
+	res, err := svc.Store(ctx, "u1", "r1", letter, SourceFile, "", 0.5)
+	if err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if len(res.ChunkIDs) < 2 || res.DocumentID == "" {
+		t.Fatalf("Store(file) = %+v, want several chunks of one document", res)
+	}
+	for i, id := range res.ChunkIDs {
+		if want := fmt.Sprintf("%s-%d", res.DocumentID, i); id != want {
+			t.Errorf("chunk %d ID = %q, want %q", i, id, want)
+		}
+		chunk, _ := store.GetMemory(ctx, "u1", id)
+		if chunk == nil || chunk.DocumentID != res.DocumentID || chunk.Ordinal != i || chunk.Source != SourceFile {
+			t.Errorf("chunk %d = %+v", i, chunk)
+		}
+	}
+	if hits, _, _ := svc.Search(ctx, "u1", "r1", "blue door", 1, SearchOptions{}); len(hits) != 1 || hits[0].Chunk.ChunkID != res.ChunkIDs[len(res.ChunkIDs)-1] {
+		t.Errorf("Search(blue door) = %+v, want the last chunk", hits)
+	}
+
+	// Other sources are stored whole, however long.
+	res, err = svc.Store(ctx, "u1", "r1", letter, "manual", "", 0.5)
+	if err != nil || len(res.ChunkIDs) != 1 || res.DocumentID != "" {
+		t.Errorf("Store(manual) = %+v, %v; want one chunk", res, err)
+	}
+}
+
+// failIndexStore fails the failAt-th IndexTokens call.
+type failIndexStore struct {
+	storage.Storage
+	failAt int
+	calls  int
+}
+
+func (s *failIndexStore) IndexTokens(ctx context.Context, entries []models.TokenEntry) error {
+	s.calls++
+	if s.calls == s.failAt {
+		return errors.New("index unavailable")
+	}
+	return s.Storage.IndexTokens(ctx, entries)
+}
+
+func TestStoreRollsBackFailedChunks(t *testing.T) {
+	ctx := context.Background()
+	store := &failIndexStore{Storage: storage.NewMemoryStorage(), failAt: 2}
+	svc := NewMemoryService(store, nil, index.NewChunker(8, -1))
+	letter := "We married at St Anne's in June. It rained all day.\n\n" +
+		"Afterwards we moved to Leeds for Dad's work. The house had a blue door."
+
+	if _, err := svc.Store(ctx, "u1", "r1", letter, SourceFile, "", 0.5); err == nil {
+		t.Fatal("Store succeeded, want the index error")
+	}
+	// Neither the first chunk nor the one whose indexing failed survives.
+	chunks, _, err := store.ListMemory(ctx, "u1", "", models.MemoryFilter{}, models.Page{})
+	if err != nil || len(chunks) != 0 {
+		t.Errorf("ListMemory after a failed store = %d chunks, %v; want none", len(chunks), err)
+	}
+	if ids, _ := store.LookupTokens(ctx, "u1", "", []string{"married"}); len(ids) != 0 {
+		t.Errorf("LookupTokens(married) = %v, want the index entries removed", ids)
+	}
+}
+
+func TestUpdateRestoresChunkOnFailure(t *testing.T) {
+	ctx := context.Background()
+	store := &failIndexStore{Storage: storage.NewMemoryStorage(), failAt: 2}
+	svc := NewMemoryService(store, nil, nil)
+	id := storeAll(t, svc, "r1", "We had a dog called Biscuit")[0]
+	before, _ := store.GetCorpusStats(ctx, "u1", "r1", []string{"dog", "spaniel"})
+
+	if _, err := svc.Update(ctx, "u1", id, "We had a spaniel called Biscuit", nil); err == nil {
+		t.Fatal("Update succeeded, want the index error")
+	}
+	if chunk, _ := store.GetMemory(ctx, "u1", id); chunk == nil || chunk.Content != "We had a dog called Biscuit" {
+		t.Errorf("chunk after a failed update = %+v, want the old content", chunk)
+	}
+	if ids, _ := store.LookupTokens(ctx, "u1", "r1", []string{"dog"}); len(ids) != 1 {
+		t.Errorf("LookupTokens(dog) = %v, want the old entries back", ids)
+	}
+	if ids, _ := store.LookupTokens(ctx, "u1", "r1", []string{"spaniel"}); len(ids) != 0 {
+		t.Errorf("LookupTokens(spaniel) = %v, want no new entries", ids)
+	}
+	if after, _ := store.GetCorpusStats(ctx, "u1", "r1", []string{"dog", "spaniel"}); !reflect.DeepEqual(after, before) {
+		t.Errorf("corpus stats = %+v, want %+v", after, before)
+	}
+}
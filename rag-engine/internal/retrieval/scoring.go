@@ -0,0 +1,114 @@
+package retrieval
+
+import (
+	"math"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+// Fixed weights of the base score.
+const (
+	relevanceWeight  = 0.7
+	importanceWeight = 0.3
+)
+
+const (
+	// defaultHalfLife is used when SearchOptions.RecencyHalfLife is unset.
+	defaultHalfLife = 30 * 24 * time.Hour
+	// reinforcementSaturation is the access count at which the
+	// reinforcement term reaches half of its maximum.
+	reinforcementSaturation = 5.0
+)
+
+// SearchOptions restricts search candidates and adds optional scoring terms
+// on top of the relevance and importance blend. The zero value keeps the
+// default behaviour.
+//
+// With weights wr (recency) and wf (reinforcement) the final score is
+//
+//	(0.7*relevance + 0.3*importance + wr*recency + wf*reinforcement) / (1 + wr + wf)
+//
+// so it stays within 0.0 – 1.0.
+type SearchOptions struct {
+	// Filter is pushed down to storage for both lexical and semantic candidates.
+	Filter models.MemoryFilter
+
+	// Cursor continues a previous search; it is the cursor that call returned.
+	Cursor string
+
+	// Explain attaches a ScoreExplanation to every returned chunk.
+	Explain bool
+
+	// RecencyWeight favours chunks created recently: recency halves every
+	// RecencyHalfLife (default 30 days) since CreatedAt.
+	RecencyWeight   float64
+	RecencyHalfLife time.Duration
+
+	// ReinforcementWeight favours chunks that keep being retrieved. The term
+	// grows with AccessCount and decays with the same half-life since the
+	// chunk was last accessed.
+	ReinforcementWeight float64
+}
+
+func (o SearchOptions) halfLife() time.Duration {
+	if o.RecencyHalfLife <= 0 {
+		return defaultHalfLife
+	}
+	return o.RecencyHalfLife
+}
+
+// score combines a chunk's normalised relevance with the other terms.
+func (o SearchOptions) score(relevance float64, c models.MemoryChunk, now time.Time) float64 {
+	score := 0.0
+	for _, comp := range o.components(relevance, c, now) {
+		score += comp.Contribution
+	}
+	return score
+}
+
+// components lists the terms of a chunk's score. Weights are already divided
+// by 1 + wr + wf, so the contributions sum to the score.
+func (o SearchOptions) components(relevance float64, c models.MemoryChunk, now time.Time) []models.ScoreComponent {
+	wr := max(o.RecencyWeight, 0)
+	wf := max(o.ReinforcementWeight, 0)
+
+	comps := []models.ScoreComponent{
+		{Name: "relevance", Value: relevance, Weight: relevanceWeight},
+		{Name: "importance", Value: c.Importance, Weight: importanceWeight},
+	}
+	if wr > 0 {
+		comps = append(comps, models.ScoreComponent{Name: "recency", Value: o.recency(c, now), Weight: wr})
+	}
+	if wf > 0 {
+		comps = append(comps, models.ScoreComponent{Name: "reinforcement", Value: o.reinforcement(c, now), Weight: wf})
+	}
+	for i := range comps {
+		comps[i].Weight /= 1 + wr + wf
+		comps[i].Contribution = comps[i].Value * comps[i].Weight
+	}
+	return comps
+}
+
+// recency is 1.0 for a chunk created now and halves every half-life.
+func (o SearchOptions) recency(c models.MemoryChunk, now time.Time) float64 {
+	return decay(now.Sub(c.CreatedAt), o.halfLife())
+}
+
+// reinforcement saturates towards 1.0 as a chunk is accessed more often and
+// fades when it has not been accessed for a while.
+func (o SearchOptions) reinforcement(c models.MemoryChunk, now time.Time) float64 {
+	if c.AccessCount <= 0 || c.LastAccessedAt == nil {
+		return 0
+	}
+	n := float64(c.AccessCount)
+	return n / (n + reinforcementSaturation) * decay(now.Sub(*c.LastAccessedAt), o.halfLife())
+}
+
+// decay returns 0.5^(age/halfLife), clamped to 1.0 for future timestamps.
+func decay(age, halfLife time.Duration) float64 {
+	if age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
@@ -0,0 +1,143 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+)
+
+// Proposal kinds recorded in ProposalResult.Kind.
+const (
+	kindIdentity = "identity"
+	kindMemory   = "memory"
+)
+
+const (
+	// defaultMemorySource is used for proposed memories that name no source.
+	defaultMemorySource = "conversation"
+	// rejectedReason marks proposals skipped because the caretaker rejected them.
+	rejectedReason = "rejected by caretaker"
+)
+
+// commit applies the accepted and edited proposals of a review: identity updates through the
+// identity service (so versions are bumped) and memories through the memory
+// service (so they are indexed). If any write fails, the writes made so far
+// are undone in reverse order and the error is returned. On success the
+// returned rollback function undoes the whole commit.
+func (s *Service) commit(ctx context.Context, item *models.ReviewItem) ([]models.ProposalResult, func(), error) {
+	var (
+		results []models.ProposalResult
+		undo    []func(context.Context) error
+	)
+	undoAll := func() {
+		// Compensate even if the request that triggered the commit is gone
+		ctx := context.WithoutCancel(ctx)
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](ctx); err != nil {
+				log.Printf("⚠️  rolling back review %s failed: %v", item.SessionID, err)
+			}
+		}
+	}
+	rollback := func(cause error) ([]models.ProposalResult, func(), error) {
+		undoAll()
+		return nil, nil, fmt.Errorf("apply review %s: %w", item.SessionID, cause)
+	}
+
+	for i, p := range item.ProposedIdentityUpdates {
+		res := models.ProposalResult{ProposalID: p.ID, Kind: kindIdentity, Index: i, Key: p.Key}
+		switch {
+		case p.Decision == models.DecisionReject:
+			res.Outcome, res.Reason = models.ProposalSkipped, rejectedReason
+		case p.Key == "":
+			res.Outcome, res.Reason = models.ProposalSkipped, "missing key"
+		}
+		if res.Outcome != "" {
+			results = append(results, res)
+			continue
+		}
+		value := p.Value
+		if p.Decision == models.DecisionEdit {
+			value = p.EditedValue
+		}
+		value, err := s.identity.Validate(p.Key, value)
+		if err != nil {
+			res.Outcome, res.Reason = models.ProposalSkipped, err.Error()
+			results = append(results, res)
+			continue
+		}
+
+		existing, err := s.store.GetIdentity(ctx, item.UserID, p.Key)
+		if err != nil {
+			return rollback(err)
+		}
+		switch {
+		case existing != nil && existing.Immutable:
+			res.Outcome, res.Reason = models.ProposalSkipped, "immutable"
+		case existing != nil && reflect.DeepEqual(existing.Value, value):
+			res.Outcome, res.Reason = models.ProposalSkipped, "unchanged"
+		}
+		if res.Outcome != "" {
+			results = append(results, res)
+			continue
+		}
+
+		fact := &models.IdentityFact{
+			UserID:    item.UserID,
+			Key:       p.Key,
+			Value:     value,
+			UpdatedAt: time.Now(),
+			Source:    retrieval.SourceReview,
+			SessionID: item.SessionID,
+		}
+		if err := s.identity.Set(ctx, fact); err != nil {
+			return rollback(err)
+		}
+		undo = append(undo, func(ctx context.Context) error {
+			return s.identity.Restore(ctx, item.UserID, p.Key, existing, item.SessionID)
+		})
+		res.Outcome, res.Version = models.ProposalApplied, fact.Version
+		results = append(results, res)
+	}
+
+	for i, p := range item.ProposedMemories {
+		res := models.ProposalResult{ProposalID: p.ID, Kind: kindMemory, Index: i}
+		content := p.Content
+		if p.Decision == models.DecisionEdit {
+			content = p.EditedContent
+		}
+		switch {
+		case p.Decision == models.DecisionReject:
+			res.Outcome, res.Reason = models.ProposalSkipped, rejectedReason
+		case strings.TrimSpace(content) == "":
+			res.Outcome, res.Reason = models.ProposalSkipped, "empty content"
+		}
+		if res.Outcome != "" {
+			results = append(results, res)
+			continue
+		}
+
+		source := p.Source
+		if source == "" {
+			source = defaultMemorySource
+		}
+		stored, err := s.memory.Store(ctx, item.UserID, "", content, source, item.SessionID, p.Importance)
+		if err != nil {
+			return rollback(err)
+		}
+		for _, chunkID := range stored.ChunkIDs {
+			undo = append(undo, func(ctx context.Context) error {
+				return s.memory.Delete(ctx, item.UserID, chunkID)
+			})
+		}
+		res.Outcome, res.ChunkID = models.ProposalApplied, stored.ChunkIDs[0]
+		results = append(results, res)
+	}
+
+	return results, undoAll, nil
+}
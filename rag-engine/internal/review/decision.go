@@ -0,0 +1,125 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+// applyDecision records one caretaker decision on the matching proposal.
+func applyDecision(item *models.ReviewItem, d models.ProposalDecisionItem) error {
+	switch d.Decision {
+	case models.DecisionAccept, models.DecisionReject, models.DecisionEdit:
+	default:
+		return fmt.Errorf("%w: unknown decision %q for proposal %q", ErrInvalidDecision, d.Decision, d.ProposalID)
+	}
+
+	for i := range item.ProposedIdentityUpdates {
+		p := &item.ProposedIdentityUpdates[i]
+		if p.ID != d.ProposalID {
+			continue
+		}
+		if d.Decision == models.DecisionEdit && d.EditedValue == nil {
+			return fmt.Errorf("%w: edit of %q needs an edited_value", ErrInvalidDecision, d.ProposalID)
+		}
+		p.Decision, p.EditedValue = d.Decision, nil
+		if d.Decision == models.DecisionEdit {
+			p.EditedValue = d.EditedValue
+		}
+		return nil
+	}
+
+	for i := range item.ProposedMemories {
+		p := &item.ProposedMemories[i]
+		if p.ID != d.ProposalID {
+			continue
+		}
+		var content string
+		if d.Decision == models.DecisionEdit {
+			s, ok := d.EditedValue.(string)
+			if !ok || strings.TrimSpace(s) == "" {
+				return fmt.Errorf("%w: edit of %q needs the corrected content as edited_value", ErrInvalidDecision, d.ProposalID)
+			}
+			content = s
+		}
+		p.Decision, p.EditedContent = d.Decision, content
+		return nil
+	}
+
+	return fmt.Errorf("%w: no proposal %q", ErrInvalidDecision, d.ProposalID)
+}
+
+// validateEdits checks caretaker-edited identity values against the schema,
+// so a bad correction is refused up front rather than skipped at commit.
+func (s *Service) validateEdits(item *models.ReviewItem) error {
+	for i := range item.ProposedIdentityUpdates {
+		p := &item.ProposedIdentityUpdates[i]
+		if p.Decision != models.DecisionEdit {
+			continue
+		}
+		v, err := s.identity.Validate(p.Key, p.EditedValue)
+		if err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrInvalidDecision, p.ID, err)
+		}
+		p.EditedValue = v
+	}
+	return nil
+}
+
+// decideRemaining gives every undecided proposal the same decision.
+func decideRemaining(item *models.ReviewItem, decision models.ProposalDecision) {
+	for i := range item.ProposedIdentityUpdates {
+		if item.ProposedIdentityUpdates[i].Decision == "" {
+			item.ProposedIdentityUpdates[i].Decision = decision
+		}
+	}
+	for i := range item.ProposedMemories {
+		if item.ProposedMemories[i].Decision == "" {
+			item.ProposedMemories[i].Decision = decision
+		}
+	}
+}
+
+// allDecided reports whether every proposal has a decision.
+func allDecided(item *models.ReviewItem) bool {
+	for _, p := range item.ProposedIdentityUpdates {
+		if p.Decision == "" {
+			return false
+		}
+	}
+	for _, p := range item.ProposedMemories {
+		if p.Decision == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// derivedStatus is the final status of a fully decided review: approved when
+// nothing was rejected, rejected when nothing was accepted, and partially
+// approved otherwise. A review without proposals counts as approved.
+func derivedStatus(item *models.ReviewItem) models.ReviewStatus {
+	var accepted, rejected int
+	count := func(d models.ProposalDecision) {
+		if d == models.DecisionReject {
+			rejected++
+		} else {
+			accepted++
+		}
+	}
+	for _, p := range item.ProposedIdentityUpdates {
+		count(p.Decision)
+	}
+	for _, p := range item.ProposedMemories {
+		count(p.Decision)
+	}
+
+	switch {
+	case rejected == 0:
+		return models.ReviewApproved
+	case accepted == 0:
+		return models.ReviewRejected
+	}
+	return models.ReviewPartial
+}
@@ -0,0 +1,217 @@
+// Package review implements the caretaker review workflow on top of the
+// storage review queue.
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+var (
+	// ErrNotFound is returned for unknown reviews and for reviews that
+	// belong to another user.
+	ErrNotFound = errors.New("review not found")
+	// ErrAlreadyDecided is returned when deciding a review that is no
+	// longer pending.
+	ErrAlreadyDecided = errors.New("review already decided")
+	// ErrInvalidDecision is returned for decisions that name an unknown
+	// proposal, an unknown verdict, or an edit without a usable value.
+	ErrInvalidDecision = errors.New("invalid decision")
+)
+
+// Service lists, inspects and decides reviews for a user. Approving a review
+// commits its proposals into identity and memory.
+type Service struct {
+	store    storage.Storage
+	identity *retrieval.IdentityService
+	memory   *retrieval.MemoryService
+
+	// locks serialises decisions per review within this process, so a
+	// double-submitted approval cannot commit the proposals twice.
+	mu    sync.Mutex
+	locks map[string]*reviewLock
+}
+
+// reviewLock is a per-review mutex, dropped once nobody holds or awaits it.
+type reviewLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewService creates a new review service.
+func NewService(store storage.Storage, identity *retrieval.IdentityService, memory *retrieval.MemoryService) *Service {
+	return &Service{
+		store:    store,
+		identity: identity,
+		memory:   memory,
+		locks:    make(map[string]*reviewLock),
+	}
+}
+
+// ListPending returns a user's pending reviews, oldest first.
+func (s *Service) ListPending(ctx context.Context, userID string) ([]models.ReviewItem, error) {
+	if userID == "" {
+		return nil, models.Required("user_id")
+	}
+	items, err := s.store.ListPendingReviews(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].AssignProposalIDs()
+	}
+	return items, nil
+}
+
+// Get returns one of a user's reviews.
+func (s *Service) Get(ctx context.Context, userID, sessionID string) (*models.ReviewItem, error) {
+	if userID == "" || sessionID == "" {
+		return nil, models.Required("user_id", "session_id")
+	}
+	item, err := s.store.GetReview(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil || item.UserID != userID {
+		return nil, ErrNotFound
+	}
+	item.AssignProposalIDs()
+	return item, nil
+}
+
+// Approve accepts every proposal the caretaker has not decided on yet and
+// finalises the review; proposals already rejected stay rejected. The
+// outcome of every proposal is recorded in Results. Approving a review
+// whose proposals were already committed returns it unchanged.
+func (s *Service) Approve(ctx context.Context, userID, sessionID string) (*models.ReviewItem, error) {
+	unlock := s.lock(sessionID)
+	defer unlock()
+
+	item, err := s.Get(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	switch item.Status {
+	case models.ReviewApproved, models.ReviewPartial:
+		return item, nil
+	case models.ReviewPending:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyDecided, item.Status)
+	}
+
+	decideRemaining(item, models.DecisionAccept)
+	return s.finalise(ctx, item)
+}
+
+// Reject rejects every proposal of a pending review, discarding them.
+func (s *Service) Reject(ctx context.Context, userID, sessionID string) (*models.ReviewItem, error) {
+	unlock := s.lock(sessionID)
+	defer unlock()
+
+	item, err := s.Get(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Status != models.ReviewPending {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyDecided, item.Status)
+	}
+
+	for i := range item.ProposedIdentityUpdates {
+		item.ProposedIdentityUpdates[i].Decision = models.DecisionReject
+	}
+	for i := range item.ProposedMemories {
+		item.ProposedMemories[i].Decision = models.DecisionReject
+	}
+	now := time.Now()
+	item.Status = models.ReviewRejected
+	item.ReviewedAt = &now
+	if err := s.store.UpdateReview(ctx, item); err != nil {
+		return nil, fmt.Errorf("update review: %w", err)
+	}
+	return item, nil
+}
+
+// Decide records caretaker decisions on individual proposals of a pending
+// review. Decisions may be submitted in several batches, and a later
+// decision on the same proposal replaces an earlier one. Once every
+// proposal is decided the review is finalised: accepted and edited
+// proposals are committed and the status becomes approved, partially
+// approved or rejected.
+func (s *Service) Decide(ctx context.Context, userID, sessionID string, decisions []models.ProposalDecisionItem) (*models.ReviewItem, error) {
+	unlock := s.lock(sessionID)
+	defer unlock()
+
+	item, err := s.Get(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Status != models.ReviewPending {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyDecided, item.Status)
+	}
+
+	for _, d := range decisions {
+		if err := applyDecision(item, d); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.validateEdits(item); err != nil {
+		return nil, err
+	}
+
+	if !allDecided(item) {
+		if err := s.store.UpdateReview(ctx, item); err != nil {
+			return nil, fmt.Errorf("update review: %w", err)
+		}
+		return item, nil
+	}
+	return s.finalise(ctx, item)
+}
+
+// finalise commits the accepted proposals of a fully decided review and
+// stores it with its derived status.
+func (s *Service) finalise(ctx context.Context, item *models.ReviewItem) (*models.ReviewItem, error) {
+	results, rollback, err := s.commit(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	item.Status = derivedStatus(item)
+	item.ReviewedAt = &now
+	item.Results = results
+	if err := s.store.UpdateReview(ctx, item); err != nil {
+		// Still pending, so a retry would commit again: undo this commit
+		rollback()
+		return nil, fmt.Errorf("update review: %w", err)
+	}
+	return item, nil
+}
+
+// lock takes the per-review lock and returns its release function.
+func (s *Service) lock(sessionID string) func() {
+	s.mu.Lock()
+	l, ok := s.locks[sessionID]
+	if !ok {
+		l = &reviewLock{}
+		s.locks[sessionID] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, sessionID)
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,337 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/schema"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+func newService(store storage.Storage) *Service {
+	return NewService(store, retrieval.NewIdentityService(store, nil), retrieval.NewMemoryService(store, nil, nil))
+}
+
+func queue(t *testing.T, store storage.Storage, sessionID, userID string) {
+	t.Helper()
+	queueItem(t, store, &models.ReviewItem{SessionID: sessionID, UserID: userID})
+}
+
+func queueItem(t *testing.T, store storage.Storage, item *models.ReviewItem) {
+	t.Helper()
+	item.Status = models.ReviewPending
+	if err := store.StoreReview(context.Background(), item); err != nil {
+		t.Fatalf("StoreReview: %v", err)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	svc := newService(store)
+	queue(t, store, "s1", "u1")
+	queue(t, store, "s2", "u1")
+
+	item, err := svc.Approve(ctx, "u1", "s1")
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if item.Status != models.ReviewApproved || item.ReviewedAt == nil {
+		t.Errorf("Approve returned %+v", item)
+	}
+	if _, err := svc.Reject(ctx, "u1", "s1"); !errors.Is(err, ErrAlreadyDecided) {
+		t.Errorf("Reject after approve error = %v, want ErrAlreadyDecided", err)
+	}
+	if _, err := svc.Reject(ctx, "u1", "s2"); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+	if _, err := svc.Approve(ctx, "u1", "s2"); !errors.Is(err, ErrAlreadyDecided) {
+		t.Errorf("Approve after reject error = %v, want ErrAlreadyDecided", err)
+	}
+
+	pending, err := svc.ListPending(ctx, "u1")
+	if err != nil {
+		t.Fatalf("ListPending: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("ListPending = %+v, want none", pending)
+	}
+}
+
+func TestOwnership(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	svc := newService(store)
+	queue(t, store, "s1", "u1")
+
+	if _, err := svc.Get(ctx, "u2", "s1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get by another user error = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.Reject(ctx, "u2", "s1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Reject by another user error = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.Get(ctx, "u1", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	item, _ := svc.Get(ctx, "u1", "s1")
+	if item.Status != models.ReviewPending {
+		t.Errorf("status = %s after foreign reject, want pending", item.Status)
+	}
+}
+
+func TestApproveCommitsProposals(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	svc := newService(store)
+	store.SetIdentity(ctx, &models.IdentityFact{UserID: "u1", Key: "birthplace", Value: "Leeds", Version: 1, Immutable: true})
+	store.SetIdentity(ctx, &models.IdentityFact{UserID: "u1", Key: "pet", Value: "Biscuit", Version: 1})
+	store.SetIdentity(ctx, &models.IdentityFact{UserID: "u1", Key: "hometown", Value: "York", Version: 1})
+	queueItem(t, store, &models.ReviewItem{
+		SessionID: "s1", UserID: "u1",
+		ProposedIdentityUpdates: []models.IdentityProposal{
+			{Key: "name", Value: "Margaret"},
+			{Key: "birthplace", Value: "Hull"},
+			{Key: "pet", Value: "Biscuit"},
+			{Key: "hometown", Value: "Whitby"},
+		},
+		ProposedMemories: []models.MemoryProposal{
+			{Content: "We honeymooned in Whitby", Importance: 0.8},
+			{Content: "   "},
+		},
+	})
+
+	item, err := svc.Approve(ctx, "u1", "s1")
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	want := []models.ProposalResult{
+		{ProposalID: "identity-0", Kind: "identity", Index: 0, Key: "name", Outcome: models.ProposalApplied, Version: 1},
+		{ProposalID: "identity-1", Kind: "identity", Index: 1, Key: "birthplace", Outcome: models.ProposalSkipped, Reason: "immutable"},
+		{ProposalID: "identity-2", Kind: "identity", Index: 2, Key: "pet", Outcome: models.ProposalSkipped, Reason: "unchanged"},
+		{ProposalID: "identity-3", Kind: "identity", Index: 3, Key: "hometown", Outcome: models.ProposalApplied, Version: 2},
+		{ProposalID: "memory-0", Kind: "memory", Index: 0, Outcome: models.ProposalApplied, ChunkID: item.Results[4].ChunkID},
+		{ProposalID: "memory-1", Kind: "memory", Index: 1, Outcome: models.ProposalSkipped, Reason: "empty content"},
+	}
+	if !reflect.DeepEqual(item.Results, want) {
+		t.Fatalf("Results = %+v\nwant      %+v", item.Results, want)
+	}
+
+	if fact, _ := store.GetIdentity(ctx, "u1", "birthplace"); fact.Value != "Leeds" {
+		t.Errorf("immutable fact changed to %v", fact.Value)
+	}
+	chunk, _ := store.GetMemory(ctx, "u1", want[4].ChunkID)
+	if chunk == nil || chunk.Source != "conversation" || chunk.SessionID != "s1" {
+		t.Errorf("committed memory = %+v", chunk)
+	}
+
+	// A second approval must not commit again
+	again, err := svc.Approve(ctx, "u1", "s1")
+	if err != nil {
+		t.Fatalf("second Approve: %v", err)
+	}
+	if !reflect.DeepEqual(again.Results, item.Results) {
+		t.Errorf("second Approve results = %+v", again.Results)
+	}
+	chunks, _, _ := store.ListMemory(ctx, "u1", "", models.MemoryFilter{}, models.Page{})
+	if len(chunks) != 1 {
+		t.Errorf("%d memories after approving twice, want 1", len(chunks))
+	}
+	if fact, _ := store.GetIdentity(ctx, "u1", "hometown"); fact.Version != 2 {
+		t.Errorf("hometown version = %d after approving twice, want 2", fact.Version)
+	}
+}
+
+// failingStore fails the nth StoreMemory call.
+type failingStore struct {
+	*storage.MemoryStorage
+	failOn, calls int
+}
+
+func (s *failingStore) StoreMemory(ctx context.Context, chunk *models.MemoryChunk) error {
+	if s.calls++; s.calls == s.failOn {
+		return errors.New("disk full")
+	}
+	return s.MemoryStorage.StoreMemory(ctx, chunk)
+}
+
+func TestApproveRollsBackOnFailure(t *testing.T) {
+	ctx := context.Background()
+	store := &failingStore{MemoryStorage: storage.NewMemoryStorage(), failOn: 2}
+	svc := newService(store)
+	store.SetIdentity(ctx, &models.IdentityFact{UserID: "u1", Key: "hometown", Value: "York", Version: 1})
+	queueItem(t, store, &models.ReviewItem{
+		SessionID: "s1", UserID: "u1",
+		ProposedIdentityUpdates: []models.IdentityProposal{
+			{Key: "name", Value: "Margaret"},
+			{Key: "hometown", Value: "Whitby"},
+		},
+		ProposedMemories: []models.MemoryProposal{
+			{Content: "We honeymooned in Whitby"},
+			{Content: "Dad built the garden shed"},
+		},
+	})
+
+	if _, err := svc.Approve(ctx, "u1", "s1"); err == nil {
+		t.Fatal("Approve succeeded despite a failing write")
+	}
+
+	if fact, _ := store.GetIdentity(ctx, "u1", "name"); fact != nil {
+		t.Errorf("new fact not rolled back: %+v", fact)
+	}
+	// The undo is recorded, so the history ends on the current fact.
+	if fact, _ := store.GetIdentity(ctx, "u1", "hometown"); fact.Value != "York" || fact.Version != 3 || fact.Source != retrieval.SourceRollback {
+		t.Errorf("updated fact not restored: %+v", fact)
+	}
+	revs, _ := store.ListIdentityRevisions(ctx, "u1", "hometown")
+	if n := len(revs); n != 2 || revs[1].Value != "York" || revs[1].RestoredVersion != 1 || revs[1].Source != retrieval.SourceRollback {
+		t.Errorf("hometown history = %+v, want the review's write then its undo", revs)
+	}
+	revs, _ = store.ListIdentityRevisions(ctx, "u1", "name")
+	if n := len(revs); n != 2 || !revs[1].Deleted || revs[1].Source != retrieval.SourceRollback {
+		t.Errorf("name history = %+v, want the review's write then its removal", revs)
+	}
+	chunks, _, _ := store.ListMemory(ctx, "u1", "", models.MemoryFilter{}, models.Page{})
+	if len(chunks) != 0 {
+		t.Errorf("%d memories left after rollback, want 0", len(chunks))
+	}
+	stats, _ := store.GetCorpusStats(ctx, "u1", "", []string{"whitby"})
+	if stats.DocCount != 0 || stats.DocFreq["whitby"] != 0 {
+		t.Errorf("corpus stats not rolled back: %+v", stats)
+	}
+	if item, _ := svc.Get(ctx, "u1", "s1"); item.Status != models.ReviewPending {
+		t.Errorf("status = %s after failed approval, want pending", item.Status)
+	}
+}
+
+func TestPartialApprovalWithEdits(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	svc := newService(store)
+	queueItem(t, store, &models.ReviewItem{
+		SessionID: "s1", UserID: "u1",
+		ProposedIdentityUpdates: []models.IdentityProposal{{ID: "name", Key: "name", Value: "Margret"}},
+		ProposedMemories: []models.MemoryProposal{
+			{ID: "m1", Content: "We honeymooned in Whitby"},
+			{ID: "m2", Content: "The weather was bad"},
+			{ID: "m3", Content: "Dad built the shed in 1975"},
+		},
+	})
+
+	// Invalid decisions leave the review untouched
+	for _, bad := range []models.ProposalDecisionItem{
+		{ProposalID: "nope", Decision: models.DecisionAccept},
+		{ProposalID: "m1", Decision: "maybe"},
+		{ProposalID: "name", Decision: models.DecisionEdit},
+		{ProposalID: "m1", Decision: models.DecisionEdit, EditedValue: 42},
+	} {
+		if _, err := svc.Decide(ctx, "u1", "s1", []models.ProposalDecisionItem{bad}); !errors.Is(err, ErrInvalidDecision) {
+			t.Errorf("Decide(%+v) error = %v, want ErrInvalidDecision", bad, err)
+		}
+	}
+
+	// A first batch leaves the review pending
+	item, err := svc.Decide(ctx, "u1", "s1", []models.ProposalDecisionItem{
+		{ProposalID: "name", Decision: models.DecisionEdit, EditedValue: "Margaret"},
+		{ProposalID: "m2", Decision: models.DecisionReject},
+	})
+	if err != nil {
+		t.Fatalf("Decide: %v", err)
+	}
+	if item.Status != models.ReviewPending || item.Results != nil {
+		t.Fatalf("after a partial batch: status %s, results %+v", item.Status, item.Results)
+	}
+	if stored, _ := store.GetReview(ctx, "s1"); stored.ProposedMemories[1].Decision != models.DecisionReject {
+		t.Errorf("decisions not persisted: %+v", stored.ProposedMemories)
+	}
+
+	// The last decision finalises it
+	item, err = svc.Decide(ctx, "u1", "s1", []models.ProposalDecisionItem{
+		{ProposalID: "m1", Decision: models.DecisionAccept},
+		{ProposalID: "m3", Decision: models.DecisionEdit, EditedValue: "Dad built the shed in 1976"},
+	})
+	if err != nil {
+		t.Fatalf("Decide: %v", err)
+	}
+	if item.Status != models.ReviewPartial {
+		t.Errorf("status = %s, want %s", item.Status, models.ReviewPartial)
+	}
+	if fact, _ := store.GetIdentity(ctx, "u1", "name"); fact == nil || fact.Value != "Margaret" {
+		t.Errorf("edited name not committed: %+v", fact)
+	}
+	chunks, _, _ := store.ListMemory(ctx, "u1", "", models.MemoryFilter{}, models.Page{})
+	var contents []string
+	for _, c := range chunks {
+		contents = append(contents, c.Content)
+	}
+	if !reflect.DeepEqual(contents, []string{"We honeymooned in Whitby", "Dad built the shed in 1976"}) {
+		t.Errorf("committed memories = %q", contents)
+	}
+	if r := item.Results[2]; r.ProposalID != "m2" || r.Outcome != models.ProposalSkipped || r.Reason != "rejected by caretaker" {
+		t.Errorf("rejected proposal result = %+v", r)
+	}
+
+	if _, err := svc.Decide(ctx, "u1", "s1", nil); !errors.Is(err, ErrAlreadyDecided) {
+		t.Errorf("Decide after finalising error = %v, want ErrAlreadyDecided", err)
+	}
+	if again, err := svc.Approve(ctx, "u1", "s1"); err != nil || again.Status != models.ReviewPartial {
+		t.Errorf("Approve after finalising = %v, %v; want unchanged", again, err)
+	}
+}
+
+func TestSchemaValidation(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	svc := NewService(store, retrieval.NewIdentityService(store, schema.Default()), retrieval.NewMemoryService(store, nil, nil))
+	queueItem(t, store, &models.ReviewItem{
+		SessionID: "s1", UserID: "u1",
+		ProposedIdentityUpdates: []models.IdentityProposal{
+			{ID: "kids", Key: "children", Value: "Tom, Sarah"},
+			{ID: "born", Key: "birthdate", Value: "1941-03-09"},
+		},
+	})
+
+	// A bad correction is refused before anything is recorded
+	edit := models.ProposalDecisionItem{ProposalID: "born", Decision: models.DecisionEdit, EditedValue: "spring 1941"}
+	if _, err := svc.Decide(ctx, "u1", "s1", []models.ProposalDecisionItem{edit}); !errors.Is(err, ErrInvalidDecision) {
+		t.Errorf("Decide(invalid edit) error = %v, want ErrInvalidDecision", err)
+	}
+
+	// Invalid proposals are skipped with the validation error
+	item, err := svc.Approve(ctx, "u1", "s1")
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if r := item.Results[0]; r.Outcome != models.ProposalSkipped || !strings.Contains(r.Reason, "expected a list") {
+		t.Errorf("invalid proposal result = %+v", r)
+	}
+	if r := item.Results[1]; r.Outcome != models.ProposalApplied {
+		t.Errorf("valid proposal result = %+v", r)
+	}
+}
+
+func TestDerivedStatus(t *testing.T) {
+	cases := []struct {
+		decisions []models.ProposalDecision
+		want      models.ReviewStatus
+	}{
+		{nil, models.ReviewApproved},
+		{[]models.ProposalDecision{models.DecisionAccept, models.DecisionEdit}, models.ReviewApproved},
+		{[]models.ProposalDecision{models.DecisionReject, models.DecisionReject}, models.ReviewRejected},
+		{[]models.ProposalDecision{models.DecisionEdit, models.DecisionReject}, models.ReviewPartial},
+	}
+	for _, c := range cases {
+		item := &models.ReviewItem{}
+		for _, d := range c.decisions {
+			item.ProposedMemories = append(item.ProposedMemories, models.MemoryProposal{Decision: d})
+		}
+		if got := derivedStatus(item); got != c.want {
+			t.Errorf("derivedStatus(%v) = %s, want %s", c.decisions, got, c.want)
+		}
+	}
+}
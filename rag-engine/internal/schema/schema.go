@@ -0,0 +1,311 @@
+// Package schema describes the identity keys a user profile may hold and
+// validates fact values against them.
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidValue is returned when a value doesn't match its field.
+	ErrInvalidValue = errors.New("invalid identity value")
+	// ErrUnknownKey is returned by strict registries for undeclared keys.
+	ErrUnknownKey = errors.New("unknown identity key")
+)
+
+// Type is the kind of value a field holds.
+type Type string
+
+const (
+	TypeString    Type = "string"
+	TypeDate      Type = "date"       // YYYY, YYYY-MM or YYYY-MM-DD
+	TypeList      Type = "list"       // list of strings, always cardinality many
+	TypePersonRef Type = "person_ref" // {"name", "relation"}; a bare name is accepted
+	TypeEnum      Type = "enum"       // one of Field.Values
+)
+
+// Cardinality says whether a field holds one value or a list of them.
+type Cardinality string
+
+const (
+	One  Cardinality = "one"
+	Many Cardinality = "many"
+)
+
+// Field declares one identity key.
+type Field struct {
+	Key         string      `json:"key"`
+	Type        Type        `json:"type"`
+	Cardinality Cardinality `json:"cardinality,omitempty"` // default one
+	Immutable   bool        `json:"immutable,omitempty"`   // new facts start immutable
+	Values      []string    `json:"values,omitempty"`      // allowed values of an enum
+	Description string      `json:"description,omitempty"`
+}
+
+// Config is the on-disk form of a registry.
+type Config struct {
+	// Strict rejects keys that have no field. Otherwise they are stored
+	// as free-form values, as before the schema existed.
+	Strict bool    `json:"strict"`
+	Fields []Field `json:"fields"`
+}
+
+// ValidationError explains why a value was rejected.
+type ValidationError struct {
+	Key    string
+	Reason string
+	err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("identity key %q: %s", e.Key, e.Reason)
+}
+
+func (e *ValidationError) Unwrap() error { return e.err }
+
+// Registry holds the declared identity fields.
+type Registry struct {
+	strict bool
+	fields map[string]Field
+}
+
+// New builds a registry, checking that every field is well formed.
+func New(cfg Config) (*Registry, error) {
+	r := &Registry{strict: cfg.Strict, fields: make(map[string]Field, len(cfg.Fields))}
+	for _, f := range cfg.Fields {
+		if f.Key == "" {
+			return nil, fmt.Errorf("schema field without key")
+		}
+		if _, dup := r.fields[f.Key]; dup {
+			return nil, fmt.Errorf("schema field %q declared twice", f.Key)
+		}
+		switch f.Type {
+		case TypeString, TypeDate, TypePersonRef:
+		case TypeList:
+			f.Cardinality = Many
+		case TypeEnum:
+			if len(f.Values) == 0 {
+				return nil, fmt.Errorf("schema field %q: enum needs values", f.Key)
+			}
+		default:
+			return nil, fmt.Errorf("schema field %q: unknown type %q", f.Key, f.Type)
+		}
+		switch f.Cardinality {
+		case "":
+			f.Cardinality = One
+		case One, Many:
+		default:
+			return nil, fmt.Errorf("schema field %q: unknown cardinality %q", f.Key, f.Cardinality)
+		}
+		r.fields[f.Key] = f
+	}
+	return r, nil
+}
+
+// Load reads a registry from a JSON file holding a Config.
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read identity schema: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse identity schema %s: %w", path, err)
+	}
+	return New(cfg)
+}
+
+// Default returns the built-in registry covering the keys the extraction
+// and chat tools use. It is not strict.
+func Default() *Registry {
+	r, err := New(Config{Fields: []Field{
+		{Key: "name", Type: TypeString, Description: "Full name"},
+		{Key: "preferred_name", Type: TypeString, Description: "What they like to be called"},
+		{Key: "birthdate", Type: TypeDate, Immutable: true},
+		{Key: "birthplace", Type: TypeString, Immutable: true},
+		{Key: "hometown", Type: TypeString},
+		{Key: "occupation", Type: TypeString},
+		{Key: "spouse", Type: TypePersonRef},
+		{Key: "children", Type: TypePersonRef, Cardinality: Many},
+		{Key: "grandchildren", Type: TypePersonRef, Cardinality: Many},
+		{Key: "pets", Type: TypeList},
+		{Key: "favourite_colour", Type: TypeString},
+	}})
+	if err != nil {
+		panic(err) // the built-in fields are static
+	}
+	return r
+}
+
+// Field returns the declaration of key.
+func (r *Registry) Field(key string) (Field, bool) {
+	f, ok := r.fields[key]
+	return f, ok
+}
+
+// Fields returns every declared field, ordered by key.
+func (r *Registry) Fields() []Field {
+	out := make([]Field, 0, len(r.fields))
+	for _, f := range r.fields {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Strict reports whether undeclared keys are rejected.
+func (r *Registry) Strict() bool { return r.strict }
+
+// Validate checks value against the field declared for key and returns it
+// in canonical form: trimmed strings, dates as YYYY[-MM[-DD]], person refs
+// as {"name", "relation"} objects and lists as []any.
+func (r *Registry) Validate(key string, value any) (any, error) {
+	f, ok := r.fields[key]
+	if !ok {
+		if r.strict {
+			return nil, &ValidationError{Key: key, Reason: "not declared in the identity schema", err: ErrUnknownKey}
+		}
+		return value, nil
+	}
+	invalid := func(format string, args ...any) error {
+		return &ValidationError{Key: key, Reason: fmt.Sprintf(format, args...), err: ErrInvalidValue}
+	}
+
+	if f.Cardinality == One {
+		v, reason := f.item(value)
+		if reason != "" {
+			return nil, invalid("%s", reason)
+		}
+		return v, nil
+	}
+
+	items, ok := value.([]any)
+	if !ok {
+		if ss, isStrings := value.([]string); isStrings {
+			items = make([]any, len(ss))
+			for i, s := range ss {
+				items[i] = s
+			}
+		} else {
+			return nil, invalid("expected a list, got %s", describe(value))
+		}
+	}
+	if len(items) == 0 {
+		return nil, invalid("expected at least one item")
+	}
+	out := make([]any, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		v, reason := f.item(item)
+		if reason != "" {
+			return nil, invalid("item %d: %s", i, reason)
+		}
+		k := fmt.Sprint(v)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// item validates a single value of f, returning a reason when invalid.
+func (f Field) item(value any) (any, string) {
+	switch f.Type {
+	case TypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, "expected a date (YYYY-MM-DD), got " + describe(value)
+		}
+		d, ok := ParseDate(strings.TrimSpace(s))
+		if !ok {
+			return nil, fmt.Sprintf("expected a date (YYYY-MM-DD), got %q", s)
+		}
+		return d, ""
+
+	case TypePersonRef:
+		switch v := value.(type) {
+		case string:
+			if strings.TrimSpace(v) == "" {
+				return nil, "expected a person's name, got an empty string"
+			}
+			return map[string]any{"name": strings.TrimSpace(v)}, ""
+		case map[string]any:
+			name, _ := v["name"].(string)
+			if strings.TrimSpace(name) == "" {
+				return nil, "person reference needs a name"
+			}
+			ref := map[string]any{"name": strings.TrimSpace(name)}
+			if rel, ok := v["relation"].(string); ok && strings.TrimSpace(rel) != "" {
+				ref["relation"] = strings.TrimSpace(rel)
+			}
+			return ref, ""
+		}
+		return nil, "expected a person (name or {\"name\", \"relation\"}), got " + describe(value)
+
+	case TypeEnum:
+		s, ok := value.(string)
+		if !ok {
+			return nil, "expected one of " + strings.Join(f.Values, ", ") + ", got " + describe(value)
+		}
+		for _, allowed := range f.Values {
+			if strings.EqualFold(strings.TrimSpace(s), allowed) {
+				return allowed, ""
+			}
+		}
+		return nil, fmt.Sprintf("expected one of %s, got %q", strings.Join(f.Values, ", "), s)
+	}
+
+	// TypeString and the items of TypeList
+	s, ok := value.(string)
+	if !ok {
+		return nil, "expected a string, got " + describe(value)
+	}
+	if strings.TrimSpace(s) == "" {
+		return nil, "expected a non-empty string"
+	}
+	return strings.TrimSpace(s), ""
+}
+
+// dateLayouts are tried in order; the value keeps the precision it came with.
+var dateLayouts = []string{"2006-01-02", "2006-01", "2006"}
+
+// ParseDate accepts YYYY, YYYY-MM, YYYY-MM-DD or an RFC3339 timestamp and
+// returns the date in the first three forms.
+func ParseDate(s string) (string, bool) {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return s, true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Format("2006-01-02"), true
+	}
+	return "", false
+}
+
+// describe names the JSON type of v for error messages.
+func describe(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64, float32, int, int64, int32:
+		return "a number"
+	case []any, []string:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
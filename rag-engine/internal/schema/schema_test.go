@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	reg, err := New(Config{Fields: []Field{
+		{Key: "name", Type: TypeString},
+		{Key: "birthdate", Type: TypeDate},
+		{Key: "pets", Type: TypeList},
+		{Key: "spouse", Type: TypePersonRef},
+		{Key: "children", Type: TypePersonRef, Cardinality: Many},
+		{Key: "handedness", Type: TypeEnum, Values: []string{"left", "right"}},
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		key   string
+		value any
+		want  any // nil = rejected
+	}{
+		{"name", "  Margaret ", "Margaret"},
+		{"name", "", nil},
+		{"name", 42.0, nil},
+		{"birthdate", "1941-03-09", "1941-03-09"},
+		{"birthdate", "1941", "1941"},
+		{"birthdate", "1941-03-09T00:00:00Z", "1941-03-09"},
+		{"birthdate", "last spring", nil},
+		{"birthdate", "1941-13-01", nil},
+		{"pets", []any{"Rex", "Biscuit", "Rex"}, []any{"Rex", "Biscuit"}},
+		{"pets", "Rex, Biscuit", nil},
+		{"pets", []any{}, nil},
+		{"spouse", "Arthur", map[string]any{"name": "Arthur"}},
+		{"spouse", map[string]any{"name": "Arthur", "relation": "husband"}, map[string]any{"name": "Arthur", "relation": "husband"}},
+		{"spouse", map[string]any{"relation": "husband"}, nil},
+		{"children", "Tom, Sarah", nil},
+		{"children", []any{"Tom", map[string]any{"name": "Sarah", "relation": "daughter"}},
+			[]any{map[string]any{"name": "Tom"}, map[string]any{"name": "Sarah", "relation": "daughter"}}},
+		{"children", []any{"Tom", 3.0}, nil},
+		{"handedness", "Left", "left"},
+		{"handedness", "both", nil},
+		{"undeclared", 7.0, 7.0},
+	}
+	for _, tt := range tests {
+		got, err := reg.Validate(tt.key, tt.value)
+		if tt.want == nil {
+			if !errors.Is(err, ErrInvalidValue) {
+				t.Errorf("Validate(%s, %#v) = %#v, %v; want ErrInvalidValue", tt.key, tt.value, got, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Validate(%s, %#v) = %#v, %v; want %#v", tt.key, tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestStrictRejectsUnknownKeys(t *testing.T) {
+	reg, err := New(Config{Strict: true, Fields: []Field{{Key: "name", Type: TypeString}}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := reg.Validate("shoe_size", "9"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Validate(undeclared) err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestNewRejectsBadFields(t *testing.T) {
+	for _, f := range []Field{
+		{Type: TypeString},
+		{Key: "x", Type: "number"},
+		{Key: "x", Type: TypeEnum},
+		{Key: "x", Type: TypeString, Cardinality: "several"},
+	} {
+		if _, err := New(Config{Fields: []Field{f}}); err == nil {
+			t.Errorf("New accepted %+v", f)
+		}
+	}
+	dup := []Field{{Key: "x", Type: TypeString}, {Key: "x", Type: TypeDate}}
+	if _, err := New(Config{Fields: dup}); err == nil {
+		t.Error("New accepted a duplicate key")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	data := `{"strict": true, "fields": [{"key": "pets", "type": "list"}, {"key": "birthdate", "type": "date", "immutable": true}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reg.Strict() || len(reg.Fields()) != 2 {
+		t.Errorf("loaded strict=%v fields=%+v", reg.Strict(), reg.Fields())
+	}
+	if f, _ := reg.Field("pets"); f.Cardinality != Many {
+		t.Errorf("list cardinality = %q, want many", f.Cardinality)
+	}
+	if f, _ := reg.Field("birthdate"); !f.Immutable {
+		t.Error("birthdate should be immutable")
+	}
+}
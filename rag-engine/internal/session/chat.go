@@ -0,0 +1,262 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+// Defaults for the Groq API, one of the OpenAI-compatible providers.
+const (
+	GroqBaseURL      = "https://api.groq.com/openai/v1"
+	GroqDefaultModel = "meta-llama/llama-4-scout-17b-16e-instruct"
+)
+
+const (
+	defaultChatTimeout = 30 * time.Second
+	chatAttempts       = 3
+	chatMaxBackoff     = 10 * time.Second
+)
+
+// extractionPrompt asks for a single JSON object so the reply can be parsed
+// strictly; anything else is treated as a failed extraction.
+const extractionPrompt = `You extract durable facts from a conversation between a user and their memory companion.
+
+Return ONLY a JSON object of this exact shape:
+{
+  "identity": [{"key": "snake_case_key", "value": <string, list or object>, "confidence": 0.0-1.0}],
+  "memories": [{"content": "one self-contained sentence", "importance": 0.0-1.0}]
+}
+
+Rules:
+- identity holds stable facts about the user themself: name, preferred_name, birthdate (YYYY-MM-DD), birthplace, hometown, occupation, spouse, children (a list of names), pets (a list), favourite_colour, and similar.
+- memories holds events, experiences and stories worth remembering, written in the third person ("The user ...").
+- Only include what the user stated. Never guess. Skip small talk and questions.
+- confidence is how sure you are the fact was stated; importance is how significant the memory is to the user's life story.
+- Use empty lists when there is nothing to extract.`
+
+// snakeKey matches the identity keys the extractor may propose.
+var snakeKey = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ChatExtractor extracts proposals by prompting an LLM behind any
+// OpenAI-compatible POST /chat/completions endpoint: Groq and other hosted
+// APIs as well as local servers (llama.cpp, Ollama).
+type ChatExtractor struct {
+	baseURL string
+	model   string
+	apiKey  string
+	timeout time.Duration // per attempt
+	client  *http.Client
+	backoff time.Duration // first retry delay, doubled per attempt
+}
+
+// NewChatExtractor creates an extractor for the given base URL (e.g.
+// "http://localhost:11434/v1") and model. apiKey may be empty; a zero
+// timeout selects the default of 30s per attempt.
+func NewChatExtractor(baseURL, model, apiKey string, timeout time.Duration) *ChatExtractor {
+	if timeout <= 0 {
+		timeout = defaultChatTimeout
+	}
+	return &ChatExtractor{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		apiKey:  apiKey,
+		timeout: timeout,
+		client:  &http.Client{},
+		backoff: 500 * time.Millisecond,
+	}
+}
+
+// Name identifies the extractor in logs.
+func (c *ChatExtractor) Name() string {
+	return "chat-" + c.model
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string            `json:"model"`
+	Messages       []chatMessage     `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// extraction is the JSON object the model is asked to return.
+type extraction struct {
+	Identity []struct {
+		Key        string   `json:"key"`
+		Value      any      `json:"value"`
+		Confidence *float64 `json:"confidence"`
+	} `json:"identity"`
+	Memories []struct {
+		Content    string   `json:"content"`
+		Importance *float64 `json:"importance"`
+	} `json:"memories"`
+}
+
+// retryableError marks failures worth another attempt.
+type retryableError struct {
+	err   error
+	after time.Duration // server-requested delay, if any
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// Extract prompts the model with the transcript and parses its proposals.
+// Network errors, timeouts, 429s and 5xx responses are retried with
+// exponential backoff; a malformed reply is not.
+func (c *ChatExtractor) Extract(ctx context.Context, messages []models.TranscriptLine) ([]models.IdentityProposal, []models.MemoryProposal, error) {
+	var transcript strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&transcript, "%s: %s\n", m.Role, strings.TrimSpace(m.Content))
+	}
+	body, err := json.Marshal(chatRequest{
+		Model: c.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: extractionPrompt},
+			{Role: "user", Content: transcript.String()},
+		},
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal chat request: %w", err)
+	}
+
+	var content string
+	delay := c.backoff
+	for attempt := 1; ; attempt++ {
+		content, err = c.complete(ctx, body)
+		var retry *retryableError
+		if err == nil || !errors.As(err, &retry) || attempt == chatAttempts {
+			break
+		}
+		wait := max(delay, retry.after)
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(min(wait, chatMaxBackoff)):
+		}
+		delay *= 2
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseExtraction(content)
+}
+
+// complete makes one chat completion call and returns the reply text.
+func (c *ChatExtractor) complete(ctx context.Context, body []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("build chat request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", &retryableError{err: fmt.Errorf("chat request: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("chat request: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			secs, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+			return "", &retryableError{err: err, after: time.Duration(secs) * time.Second}
+		}
+		return "", err
+	}
+
+	var out chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode chat response: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("chat response: no choices")
+	}
+	return out.Choices[0].Message.Content, nil
+}
+
+// parseExtraction strictly decodes the model's reply: unknown fields,
+// missing scores, scores outside [0, 1] and malformed keys all fail the
+// whole extraction so the caller can fall back.
+func parseExtraction(content string) ([]models.IdentityProposal, []models.MemoryProposal, error) {
+	content = strings.TrimSpace(content)
+	// Some models wrap JSON in a markdown fence despite json_object mode
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+	}
+
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.DisallowUnknownFields()
+	var ex extraction
+	if err := dec.Decode(&ex); err != nil {
+		return nil, nil, fmt.Errorf("parse extraction: %w", err)
+	}
+
+	var identities []models.IdentityProposal
+	for i, it := range ex.Identity {
+		switch {
+		case !snakeKey.MatchString(it.Key):
+			return nil, nil, fmt.Errorf("parse extraction: identity %d: invalid key %q", i, it.Key)
+		case it.Value == nil:
+			return nil, nil, fmt.Errorf("parse extraction: identity %d (%s): missing value", i, it.Key)
+		case !unitScore(it.Confidence):
+			return nil, nil, fmt.Errorf("parse extraction: identity %d (%s): confidence must be within [0, 1]", i, it.Key)
+		}
+		identities = append(identities, models.IdentityProposal{
+			Key:        it.Key,
+			Value:      it.Value,
+			Confidence: *it.Confidence,
+		})
+	}
+
+	var memories []models.MemoryProposal
+	for i, m := range ex.Memories {
+		switch {
+		case strings.TrimSpace(m.Content) == "":
+			return nil, nil, fmt.Errorf("parse extraction: memory %d: empty content", i)
+		case !unitScore(m.Importance):
+			return nil, nil, fmt.Errorf("parse extraction: memory %d: importance must be within [0, 1]", i)
+		}
+		memories = append(memories, models.MemoryProposal{
+			Content:    strings.TrimSpace(m.Content),
+			Importance: *m.Importance,
+			Source:     "conversation",
+		})
+	}
+	return identities, memories, nil
+}
+
+func unitScore(v *float64) bool {
+	return v != nil && *v >= 0 && *v <= 1
+}
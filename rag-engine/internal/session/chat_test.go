@@ -0,0 +1,134 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+var transcript = []models.TranscriptLine{
+	{Role: "user", Content: "My name is Margaret and I grew up in Whitby."},
+	{Role: "assistant", Content: "That sounds lovely."},
+}
+
+// fakeChat serves chat completions from reply, which gets the attempt number.
+func fakeChat(t *testing.T, reply func(attempt int, w http.ResponseWriter)) (*ChatExtractor, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) != 2 {
+			t.Errorf("bad request body: %+v, %v", req, err)
+		}
+		reply(int(calls.Add(1)), w)
+	}))
+	t.Cleanup(srv.Close)
+
+	g := NewChatExtractor(srv.URL, "test-model", "test-key", time.Second)
+	g.backoff = time.Millisecond
+	return g, &calls
+}
+
+func completion(w http.ResponseWriter, content string) {
+	json.NewEncoder(w).Encode(map[string]any{
+		"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": content}}},
+	})
+}
+
+const goodReply = `{
+  "identity": [{"key": "name", "value": "Margaret", "confidence": 0.95}],
+  "memories": [{"content": "The user grew up in Whitby.", "importance": 0.7}]
+}`
+
+func TestChatExtract(t *testing.T) {
+	g, _ := fakeChat(t, func(_ int, w http.ResponseWriter) { completion(w, goodReply) })
+
+	ids, mems, err := g.Extract(context.Background(), transcript)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(ids) != 1 || ids[0].Key != "name" || ids[0].Value != "Margaret" || ids[0].Confidence != 0.95 {
+		t.Errorf("identities = %+v", ids)
+	}
+	if len(mems) != 1 || mems[0].Content != "The user grew up in Whitby." || mems[0].Importance != 0.7 || mems[0].Source != "conversation" {
+		t.Errorf("memories = %+v", mems)
+	}
+}
+
+func TestChatRetries(t *testing.T) {
+	g, calls := fakeChat(t, func(attempt int, w http.ResponseWriter) {
+		switch attempt {
+		case 1:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		case 2:
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			completion(w, goodReply)
+		}
+	})
+	if _, _, err := g.Extract(context.Background(), transcript); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 3", n)
+	}
+}
+
+func TestChatDoesNotRetryClientErrors(t *testing.T) {
+	g, calls := fakeChat(t, func(_ int, w http.ResponseWriter) {
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	})
+	if _, _, err := g.Extract(context.Background(), transcript); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Extract error = %v, want the 401", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
+
+func TestChatTimeout(t *testing.T) {
+	g, calls := fakeChat(t, func(attempt int, w http.ResponseWriter) {
+		if attempt == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		completion(w, goodReply)
+	})
+	g.timeout = 50 * time.Millisecond
+	if _, _, err := g.Extract(context.Background(), transcript); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("calls = %d, want 2", n)
+	}
+}
+
+func TestParseExtractionStrict(t *testing.T) {
+	for _, reply := range []string{
+		`not json`,
+		`{"identity": [], "memories": [], "notes": "extra field"}`,
+		`{"identity": [{"key": "Name", "value": "M", "confidence": 0.9}]}`,
+		`{"identity": [{"key": "name", "value": null, "confidence": 0.9}]}`,
+		`{"identity": [{"key": "name", "value": "M"}]}`,
+		`{"memories": [{"content": "x", "importance": 1.5}]}`,
+		`{"memories": [{"content": "  ", "importance": 0.5}]}`,
+	} {
+		if _, _, err := parseExtraction(reply); err == nil {
+			t.Errorf("parseExtraction(%s) accepted", reply)
+		}
+	}
+
+	fenced := "```json\n" + goodReply + "\n```"
+	if ids, _, err := parseExtraction(fenced); err != nil || len(ids) != 1 {
+		t.Errorf("parseExtraction(fenced) = %+v, %v", ids, err)
+	}
+}
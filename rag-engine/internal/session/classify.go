@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+// classifyIdentity compares each proposal with the stored fact for its key
+// and records the classification and the stored value on the proposal.
+// When the facts can't be loaded the proposals are left unclassified.
+func (p *Processor) classifyIdentity(ctx context.Context, userID string, proposals []models.IdentityProposal) {
+	if p.identity == nil || len(proposals) == 0 {
+		return
+	}
+	keys := make([]string, len(proposals))
+	for i, ip := range proposals {
+		keys[i] = ip.Key
+	}
+	facts, err := p.identity.GetMany(ctx, userID, keys)
+	if err != nil {
+		log.Printf("⚠️  classifying identity proposals failed: %v", err)
+		return
+	}
+	existing := make(map[string]models.IdentityFact, len(facts))
+	for _, f := range facts {
+		existing[f.Key] = f
+	}
+
+	for i := range proposals {
+		ip := &proposals[i]
+		fact, ok := existing[ip.Key]
+		switch {
+		case !ok:
+			ip.Classification = models.ClassNew
+			continue
+		case p.sameValue(ip.Key, ip.Value, fact.Value):
+			ip.Classification = models.ClassConfirming
+		case fact.Immutable:
+			ip.Classification = models.ClassBlocked
+		default:
+			ip.Classification = models.ClassConflicting
+		}
+		ip.ExistingValue, ip.ExistingVersion = fact.Value, fact.Version
+	}
+}
+
+// sameValue reports whether a proposed value says the same as the stored
+// one. Both are put in canonical form first; strings then compare without
+// regard to case, and a proposed list confirms a stored one when each of
+// its items is already there.
+func (p *Processor) sameValue(key string, proposed, stored any) bool {
+	if v, err := p.identity.Validate(key, proposed); err == nil {
+		proposed = v
+	}
+	if v, err := p.identity.Validate(key, stored); err == nil {
+		stored = v
+	}
+
+	if items, ok := proposed.([]any); ok {
+		have, ok := stored.([]any)
+		if !ok {
+			return false
+		}
+		for _, item := range items {
+			found := false
+			for _, h := range have {
+				if sameItem(item, h) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+	return sameItem(proposed, stored)
+}
+
+func sameItem(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	// Person references are the same person when the names match
+	if ra, ok := a.(map[string]any); ok {
+		if rb, ok := b.(map[string]any); ok {
+			return sameItem(ra["name"], rb["name"])
+		}
+	}
+	sa, okA := a.(string)
+	sb, okB := b.(string)
+	if okA && okB {
+		return strings.EqualFold(strings.TrimSpace(sa), strings.TrimSpace(sb))
+	}
+	// Numbers and the like may come back from storage as different types
+	return !okA && !okB && a != nil && b != nil && fmt.Sprint(a) == fmt.Sprint(b)
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/schema"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+// fixedExtractor proposes the same identity updates for any transcript.
+type fixedExtractor []models.IdentityProposal
+
+func (fixedExtractor) Name() string { return "fixed" }
+
+func (f fixedExtractor) Extract(context.Context, []models.TranscriptLine) ([]models.IdentityProposal, []models.MemoryProposal, error) {
+	return append([]models.IdentityProposal(nil), f...), nil, nil
+}
+
+func TestProcessClassifiesIdentityProposals(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	identity := retrieval.NewIdentityService(store, schema.Default())
+	for _, f := range []models.IdentityFact{
+		{UserID: "u1", Key: "name", Value: "Peggy"},
+		{UserID: "u1", Key: "hometown", Value: "Leeds"},
+		{UserID: "u1", Key: "birthdate", Value: "1941-03-09"}, // immutable by default
+		{UserID: "u1", Key: "children", Value: []any{"Sarah"}},
+	} {
+		if err := identity.Set(ctx, &f); err != nil {
+			t.Fatalf("Set(%s): %v", f.Key, err)
+		}
+	}
+
+	proposals := fixedExtractor{
+		{Key: "name", Value: "margaret"},
+		{Key: "hometown", Value: "leeds "},
+		{Key: "birthdate", Value: "1948"},
+		{Key: "birthdate", Value: "1941-03-09T00:00:00Z"},
+		{Key: "children", Value: []any{map[string]any{"name": "Sarah", "relation": "daughter"}}},
+		{Key: "children", Value: []any{"Sarah", "Tom"}},
+		{Key: "occupation", Value: "nurse"},
+	}
+	sessionID, err := NewProcessor(store, identity, nil, proposals).Process(ctx, "u1", transcript)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	item, _ := store.GetReview(ctx, sessionID)
+
+	want := []models.ProposalClass{
+		models.ClassConflicting,
+		models.ClassConfirming,
+		models.ClassBlocked,
+		models.ClassConfirming,
+		models.ClassConfirming,
+		models.ClassConflicting,
+		models.ClassNew,
+	}
+	for i, ip := range item.ProposedIdentityUpdates {
+		if ip.Classification != want[i] {
+			t.Errorf("%s = %#v: classification %q, want %q", ip.Key, ip.Value, ip.Classification, want[i])
+		}
+	}
+	if name := item.ProposedIdentityUpdates[0]; name.ExistingValue != "Peggy" || name.ExistingVersion != 1 {
+		t.Errorf("conflict without the stored value: %+v", name)
+	}
+	if occ := item.ProposedIdentityUpdates[6]; occ.ExistingValue != nil || occ.ExistingVersion != 0 {
+		t.Errorf("new proposal carries a stored value: %+v", occ)
+	}
+}
@@ -0,0 +1,136 @@
+package session
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+)
+
+// Memory deduplication compares word sets (stop words included, so "I do
+// not like gardening" stays apart from "I like gardening") by Jaccard
+// similarity.
+const (
+	// duplicateThreshold and above: the proposal is dropped.
+	duplicateThreshold = 0.85
+	// likelyDuplicateThreshold and above: the proposal is kept but points
+	// at the existing chunk so the caretaker can merge instead.
+	likelyDuplicateThreshold = 0.5
+	// dedupCandidates is how many search hits are compared per proposal.
+	dedupCandidates = 5
+)
+
+// dedupMemories drops proposals that repeat one another, a pending review
+// or an existing memory, and annotates likely duplicates of existing
+// memories. Lookup failures are logged and skip that comparison, so a
+// session is never lost to deduplication.
+func (p *Processor) dedupMemories(ctx context.Context, userID string, proposals []models.MemoryProposal) []models.MemoryProposal {
+	if len(proposals) == 0 {
+		return proposals
+	}
+
+	var seen []map[string]bool // this session's kept proposals and pending ones
+	pending, err := p.store.ListPendingReviews(ctx, userID)
+	if err != nil {
+		log.Printf("⚠️  dedup: listing pending reviews failed: %v", err)
+	}
+	for _, r := range pending {
+		for _, mp := range r.ProposedMemories {
+			if mp.Decision == models.DecisionReject {
+				continue
+			}
+			content := mp.Content
+			if mp.Decision == models.DecisionEdit {
+				content = mp.EditedContent
+			}
+			seen = append(seen, wordSet(content))
+		}
+	}
+
+	kept := proposals[:0:0]
+	dropped := 0
+	for _, mp := range proposals {
+		words := wordSet(mp.Content)
+		if bestSimilarity(words, seen) >= duplicateThreshold {
+			dropped++
+			continue
+		}
+
+		chunkID, sim := p.closestMemory(ctx, userID, mp.Content, words)
+		if sim >= duplicateThreshold {
+			dropped++
+			continue
+		}
+		if sim >= likelyDuplicateThreshold {
+			mp.DuplicateOf, mp.Similarity = chunkID, sim
+		}
+
+		seen = append(seen, words)
+		kept = append(kept, mp)
+	}
+
+	if dropped > 0 {
+		log.Printf("🧹 Dropped %d duplicate memory proposal(s) for %s", dropped, userID)
+	}
+	return kept
+}
+
+// closestMemory returns the existing chunk most similar to content.
+func (p *Processor) closestMemory(ctx context.Context, userID, content string, words map[string]bool) (string, float64) {
+	if p.memory == nil {
+		return "", 0
+	}
+	hits, _, err := p.memory.Search(ctx, userID, "", content, dedupCandidates, retrieval.SearchOptions{})
+	if err != nil {
+		log.Printf("⚠️  dedup: searching existing memories failed: %v", err)
+		return "", 0
+	}
+
+	var bestID string
+	var best float64
+	for _, h := range hits {
+		if sim := jaccard(words, wordSet(h.Chunk.Content)); sim > best {
+			bestID, best = h.Chunk.ChunkID, sim
+		}
+	}
+	return bestID, best
+}
+
+func bestSimilarity(words map[string]bool, others []map[string]bool) float64 {
+	var best float64
+	for _, o := range others {
+		best = max(best, jaccard(words, o))
+	}
+	return best
+}
+
+// wordSet lowercases text and splits it into words, ignoring punctuation.
+func wordSet(text string) map[string]bool {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	set := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if f = strings.Trim(f, "'"); f != "" {
+			set[f] = true
+		}
+	}
+	return set
+}
+
+// jaccard is |a ∩ b| / |a ∪ b|, or 0 when both are empty.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for w := range a {
+		if b[w] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
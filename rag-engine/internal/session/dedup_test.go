@@ -0,0 +1,71 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+func TestProcessDeduplicatesMemories(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+	memory := retrieval.NewMemoryService(store, nil, nil)
+	stored, err := memory.Store(ctx, "u1", "", "I love gardening.", "manual", "", 0.5)
+	if err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	pending := &models.ReviewItem{
+		SessionID: "earlier", UserID: "u1", Status: models.ReviewPending,
+		ProposedMemories: []models.MemoryProposal{{Content: "We went to Whitby every summer"}},
+	}
+	if err := store.StoreReview(ctx, pending); err != nil {
+		t.Fatalf("StoreReview: %v", err)
+	}
+
+	proc := NewProcessor(store, nil, memory, NewRuleExtractor())
+	sessionID, err := proc.Process(ctx, "u1", []models.TranscriptLine{
+		{Role: "user", Content: "I love gardening!"},                 // existing memory
+		{Role: "user", Content: "We went to Whitby every summer."},   // pending review
+		{Role: "user", Content: "Dad built the shed in 1975."},       // new
+		{Role: "user", Content: "Dad built the shed in 1975"},        // repeated this session
+		{Role: "user", Content: "I really love gardening"},           // likely duplicate
+		{Role: "user", Content: "I do not love gardening any more."}, // negation is not a duplicate
+	})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	item, _ := store.GetReview(ctx, sessionID)
+	got := item.ProposedMemories
+	if len(got) != 3 {
+		t.Fatalf("kept %d proposals, want 3: %+v", len(got), got)
+	}
+	if got[0].Content != "Dad built the shed in 1975." || got[0].DuplicateOf != "" {
+		t.Errorf("new memory = %+v", got[0])
+	}
+	if got[1].DuplicateOf != stored.ChunkIDs[0] || got[1].Similarity != 0.75 {
+		t.Errorf("likely duplicate = %+v, want duplicate_of %s at 0.75", got[1], stored.ChunkIDs[0])
+	}
+	if got[2].DuplicateOf != "" {
+		t.Errorf("negated statement marked as duplicate: %+v", got[2])
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want float64
+	}{
+		{"I love gardening", "i LOVE gardening!", 1},
+		{"I love gardening", "I really love gardening", 0.75},
+		{"Tom's bike", "toms bike", 1.0 / 3},
+		{"", "", 0},
+	} {
+		if got := jaccard(wordSet(tt.a), wordSet(tt.b)); got != tt.want {
+			t.Errorf("jaccard(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+// Extractor turns a conversation transcript into proposed identity updates
+// and memories for caretaker review.
+type Extractor interface {
+	Extract(ctx context.Context, messages []models.TranscriptLine) ([]models.IdentityProposal, []models.MemoryProposal, error)
+	// Name identifies the extractor in logs.
+	Name() string
+}
+
+// Chain tries its extractors in order and returns the first success.
+type Chain struct {
+	extractors []Extractor
+}
+
+// NewChain creates a chain over the given extractors.
+func NewChain(extractors ...Extractor) *Chain {
+	return &Chain{extractors: extractors}
+}
+
+// Name lists the links of the chain.
+func (c *Chain) Name() string {
+	names := make([]string, len(c.extractors))
+	for i, e := range c.extractors {
+		names[i] = e.Name()
+	}
+	return strings.Join(names, " → ")
+}
+
+// Extract returns the proposals of the first extractor that succeeds, or
+// all of their errors when none does.
+func (c *Chain) Extract(ctx context.Context, messages []models.TranscriptLine) ([]models.IdentityProposal, []models.MemoryProposal, error) {
+	var errs []error
+	for _, e := range c.extractors {
+		identities, memories, err := e.Extract(ctx, messages)
+		if err == nil {
+			return identities, memories, nil
+		}
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		log.Printf("⚠️  %s extraction failed, trying next extractor: %v", e.Name(), err)
+		errs = append(errs, fmt.Errorf("%s: %w", e.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, nil, fmt.Errorf("no extractors configured")
+	}
+	return nil, nil, errors.Join(errs...)
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+type failingExtractor struct{ name string }
+
+func (f failingExtractor) Name() string { return f.name }
+
+func (f failingExtractor) Extract(context.Context, []models.TranscriptLine) ([]models.IdentityProposal, []models.MemoryProposal, error) {
+	return nil, nil, errors.New(f.name + " is down")
+}
+
+func TestChainUsesFirstSuccess(t *testing.T) {
+	chain := NewChain(failingExtractor{"primary"}, NewRuleExtractor(), failingExtractor{"never"})
+	if got := chain.Name(); got != "primary → rules → never" {
+		t.Errorf("Name = %q", got)
+	}
+	ids, mems, err := chain.Extract(context.Background(), transcript)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(ids) != 2 || len(mems) != 1 {
+		t.Errorf("rule proposals = %+v, %+v", ids, mems)
+	}
+}
+
+func TestChainAllFail(t *testing.T) {
+	_, _, err := NewChain(failingExtractor{"a"}, failingExtractor{"b"}).Extract(context.Background(), transcript)
+	if err == nil || !strings.Contains(err.Error(), "a is down") || !strings.Contains(err.Error(), "b is down") {
+		t.Errorf("Extract error = %v, want both failures", err)
+	}
+	if _, _, err := NewChain().Extract(context.Background(), transcript); err == nil {
+		t.Error("empty chain succeeded")
+	}
+}
+
+func TestProcessFallsBackToRules(t *testing.T) {
+	ctx := context.Background()
+	llm, _ := fakeChat(t, func(_ int, w http.ResponseWriter) { completion(w, "I can't do that") })
+	store := storage.NewMemoryStorage()
+
+	sessionID, err := NewProcessor(store, nil, nil, NewChain(llm, NewRuleExtractor())).Process(ctx, "u1", transcript)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	item, _ := store.GetReview(ctx, sessionID)
+	ids := item.ProposedIdentityUpdates
+	if len(ids) != 2 || ids[0].Value != "Margaret" || ids[1].Value != "Whitby" {
+		t.Errorf("fallback proposals = %+v", ids)
+	}
+}
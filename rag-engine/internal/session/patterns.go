@@ -0,0 +1,253 @@
+package session
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+// identityPattern recognises one way of stating an identity fact. Trigger
+// phrases match case-insensitively while the captured values keep the
+// speaker's casing, so names and places come out as they were written.
+type identityPattern struct {
+	name       string
+	re         *regexp.Regexp
+	confidence float64
+	// fact maps a match to a canonical identity key and value; ok=false
+	// drops the match.
+	fact func(m []string) (key string, value any, ok bool)
+}
+
+// Building blocks for the patterns below.
+const (
+	// A capitalised name of up to three words: "Sarah", "Mary Ann", "O'Neill".
+	namePart = `([A-Z][\p{L}'’-]*(?:\s+[A-Z][\p{L}'’-]*){0,2})`
+	// A place, optionally qualified: "Leeds", "Leeds, Yorkshire", "New York".
+	placePart = `([A-Z][\p{L}'’-]*(?:\s+[A-Z][\p{L}'’-]*){0,3}(?:,\s+[A-Z][\p{L}'’-]*(?:\s+[A-Z][\p{L}'’-]*){0,2})?)`
+	// What may follow a place: punctuation, the end, or a lowercase word.
+	// This keeps "born in March 1941 in Leeds" from taking "March".
+	placeEnd = `(?:\s+[a-z]|\s*[.,!?;]|\s*$)`
+	// Introduces a name after a relation: "my wife Joan", "my wife, Joan",
+	// "my wife's name was Joan", "my wife called Joan".
+	named = `(?i:'s name (?:is|was)|,? (?:called|named|whose name (?:is|was)))?[,\s]\s*`
+
+	monthPart = `(?i:jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)`
+	datePart  = `(\d{1,2}(?i:st|nd|rd|th)?(?:\s+of)?\s+` + monthPart + `,?\s+\d{4}` +
+		`|` + monthPart + `\s+\d{1,2}(?i:st|nd|rd|th)?,?\s+\d{4}` +
+		`|` + monthPart + `,?\s+\d{4}` +
+		`|\d{4})\b`
+)
+
+var identityPatterns = []identityPattern{
+	{
+		name:       "name",
+		re:         regexp.MustCompile(`(?i:\bmy (?:full )?name is|\bmy name's|\bi'm called|\bi am called)\s+` + namePart),
+		confidence: 0.9,
+		fact:       fixed("name"),
+	},
+	{
+		name:       "preferred_name",
+		re:         regexp.MustCompile(`(?i:\b(?:please |everyone |people |they |friends )?call(?:s|ed)? me)\s+` + namePart),
+		confidence: 0.75,
+		fact:       fixed("preferred_name"),
+	},
+	{
+		name:       "spouse",
+		re:         regexp.MustCompile(`(?i:\bmy (?:late |first |second )?(wife|husband|partner))` + named + namePart),
+		confidence: 0.85,
+		fact:       person("spouse"),
+	},
+	{
+		name:       "children",
+		re:         regexp.MustCompile(`(?i:\bmy (?:eldest |oldest |youngest |little |middle )?(daughter|son))` + named + namePart),
+		confidence: 0.85,
+		fact:       person("children"),
+	},
+	{
+		name:       "grandchildren",
+		re:         regexp.MustCompile(`(?i:\bmy (?:eldest |oldest |youngest |little )?(granddaughter|grandson|grandchild))` + named + namePart),
+		confidence: 0.85,
+		fact:       person("grandchildren"),
+	},
+	{
+		name:       "birthdate",
+		re:         regexp.MustCompile(`(?i:\b(?:i was born|born))\b[^.!?]*?(?i:\b(?:in|on))\s+(?i:the\s+)?` + datePart),
+		confidence: 0.85,
+		fact: func(m []string) (string, any, bool) {
+			d, ok := parseBirthdate(m[1])
+			return "birthdate", d, ok
+		},
+	},
+	{
+		name:       "birthplace",
+		re:         regexp.MustCompile(`(?i:\b(?:i was born|born))\b[^.!?]*?(?i:\b(?:in|at))\s+` + placePart + placeEnd),
+		confidence: 0.85,
+		fact:       fixed("birthplace"),
+	},
+	{
+		name:       "hometown",
+		re:         regexp.MustCompile(`(?i:\bi grew up in|\bi was (?:raised|brought up) in|\bi'm from|\bi am from|\bi come from|\bmy home ?town (?:is|was))\s+` + placePart + placeEnd),
+		confidence: 0.8,
+		fact:       fixed("hometown"),
+	},
+	{
+		name: "occupation",
+		re: regexp.MustCompile(`(?i:\bi (?:worked|work|used to work|trained|retired) as|\bmy (?:job|profession|occupation) (?:is|was))\s+` +
+			`(?i:an?\s+)?([\p{L}-]+(?:\s+[\p{L}-]+){0,2}?)` +
+			`(?:\s+(?i:at|in|for|until|from|before|after|when|and|but|on|with)\b|\s*[.,!?;]|\s*$)`),
+		confidence: 0.8,
+		fact:       fixed("occupation"),
+	},
+	{
+		name:       "pets",
+		re:         regexp.MustCompile(`(?i:\bmy (?:old |late |little )?(?:dog|cat|pet|puppy|kitten|budgie|parrot|horse|pony|rabbit|hamster|tortoise))` + named + namePart),
+		confidence: 0.8,
+		fact:       fixed("pets"),
+	},
+	{
+		name:       "favourite",
+		re:         regexp.MustCompile(`(?i:\bmy favou?rite (colou?r|food|meal|drink|song|singer|band|book|author|film|movie|flower|season|place|sport|team|hobby) (?:is|was|has always been)\s+)([^.,!?;]+)`),
+		confidence: 0.8,
+		fact: func(m []string) (string, any, bool) {
+			return "favourite_" + favouriteKeys.Replace(strings.ToLower(m[1])), strings.TrimSpace(m[2]), true
+		},
+	},
+}
+
+// favouriteKeys folds spelling variants onto the canonical key suffix.
+var favouriteKeys = strings.NewReplacer("color", "colour", "movie", "film")
+
+// listKeys are multi-valued: every match adds an item instead of competing.
+var listKeys = map[string]bool{"children": true, "grandchildren": true, "pets": true}
+
+// fixed uses the first capture as the value of key.
+func fixed(key string) func(m []string) (string, any, bool) {
+	return func(m []string) (string, any, bool) {
+		return key, strings.TrimSpace(m[len(m)-1]), true
+	}
+}
+
+// person builds a person reference from a (relation, name) match.
+func person(key string) func(m []string) (string, any, bool) {
+	return func(m []string) (string, any, bool) {
+		return key, map[string]any{"name": strings.TrimSpace(m[2]), "relation": strings.ToLower(m[1])}, true
+	}
+}
+
+var (
+	yearOnly  = regexp.MustCompile(`^\d{4}$`)
+	dayMonth  = regexp.MustCompile(`^(\d{1,2})(?i:st|nd|rd|th)?(?:\s+of)?\s+(\pL+),?\s+(\d{4})$`)
+	monthDay  = regexp.MustCompile(`^(\pL+)\s+(\d{1,2})(?i:st|nd|rd|th)?,?\s+(\d{4})$`)
+	monthYear = regexp.MustCompile(`^(\pL+),?\s+(\d{4})$`)
+)
+
+// parseBirthdate turns a spoken date into YYYY, YYYY-MM or YYYY-MM-DD.
+func parseBirthdate(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	var day, month, year string
+	switch {
+	case yearOnly.MatchString(s):
+		year = s
+	case dayMonth.MatchString(s):
+		m := dayMonth.FindStringSubmatch(s)
+		day, month, year = m[1], m[2], m[3]
+	case monthDay.MatchString(s):
+		m := monthDay.FindStringSubmatch(s)
+		month, day, year = m[1], m[2], m[3]
+	case monthYear.MatchString(s):
+		m := monthYear.FindStringSubmatch(s)
+		month, year = m[1], m[2]
+	default:
+		return "", false
+	}
+
+	y, _ := strconv.Atoi(year)
+	if y < 1850 || y > time.Now().Year() {
+		return "", false
+	}
+	if month == "" {
+		return year, true
+	}
+	mon, ok := monthNumber(month)
+	if !ok {
+		return "", false
+	}
+	if day == "" {
+		return fmt.Sprintf("%s-%02d", year, mon), true
+	}
+	d, _ := strconv.Atoi(day)
+	if t := time.Date(y, time.Month(mon), d, 0, 0, 0, 0, time.UTC); t.Day() != d {
+		return "", false // e.g. 31 June
+	}
+	return fmt.Sprintf("%s-%02d-%02d", year, mon, d), true
+}
+
+func monthNumber(name string) (int, bool) {
+	name = strings.ToLower(name)
+	for m := time.January; m <= time.December; m++ {
+		full := strings.ToLower(m.String())
+		if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+			return int(m), true
+		}
+	}
+	return 0, false
+}
+
+// matchIdentity runs the pattern library over the user's messages. Each
+// key yields one proposal: list keys collect every distinct item, other
+// keys keep the most confident match (the earliest on ties).
+func matchIdentity(messages []models.TranscriptLine) []models.IdentityProposal {
+	var proposals []models.IdentityProposal
+	index := make(map[string]int) // key -> position in proposals
+	seen := make(map[string]bool) // key + item, for list keys
+
+	for _, msg := range messages {
+		if msg.Role != "user" {
+			continue
+		}
+		for _, p := range identityPatterns {
+			for _, m := range p.re.FindAllStringSubmatch(msg.Content, -1) {
+				key, value, ok := p.fact(m)
+				if !ok || value == "" || itemName(value) == "I" {
+					continue // "my son, I think..." is not a son called I
+				}
+				i, exists := index[key]
+				switch {
+				case listKeys[key]:
+					item := key + "\x00" + strings.ToLower(itemName(value))
+					if seen[item] {
+						continue
+					}
+					seen[item] = true
+					if !exists {
+						index[key] = len(proposals)
+						proposals = append(proposals, models.IdentityProposal{Key: key, Value: []any{value}, Confidence: p.confidence})
+						continue
+					}
+					proposals[i].Value = append(proposals[i].Value.([]any), value)
+					proposals[i].Confidence = min(proposals[i].Confidence, p.confidence)
+				case !exists:
+					index[key] = len(proposals)
+					proposals = append(proposals, models.IdentityProposal{Key: key, Value: value, Confidence: p.confidence})
+				case p.confidence > proposals[i].Confidence:
+					proposals[i].Value, proposals[i].Confidence = value, p.confidence
+				}
+			}
+		}
+	}
+	return proposals
+}
+
+// itemName is the identifying text of a list item, for deduplication.
+func itemName(v any) string {
+	if ref, ok := v.(map[string]any); ok {
+		name, _ := ref["name"].(string)
+		return name
+	}
+	s, _ := v.(string)
+	return s
+}
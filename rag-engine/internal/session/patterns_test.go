@@ -0,0 +1,115 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+type ref = map[string]any
+
+// corpus pairs things families say with the identity facts they state.
+var corpus = []struct {
+	say  string
+	want map[string]any
+}{
+	// Names
+	{"My name is Margaret Rose.", map[string]any{"name": "Margaret Rose"}},
+	{"my name's Arthur", map[string]any{"name": "Arthur"}},
+	{"My name is Margaret but everyone calls me Peggy.", map[string]any{"name": "Margaret", "preferred_name": "Peggy"}},
+
+	// Relationships
+	{"My wife's name was Joan.", map[string]any{"spouse": ref{"name": "Joan", "relation": "wife"}}},
+	{"I miss my late husband Frank terribly.", map[string]any{"spouse": ref{"name": "Frank", "relation": "husband"}}},
+	{"My daughter Sarah visits on Sundays.", map[string]any{"children": []any{ref{"name": "Sarah", "relation": "daughter"}}}},
+	{"My eldest son, Tom, lives in Canada and my daughter called Amy is a nurse.", map[string]any{
+		"children": []any{ref{"name": "Tom", "relation": "son"}, ref{"name": "Amy", "relation": "daughter"}},
+	}},
+	{"My granddaughter Lily is starting school.", map[string]any{"grandchildren": []any{ref{"name": "Lily", "relation": "granddaughter"}}}},
+	{"My son is a doctor.", map[string]any{}},
+	{"My wife and I went dancing.", map[string]any{}},
+
+	// Birth
+	{"I was born in 1948.", map[string]any{"birthdate": "1948"}},
+	{"I was born on the 9th of March 1941.", map[string]any{"birthdate": "1941-03-09"}},
+	{"I was born on March 9, 1941 in Whitby.", map[string]any{"birthdate": "1941-03-09", "birthplace": "Whitby"}},
+	{"I was born in Leeds in 1948.", map[string]any{"birthplace": "Leeds", "birthdate": "1948"}},
+	{"I was born in June 1952 in Cork, Ireland.", map[string]any{"birthdate": "1952-06", "birthplace": "Cork, Ireland"}},
+	{"I was born at home during the war.", map[string]any{}},
+	{"I was born on 31 June 1950.", map[string]any{}},
+
+	// Places
+	{"I grew up in Leeds.", map[string]any{"hometown": "Leeds"}},
+	{"I'm from New York originally.", map[string]any{"hometown": "New York"}},
+	{"I was brought up in St Ives", map[string]any{"hometown": "St Ives"}},
+
+	// Work
+	{"I worked as a nurse at St James's for forty years.", map[string]any{"occupation": "nurse"}},
+	{"I used to work as a bus driver.", map[string]any{"occupation": "bus driver"}},
+	{"My job was teaching", map[string]any{"occupation": "teaching"}},
+
+	// Pets and favourites
+	{"My old dog Biscuit and my cat Smudge.", map[string]any{"pets": []any{"Biscuit", "Smudge"}}},
+	{"My favourite colour is blue.", map[string]any{"favourite_colour": "blue"}},
+	{"My favorite color has always been green", map[string]any{"favourite_colour": "green"}},
+	{"My favourite song is Abide With Me.", map[string]any{"favourite_song": "Abide With Me"}},
+	{"My favourite movie was The Sound of Music", map[string]any{"favourite_film": "The Sound of Music"}},
+
+	// Nothing to extract
+	{"What day is it today?", map[string]any{}},
+	{"It rained all week.", map[string]any{}},
+}
+
+func TestIdentityPatternCorpus(t *testing.T) {
+	for _, tt := range corpus {
+		got := make(map[string]any)
+		for _, p := range matchIdentity([]models.TranscriptLine{{Role: "user", Content: tt.say}}) {
+			if p.Confidence <= 0 || p.Confidence > 1 {
+				t.Errorf("%q: %s confidence %v out of range", tt.say, p.Key, p.Confidence)
+			}
+			got[p.Key] = p.Value
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q\n got  %v\n want %v", tt.say, got, tt.want)
+		}
+	}
+}
+
+func TestIdentityPatternsAcrossMessages(t *testing.T) {
+	got := matchIdentity([]models.TranscriptLine{
+		{Role: "user", Content: "My daughter Sarah came round."},
+		{Role: "assistant", Content: "My name is Companion."},
+		{Role: "user", Content: "Everyone calls me Peg. My daughter Sarah and my son Tom."},
+		{Role: "user", Content: "My name is Margaret."},
+	})
+
+	want := []models.IdentityProposal{
+		{Key: "children", Value: []any{ref{"name": "Sarah", "relation": "daughter"}, ref{"name": "Tom", "relation": "son"}}, Confidence: 0.85},
+		{Key: "preferred_name", Value: "Peg", Confidence: 0.75},
+		{Key: "name", Value: "Margaret", Confidence: 0.9},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("proposals =\n %+v\nwant\n %+v", got, want)
+	}
+}
+
+func TestParseBirthdate(t *testing.T) {
+	for in, want := range map[string]string{
+		"1948":             "1948",
+		"March 1941":       "1941-03",
+		"Sept 1939":        "1939-09",
+		"9 March 1941":     "1941-03-09",
+		"1st of May 1950":  "1950-05-01",
+		"December 25 1937": "1937-12-25",
+		"29 February 1944": "1944-02-29",
+		"29 February 1945": "",
+		"1066":             "",
+		"Smarch 1941":      "",
+	} {
+		got, ok := parseBirthdate(in)
+		if got != want || ok != (want != "") {
+			t.Errorf("parseBirthdate(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+}
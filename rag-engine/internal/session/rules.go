@@ -0,0 +1,51 @@
+package session
+
+import (
+	"context"
+	"strings"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+// RuleExtractor does rule-based extraction from a transcript: identity facts
+// come from the pattern library in patterns.go and every statement becomes
+// a memory proposal. It needs no external service, so it is the usual last
+// link of a Chain.
+type RuleExtractor struct{}
+
+// NewRuleExtractor creates a rule-based extractor.
+func NewRuleExtractor() *RuleExtractor {
+	return &RuleExtractor{}
+}
+
+// Name identifies the extractor in logs.
+func (RuleExtractor) Name() string { return "rules" }
+
+// Extract never fails.
+func (RuleExtractor) Extract(ctx context.Context, messages []models.TranscriptLine) ([]models.IdentityProposal, []models.MemoryProposal, error) {
+	var memories []models.MemoryProposal
+
+	for _, msg := range messages {
+		if msg.Role != "user" {
+			continue
+		}
+
+		content := strings.TrimSpace(msg.Content)
+		if len(content) < 10 {
+			continue // skip trivial messages
+		}
+
+		// Simple heuristic: if a user message is a statement (not a question),
+		// treat it as a potential memory
+		if !strings.HasSuffix(content, "?") {
+			memories = append(memories, models.MemoryProposal{
+				Content:    content,
+				Importance: 0.5,
+				Source:     "conversation",
+			})
+		}
+
+	}
+
+	return matchIdentity(messages), memories, nil
+}
@@ -3,45 +3,47 @@ package session
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
 	"github.com/memory-lane/rag-engine/internal/storage"
 )
 
-// Processor handles conversation session processing.
-// When Groq is configured, it calls the LLM for structured extraction.
-// Until then, it uses a simple rule-based extractor.
+// Processor handles conversation session processing: it runs the
+// configured Extractor over a transcript, compares the proposals with what
+// the user already has and queues them for review.
 type Processor struct {
-	store   storage.Storage
-	groqKey string // empty = stub mode
+	store     storage.Storage
+	identity  *retrieval.IdentityService // nil = identity proposals are not classified
+	memory    *retrieval.MemoryService   // nil = no comparison with existing memories
+	extractor Extractor
 }
 
-// NewProcessor creates a session processor.
-func NewProcessor(store storage.Storage, groqKey string) *Processor {
-	return &Processor{store: store, groqKey: groqKey}
+// NewProcessor creates a session processor. A nil extractor selects the
+// rule-based one.
+func NewProcessor(store storage.Storage, identity *retrieval.IdentityService, memory *retrieval.MemoryService, extractor Extractor) *Processor {
+	if extractor == nil {
+		extractor = NewRuleExtractor()
+	}
+	return &Processor{store: store, identity: identity, memory: memory, extractor: extractor}
 }
 
 // Process takes a conversation transcript, extracts proposed identity updates
 // and memory chunks, and stores them in the review queue for caretaker approval.
 func (p *Processor) Process(ctx context.Context, userID string, messages []models.TranscriptLine) (string, error) {
 	if userID == "" || len(messages) == 0 {
-		return "", fmt.Errorf("user_id and messages are required")
+		return "", models.Required("user_id", "messages")
 	}
 
 	sessionID := fmt.Sprintf("session-%s-%d", userID, time.Now().UnixNano())
 
-	var identityProposals []models.IdentityProposal
-	var memoryProposals []models.MemoryProposal
-
-	if p.groqKey != "" {
-		// TODO: Call Groq extraction prompt when API key is available
-		// For now, fall through to simple extraction
-		identityProposals, memoryProposals = p.simpleExtract(messages)
-	} else {
-		identityProposals, memoryProposals = p.simpleExtract(messages)
+	identityProposals, memoryProposals, err := p.extractor.Extract(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("extract proposals: %w", err)
 	}
+	p.classifyIdentity(ctx, userID, identityProposals)
+	memoryProposals = p.dedupMemories(ctx, userID, memoryProposals)
 
 	review := &models.ReviewItem{
 		SessionID:               sessionID,
@@ -51,6 +53,7 @@ func (p *Processor) Process(ctx context.Context, userID string, messages []model
 		ProposedMemories:        memoryProposals,
 		CreatedAt:               time.Now(),
 	}
+	review.AssignProposalIDs()
 
 	if err := p.store.StoreReview(ctx, review); err != nil {
 		return "", fmt.Errorf("store review: %w", err)
@@ -58,48 +61,3 @@ func (p *Processor) Process(ctx context.Context, userID string, messages []model
 
 	return sessionID, nil
 }
-
-// simpleExtract does rule-based extraction from a transcript.
-// This is a placeholder until Groq-powered extraction is wired up.
-func (p *Processor) simpleExtract(messages []models.TranscriptLine) ([]models.IdentityProposal, []models.MemoryProposal) {
-	var identities []models.IdentityProposal
-	var memories []models.MemoryProposal
-
-	for _, msg := range messages {
-		if msg.Role != "user" {
-			continue
-		}
-
-		content := strings.TrimSpace(msg.Content)
-		if len(content) < 10 {
-			continue // skip trivial messages
-		}
-
-		// Simple heuristic: if a user message is a statement (not a question),
-		// treat it as a potential memory
-		if !strings.HasSuffix(content, "?") {
-			memories = append(memories, models.MemoryProposal{
-				Content:    content,
-				Importance: 0.5,
-				Source:     "conversation",
-			})
-		}
-
-		// Very simple identity extraction: look for "my X is Y" patterns
-		lower := strings.ToLower(content)
-		if strings.Contains(lower, "my name is") {
-			parts := strings.SplitN(lower, "my name is", 2)
-			if len(parts) == 2 {
-				name := strings.TrimSpace(parts[1])
-				name = strings.TrimRight(name, ".,!?")
-				identities = append(identities, models.IdentityProposal{
-					Key:        "name",
-					Value:      name,
-					Confidence: 0.8,
-				})
-			}
-		}
-	}
-
-	return identities, memories
-}
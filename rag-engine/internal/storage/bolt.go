@@ -0,0 +1,863 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt buckets mirror the Mongo collections. Keys are NUL-separated so a
+// user (and optionally replica) can be range-scanned with a prefix seek:
+//
+//	identity_core     user_id \0 key                          -> IdentityFact
+//	identity_history  user_id \0 key \0 version (%010d)       -> IdentityRevision
+//	memory_chunks     user_id \0 chunk_id                     -> MemoryChunk
+//	token_index       user_id \0 replica_id \0 token \0 chunk -> TokenEntry
+//	review_queue      session_id                              -> ReviewItem
+//	corpus_stats      user_id \0 replica_id                   -> CorpusStats
+//	documents         document_id                             -> Document
+//	jobs              job_id                                  -> Job
+//	idempotency_keys  user_id \0 key                          -> IdempotencyRecord
+var (
+	boltIdentityBucket = []byte(identityCollection)
+	boltHistoryBucket  = []byte(historyCollection)
+	boltMemoryBucket   = []byte(memoryCollection)
+	boltTokenBucket    = []byte(tokenCollection)
+	boltReviewBucket   = []byte(reviewCollection)
+	boltStatsBucket    = []byte(statsCollection)
+	boltDocumentBucket = []byte(documentCollection)
+	boltJobBucket      = []byte(jobCollection)
+	boltIdemBucket     = []byte(idemCollection)
+)
+
+// BoltStorage implements Storage on top of a single local bbolt file.
+// It needs no external services, which suits small or at-home installs.
+type BoltStorage struct {
+	db   *bolt.DB
+	path string
+}
+
+// NewBoltStorage opens (or creates) the database file at path.
+func NewBoltStorage(path string) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("bolt open %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{boltIdentityBucket, boltHistoryBucket, boltMemoryBucket, boltTokenBucket, boltReviewBucket, boltStatsBucket, boltDocumentBucket, boltJobBucket, boltIdemBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("bolt buckets: %w", err)
+	}
+
+	return &BoltStorage{db: db, path: path}, nil
+}
+
+// boltKey joins key parts with a NUL separator.
+func boltKey(parts ...string) []byte {
+	var buf bytes.Buffer
+	for i, p := range parts {
+		if i > 0 {
+			buf.WriteByte(0)
+		}
+		buf.WriteString(p)
+	}
+	return buf.Bytes()
+}
+
+// boltPrefix is boltKey with a trailing separator, for prefix scans.
+func boltPrefix(parts ...string) []byte {
+	return append(boltKey(parts...), 0)
+}
+
+// --- Identity ---
+
+func (s *BoltStorage) GetIdentity(ctx context.Context, userID, key string) (*models.IdentityFact, error) {
+	var fact *models.IdentityFact
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltIdentityBucket).Get(boltKey(userID, key))
+		if v == nil {
+			return nil // not found
+		}
+		fact = &models.IdentityFact{}
+		return json.Unmarshal(v, fact)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt get identity: %w", err)
+	}
+	return fact, nil
+}
+
+func (s *BoltStorage) SetIdentity(ctx context.Context, fact *models.IdentityFact) error {
+	data, err := json.Marshal(fact)
+	if err != nil {
+		return fmt.Errorf("bolt marshal identity: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltIdentityBucket).Put(boltKey(fact.UserID, fact.Key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt set identity: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) DeleteIdentity(ctx context.Context, userID, key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltIdentityBucket).Delete(boltKey(userID, key))
+	})
+	if err != nil {
+		return fmt.Errorf("bolt delete identity: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) ListIdentity(ctx context.Context, userID string) ([]models.IdentityFact, error) {
+	var facts []models.IdentityFact
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltIdentityBucket).Cursor()
+		prefix := boltPrefix(userID)
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var fact models.IdentityFact
+			if err := json.Unmarshal(v, &fact); err != nil {
+				return err
+			}
+			facts = append(facts, fact)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt list identity: %w", err)
+	}
+	return facts, nil
+}
+
+func (s *BoltStorage) GetIdentities(ctx context.Context, userID string, keys []string) ([]models.IdentityFact, error) {
+	var facts []models.IdentityFact
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltIdentityBucket)
+		for _, k := range sortedUnique(keys) {
+			v := b.Get(boltKey(userID, k))
+			if v == nil {
+				continue
+			}
+			var fact models.IdentityFact
+			if err := json.Unmarshal(v, &fact); err != nil {
+				return err
+			}
+			facts = append(facts, fact)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt get identities: %w", err)
+	}
+	return facts, nil
+}
+
+// boltVersion renders a version so keys sort numerically.
+func boltVersion(v int) string {
+	return fmt.Sprintf("%010d", v)
+}
+
+func (s *BoltStorage) AppendIdentityRevision(ctx context.Context, rev *models.IdentityRevision) error {
+	data, err := json.Marshal(rev)
+	if err != nil {
+		return fmt.Errorf("bolt marshal identity revision: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltHistoryBucket)
+		k := boltKey(rev.UserID, rev.Key, boltVersion(rev.Version))
+		if b.Get(k) != nil {
+			return fmt.Errorf("duplicate version %d of %q", rev.Version, rev.Key)
+		}
+		return b.Put(k, data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt append identity revision: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) ListIdentityRevisions(ctx context.Context, userID, key string) ([]models.IdentityRevision, error) {
+	var revs []models.IdentityRevision
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltHistoryBucket).Cursor()
+		prefix := boltPrefix(userID, key)
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var rev models.IdentityRevision
+			if err := json.Unmarshal(v, &rev); err != nil {
+				return err
+			}
+			revs = append(revs, rev)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt list identity revisions: %w", err)
+	}
+	return revs, nil
+}
+
+// --- Memory ---
+
+func (s *BoltStorage) StoreMemory(ctx context.Context, chunk *models.MemoryChunk) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return fmt.Errorf("bolt marshal memory: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltMemoryBucket)
+		k := boltKey(chunk.UserID, chunk.ChunkID)
+		if b.Get(k) != nil {
+			return fmt.Errorf("duplicate chunk %q", chunk.ChunkID)
+		}
+		return b.Put(k, data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt store memory: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) GetMemory(ctx context.Context, userID, chunkID string) (*models.MemoryChunk, error) {
+	var chunk *models.MemoryChunk
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltMemoryBucket).Get(boltKey(userID, chunkID))
+		if v == nil {
+			return nil // not found
+		}
+		chunk = &models.MemoryChunk{}
+		return json.Unmarshal(v, chunk)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt get memory: %w", err)
+	}
+	return chunk, nil
+}
+
+func (s *BoltStorage) UpdateMemory(ctx context.Context, chunk *models.MemoryChunk) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return fmt.Errorf("bolt marshal memory: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltMemoryBucket)
+		k := boltKey(chunk.UserID, chunk.ChunkID)
+		if b.Get(k) == nil {
+			return nil // matches Mongo: updating a missing chunk is a no-op
+		}
+		return b.Put(k, data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt update memory: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) DeleteMemory(ctx context.Context, userID, chunkID string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltMemoryBucket).Delete(boltKey(userID, chunkID))
+	})
+	if err != nil {
+		return fmt.Errorf("bolt delete memory: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) SearchMemoryByTokens(ctx context.Context, userID, replicaID string, tokens []string, filter models.MemoryFilter) ([]models.MemoryChunk, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	chunkIDs, err := s.LookupTokens(ctx, userID, replicaID, tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunkIDs) == 0 {
+		return nil, nil
+	}
+	sort.Strings(chunkIDs)
+
+	var chunks []models.MemoryChunk
+	err = s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltMemoryBucket)
+		for _, id := range chunkIDs {
+			v := b.Get(boltKey(userID, id))
+			if v == nil {
+				continue // index entry without a chunk
+			}
+			var chunk models.MemoryChunk
+			if err := json.Unmarshal(v, &chunk); err != nil {
+				return err
+			}
+			if replicaID != "" && chunk.ReplicaID != replicaID {
+				continue
+			}
+			if !filter.Matches(chunk) {
+				continue
+			}
+			chunks = append(chunks, chunk)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt search memory: %w", err)
+	}
+	return chunks, nil
+}
+
+func (s *BoltStorage) ListMemory(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, page models.Page) ([]models.MemoryChunk, string, error) {
+	var chunks []models.MemoryChunk
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltMemoryBucket).Cursor()
+		prefix := boltPrefix(userID)
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var chunk models.MemoryChunk
+			if err := json.Unmarshal(v, &chunk); err != nil {
+				return err
+			}
+			if replicaID != "" && chunk.ReplicaID != replicaID {
+				continue
+			}
+			if !filter.Matches(chunk) {
+				continue
+			}
+			chunks = append(chunks, chunk)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("bolt list memory: %w", err)
+	}
+	return pageChunks(chunks, page)
+}
+
+func (s *BoltStorage) RecentMemory(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, limit int) ([]models.MemoryChunk, error) {
+	chunks, _, err := s.ListMemory(ctx, userID, replicaID, filter, models.Page{})
+	if err != nil {
+		return nil, err
+	}
+	return newestChunks(chunks, limit), nil
+}
+
+func (s *BoltStorage) RecordAccess(ctx context.Context, userID string, chunkIDs []string, at time.Time) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltMemoryBucket)
+		for _, id := range chunkIDs {
+			k := boltKey(userID, id)
+			v := b.Get(k)
+			if v == nil {
+				continue
+			}
+			var chunk models.MemoryChunk
+			if err := json.Unmarshal(v, &chunk); err != nil {
+				return err
+			}
+			chunk.AccessCount++
+			chunk.LastAccessedAt = &at
+			data, err := json.Marshal(chunk)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(k, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("bolt record access: %w", err)
+	}
+	return nil
+}
+
+// --- Token Index ---
+
+func (s *BoltStorage) IndexTokens(ctx context.Context, entries []models.TokenEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltTokenBucket)
+		for _, e := range entries {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(boltKey(e.UserID, e.ReplicaID, e.Token, e.ChunkID), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("bolt index tokens: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) LookupTokens(ctx context.Context, userID, replicaID string, tokens []string) ([]string, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	want := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		want[t] = true
+	}
+
+	chunkSet := make(map[string]bool)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltTokenBucket).Cursor()
+
+		if replicaID != "" {
+			// Exact replica: seek straight to each token's range
+			for t := range want {
+				prefix := boltPrefix(userID, replicaID, t)
+				for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+					chunkSet[string(k[len(prefix):])] = true
+				}
+			}
+			return nil
+		}
+
+		// Any replica: walk the user's entries and filter by token
+		prefix := boltPrefix(userID)
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			parts := bytes.SplitN(k[len(prefix):], []byte{0}, 3)
+			if len(parts) == 3 && want[string(parts[1])] {
+				chunkSet[string(parts[2])] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt lookup tokens: %w", err)
+	}
+
+	ids := make([]string, 0, len(chunkSet))
+	for id := range chunkSet {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *BoltStorage) RemoveTokens(ctx context.Context, userID, chunkID string, tokens []string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltTokenBucket)
+
+		// Collect first: deleting while iterating would skip keys
+		var stale [][]byte
+		suffix := append([]byte{0}, chunkID...)
+		prefix := boltPrefix(userID)
+		c := b.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			if bytes.HasSuffix(k, suffix) && bytes.Count(k[len(prefix):], []byte{0}) == 2 {
+				stale = append(stale, bytes.Clone(k))
+			}
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("bolt remove tokens: %w", err)
+	}
+	return nil
+}
+
+// --- Corpus Stats ---
+
+func (s *BoltStorage) UpdateCorpusStats(ctx context.Context, userID, replicaID string, tokens []string, length, delta int) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltStatsBucket)
+		k := boltKey(userID, replicaID)
+
+		st := models.CorpusStats{UserID: userID, ReplicaID: replicaID}
+		if v := b.Get(k); v != nil {
+			if err := json.Unmarshal(v, &st); err != nil {
+				return err
+			}
+		}
+		if st.DocFreq == nil {
+			st.DocFreq = make(map[string]int)
+		}
+		st.DocCount += delta
+		st.TotalLength += delta * length
+		for _, t := range tokens {
+			st.DocFreq[t] += delta
+			if st.DocFreq[t] <= 0 {
+				delete(st.DocFreq, t)
+			}
+		}
+
+		data, err := json.Marshal(st)
+		if err != nil {
+			return err
+		}
+		return b.Put(k, data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt update corpus stats: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) GetCorpusStats(ctx context.Context, userID, replicaID string, tokens []string) (*models.CorpusStats, error) {
+	out := &models.CorpusStats{UserID: userID, ReplicaID: replicaID, DocFreq: make(map[string]int, len(tokens))}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltStatsBucket).Get(boltKey(userID, replicaID))
+		if v == nil {
+			return nil
+		}
+		var st models.CorpusStats
+		if err := json.Unmarshal(v, &st); err != nil {
+			return err
+		}
+		out.DocCount = st.DocCount
+		out.TotalLength = st.TotalLength
+		for _, t := range tokens {
+			if df := st.DocFreq[t]; df != 0 {
+				out.DocFreq[t] = df
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt get corpus stats: %w", err)
+	}
+	return out, nil
+}
+
+// --- Review Queue ---
+
+func (s *BoltStorage) StoreReview(ctx context.Context, item *models.ReviewItem) error {
+	item.CreatedAt = time.Now()
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("bolt marshal review: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltReviewBucket)
+		if b.Get([]byte(item.SessionID)) != nil {
+			return fmt.Errorf("duplicate session %q", item.SessionID)
+		}
+		return b.Put([]byte(item.SessionID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt store review: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) GetReview(ctx context.Context, sessionID string) (*models.ReviewItem, error) {
+	var item *models.ReviewItem
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltReviewBucket).Get([]byte(sessionID))
+		if v == nil {
+			return nil
+		}
+		item = &models.ReviewItem{}
+		return json.Unmarshal(v, item)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt get review: %w", err)
+	}
+	return item, nil
+}
+
+func (s *BoltStorage) ListPendingReviews(ctx context.Context, userID string) ([]models.ReviewItem, error) {
+	var items []models.ReviewItem
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltReviewBucket).ForEach(func(k, v []byte) error {
+			var item models.ReviewItem
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			if item.UserID == userID && item.Status == models.ReviewPending {
+				items = append(items, item)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt list pending reviews: %w", err)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+func (s *BoltStorage) UpdateReviewStatus(ctx context.Context, sessionID string, status models.ReviewStatus) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltReviewBucket)
+		v := b.Get([]byte(sessionID))
+		if v == nil {
+			return nil // matches Mongo: updating a missing review is a no-op
+		}
+		var item models.ReviewItem
+		if err := json.Unmarshal(v, &item); err != nil {
+			return err
+		}
+		now := time.Now()
+		item.Status = status
+		item.ReviewedAt = &now
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(sessionID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt update review status: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) UpdateReview(ctx context.Context, item *models.ReviewItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("bolt marshal review: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltReviewBucket)
+		if b.Get([]byte(item.SessionID)) == nil {
+			return nil
+		}
+		return b.Put([]byte(item.SessionID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt update review: %w", err)
+	}
+	return nil
+}
+
+// --- Documents ---
+
+func (s *BoltStorage) StoreDocument(ctx context.Context, doc *models.Document) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("bolt marshal document: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDocumentBucket)
+		if b.Get([]byte(doc.DocumentID)) != nil {
+			return fmt.Errorf("duplicate document %q", doc.DocumentID)
+		}
+		return b.Put([]byte(doc.DocumentID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt store document: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) GetDocument(ctx context.Context, documentID string) (*models.Document, error) {
+	var doc *models.Document
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltDocumentBucket).Get([]byte(documentID))
+		if v == nil {
+			return nil
+		}
+		doc = &models.Document{}
+		return json.Unmarshal(v, doc)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt get document: %w", err)
+	}
+	return doc, nil
+}
+
+func (s *BoltStorage) DeleteDocument(ctx context.Context, documentID string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDocumentBucket).Delete([]byte(documentID))
+	})
+	if err != nil {
+		return fmt.Errorf("bolt delete document: %w", err)
+	}
+	return nil
+}
+
+// --- Job Queue ---
+
+func (s *BoltStorage) CreateJob(ctx context.Context, job *models.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("bolt marshal job: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltJobBucket)
+		if b.Get([]byte(job.JobID)) != nil {
+			return fmt.Errorf("duplicate job %q", job.JobID)
+		}
+		return b.Put([]byte(job.JobID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt create job: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) GetJob(ctx context.Context, jobID string) (*models.Job, error) {
+	var job *models.Job
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltJobBucket).Get([]byte(jobID))
+		if v == nil {
+			return nil
+		}
+		job = &models.Job{}
+		return json.Unmarshal(v, job)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt get job: %w", err)
+	}
+	return job, nil
+}
+
+// ClaimJob scans the bucket inside a single write transaction; bolt
+// serialises writers, so two workers never claim the same job.
+func (s *BoltStorage) ClaimJob(ctx context.Context, now time.Time, lease time.Duration) (*models.Job, error) {
+	var next *models.Job
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltJobBucket)
+		err := b.ForEach(func(k, v []byte) error {
+			var job models.Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return err
+			}
+			if jobDue(&job, now) && (next == nil || job.RunAt.Before(next.RunAt)) {
+				next = &job
+			}
+			return nil
+		})
+		if err != nil || next == nil {
+			return err
+		}
+		claimJob(next, now, lease)
+		data, err := json.Marshal(next)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(next.JobID), data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt claim job: %w", err)
+	}
+	return next, nil
+}
+
+func (s *BoltStorage) UpdateJob(ctx context.Context, job *models.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("bolt marshal job: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltJobBucket)
+		v := b.Get([]byte(job.JobID))
+		if v == nil {
+			return ErrJobLost
+		}
+		var stored models.Job
+		if err := json.Unmarshal(v, &stored); err != nil {
+			return err
+		}
+		if stored.Attempts != job.Attempts {
+			return ErrJobLost
+		}
+		return b.Put([]byte(job.JobID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt update job: %w", err)
+	}
+	return nil
+}
+
+// --- Idempotency Keys ---
+
+func (s *BoltStorage) ReserveIdempotencyKey(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("bolt marshal idempotency key: %w", err)
+	}
+	var existing *models.IdempotencyRecord
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltIdemBucket)
+		k := boltKey(rec.UserID, rec.Key)
+		if v := b.Get(k); v != nil {
+			existing = &models.IdempotencyRecord{}
+			return json.Unmarshal(v, existing)
+		}
+		return b.Put(k, data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt reserve idempotency key: %w", err)
+	}
+	return existing, nil
+}
+
+func (s *BoltStorage) CompleteIdempotencyKey(ctx context.Context, userID, key, resultID string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltIdemBucket)
+		k := boltKey(userID, key)
+		v := b.Get(k)
+		if v == nil {
+			return nil
+		}
+		var rec models.IdempotencyRecord
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return err
+		}
+		rec.ResultID = resultID
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		return b.Put(k, data)
+	})
+	if err != nil {
+		return fmt.Errorf("bolt complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltIdemBucket).Delete(boltKey(userID, key))
+	})
+	if err != nil {
+		return fmt.Errorf("bolt release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// --- Health & Lifecycle ---
+
+func (s *BoltStorage) Ping(ctx context.Context) error {
+	return s.db.View(func(tx *bolt.Tx) error { return nil })
+}
+
+func (s *BoltStorage) BackendName() string {
+	return "bolt"
+}
+
+func (s *BoltStorage) Close(ctx context.Context) error {
+	return s.db.Close()
+}
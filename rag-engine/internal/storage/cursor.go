@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor turns a backend-specific position into an opaque token.
+func EncodeCursor(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reverses EncodeCursor, returning ErrInvalidCursor on failure.
+func DecodeCursor(cursor string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// chunkPosition is the cursor of backends that order chunks by creation
+// time, with the chunk ID breaking ties.
+type chunkPosition struct {
+	CreatedAt time.Time `json:"c"`
+	ChunkID   string    `json:"id"`
+}
+
+// after reports whether a chunk sorts strictly after the position.
+func (p chunkPosition) after(c models.MemoryChunk) bool {
+	if !c.CreatedAt.Equal(p.CreatedAt) {
+		return c.CreatedAt.After(p.CreatedAt)
+	}
+	return c.ChunkID > p.ChunkID
+}
+
+// newestChunks orders chunks newest first and keeps at most limit of them.
+func newestChunks(chunks []models.MemoryChunk, limit int) []models.MemoryChunk {
+	sort.Slice(chunks, func(i, j int) bool {
+		if !chunks[i].CreatedAt.Equal(chunks[j].CreatedAt) {
+			return chunks[i].CreatedAt.After(chunks[j].CreatedAt)
+		}
+		return chunks[i].ChunkID > chunks[j].ChunkID
+	})
+	if len(chunks) > limit {
+		chunks = chunks[:limit]
+	}
+	return chunks
+}
+
+// pageChunks orders chunks by creation time and chunk ID and cuts out the
+// requested page. It backs the in-process stores, which filter in Go anyway.
+func pageChunks(chunks []models.MemoryChunk, page models.Page) ([]models.MemoryChunk, string, error) {
+	sort.Slice(chunks, func(i, j int) bool {
+		if !chunks[i].CreatedAt.Equal(chunks[j].CreatedAt) {
+			return chunks[i].CreatedAt.Before(chunks[j].CreatedAt)
+		}
+		return chunks[i].ChunkID < chunks[j].ChunkID
+	})
+
+	if page.Cursor != "" {
+		var pos chunkPosition
+		if err := DecodeCursor(page.Cursor, &pos); err != nil {
+			return nil, "", err
+		}
+		start := sort.Search(len(chunks), func(i int) bool { return pos.after(chunks[i]) })
+		chunks = chunks[start:]
+	}
+
+	if page.Limit <= 0 || len(chunks) <= page.Limit {
+		return chunks, "", nil
+	}
+	chunks = chunks[:page.Limit]
+	last := chunks[len(chunks)-1]
+	return chunks, EncodeCursor(chunkPosition{CreatedAt: last.CreatedAt, ChunkID: last.ChunkID}), nil
+}
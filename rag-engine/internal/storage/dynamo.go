@@ -2,7 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -15,14 +19,49 @@ import (
 
 const (
 	identityTable = "IdentityCore"
+	historyTable  = "IdentityHistory"
 	memoryTable   = "MemoryChunks"
 	tokenTable    = "TokenIndex"
 	reviewTable   = "ReviewQueue"
+	statsTable    = "CorpusStats"
+	documentTable = "Documents"
+	jobTable      = "Jobs"
+	idemTable     = "IdempotencyKeys"
 )
 
+// dynamoTagKey makes attribute names follow the json struct tags, so items
+// use the same field names (user_id, status, ...) as the Mongo documents and
+// as the filter expressions below. Items written before this used the Go
+// field names; MigrateAttributeNames rewrites them.
+const dynamoTagKey = "json"
+
+func marshalItem(v any) (map[string]types.AttributeValue, error) {
+	return attributevalue.MarshalMapWithOptions(v, func(o *attributevalue.EncoderOptions) {
+		o.TagKey = dynamoTagKey
+	})
+}
+
+func unmarshalItem(item map[string]types.AttributeValue, v any) error {
+	return attributevalue.UnmarshalMapWithOptions(item, v, func(o *attributevalue.DecoderOptions) {
+		o.TagKey = dynamoTagKey
+	})
+}
+
 // DynamoStorage implements Storage using AWS DynamoDB.
 type DynamoStorage struct {
 	client *dynamodb.Client
+
+	// pageSize caps the items read per query page; 0 leaves it to DynamoDB's
+	// 1 MB page. Tests shrink it to exercise pagination.
+	pageSize int32
+}
+
+// pageLimit is the Limit for queries that follow every page.
+func (s *DynamoStorage) pageLimit() *int32 {
+	if s.pageSize <= 0 {
+		return nil
+	}
+	return aws.Int32(s.pageSize)
 }
 
 // NewDynamoStorage creates a DynamoDB-backed storage.
@@ -66,7 +105,7 @@ func (s *DynamoStorage) GetIdentity(ctx context.Context, userID, key string) (*m
 	}
 
 	var fact models.IdentityFact
-	if err := attributevalue.UnmarshalMap(out.Item, &fact); err != nil {
+	if err := unmarshalItem(out.Item, &fact); err != nil {
 		return nil, fmt.Errorf("dynamo unmarshal identity: %w", err)
 	}
 	fact.UserID = userID
@@ -75,7 +114,7 @@ func (s *DynamoStorage) GetIdentity(ctx context.Context, userID, key string) (*m
 }
 
 func (s *DynamoStorage) SetIdentity(ctx context.Context, fact *models.IdentityFact) error {
-	item, err := attributevalue.MarshalMap(fact)
+	item, err := marshalItem(fact)
 	if err != nil {
 		return fmt.Errorf("dynamo marshal identity: %w", err)
 	}
@@ -92,28 +131,280 @@ func (s *DynamoStorage) SetIdentity(ctx context.Context, fact *models.IdentityFa
 	return nil
 }
 
+func (s *DynamoStorage) DeleteIdentity(ctx context.Context, userID, key string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(identityTable),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: "user#" + userID},
+			"sk": &types.AttributeValueMemberS{Value: "identity#" + key},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo delete identity: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStorage) ListIdentity(ctx context.Context, userID string) ([]models.IdentityFact, error) {
+	var facts []models.IdentityFact
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:              aws.String(identityTable),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: "user#" + userID},
+			":prefix": &types.AttributeValueMemberS{Value: "identity#"},
+		},
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("dynamo list identity: %w", err)
+		}
+		for _, item := range out.Items {
+			var fact models.IdentityFact
+			if err := unmarshalItem(item, &fact); err != nil {
+				continue
+			}
+			facts = append(facts, fact)
+		}
+	}
+	return facts, nil
+}
+
+func (s *DynamoStorage) GetIdentities(ctx context.Context, userID string, keys []string) ([]models.IdentityFact, error) {
+	keys = sortedUnique(keys)
+	found := make(map[string]models.IdentityFact, len(keys))
+
+	// BatchGetItem accepts at most 100 keys per call
+	const batchSize = 100
+	for start := 0; start < len(keys); start += batchSize {
+		end := min(start+batchSize, len(keys))
+		batchKeys := make([]map[string]types.AttributeValue, 0, end-start)
+		for _, k := range keys[start:end] {
+			batchKeys = append(batchKeys, map[string]types.AttributeValue{
+				"pk": &types.AttributeValueMemberS{Value: "user#" + userID},
+				"sk": &types.AttributeValueMemberS{Value: "identity#" + k},
+			})
+		}
+
+		request := map[string]types.KeysAndAttributes{identityTable: {Keys: batchKeys}}
+		for len(request) > 0 {
+			batch, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+			if err != nil {
+				return nil, fmt.Errorf("dynamo get identities: %w", err)
+			}
+			for _, item := range batch.Responses[identityTable] {
+				var fact models.IdentityFact
+				if err := unmarshalItem(item, &fact); err != nil {
+					continue
+				}
+				found[fact.Key] = fact
+			}
+			request = batch.UnprocessedKeys
+		}
+	}
+
+	// Responses come back unordered
+	var facts []models.IdentityFact
+	for _, k := range keys {
+		if f, ok := found[k]; ok {
+			facts = append(facts, f)
+		}
+	}
+	return facts, nil
+}
+
+// historyPK is the partition holding the revisions of one identity key;
+// sort keys are zero-padded versions so they order numerically.
+func historyPK(userID, key string) string {
+	return "user#" + userID + "#identity#" + key
+}
+
+func (s *DynamoStorage) AppendIdentityRevision(ctx context.Context, rev *models.IdentityRevision) error {
+	item, err := marshalItem(rev)
+	if err != nil {
+		return fmt.Errorf("dynamo marshal identity revision: %w", err)
+	}
+	item["pk"] = &types.AttributeValueMemberS{Value: historyPK(rev.UserID, rev.Key)}
+	item["sk"] = &types.AttributeValueMemberS{Value: fmt.Sprintf("version#%010d", rev.Version)}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(historyTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo append identity revision: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStorage) ListIdentityRevisions(ctx context.Context, userID, key string) ([]models.IdentityRevision, error) {
+	var revs []models.IdentityRevision
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:              aws.String(historyTable),
+		KeyConditionExpression: aws.String("pk = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: historyPK(userID, key)},
+		},
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("dynamo list identity revisions: %w", err)
+		}
+		for _, item := range out.Items {
+			var rev models.IdentityRevision
+			if err := unmarshalItem(item, &rev); err != nil {
+				continue
+			}
+			revs = append(revs, rev)
+		}
+	}
+	return revs, nil
+}
+
 // --- Memory ---
 
-func (s *DynamoStorage) StoreMemory(ctx context.Context, chunk *models.MemoryChunk) error {
-	item, err := attributevalue.MarshalMap(chunk)
+// Memory items live in the user's partition, sorted by creation time so
+// listings read in order. Each chunk also has a key item, sk
+// "chunk#<chunk_id>", holding the sort key of its memory item, so a chunk
+// is found by ID with GetItem instead of a walk of the partition.
+
+// memorySK is the sort key of a chunk's memory item. The fixed-width UTC
+// time keeps lexical and chronological order the same; the chunk ID keeps
+// chunks created at the same instant apart.
+func memorySK(chunk *models.MemoryChunk) string {
+	return "memory#" + chunk.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000Z") + "#" + chunk.ChunkID
+}
+
+// chunkKey is the primary key of a chunk's key item.
+func chunkKey(userID, chunkID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "user#" + userID},
+		"sk": &types.AttributeValueMemberS{Value: "chunk#" + chunkID},
+	}
+}
+
+// chunkKeyItem is the key item pointing at the memory item with sort key sk.
+func chunkKeyItem(userID, chunkID string, sk types.AttributeValue) map[string]types.AttributeValue {
+	item := chunkKey(userID, chunkID)
+	item["chunk_id"] = &types.AttributeValueMemberS{Value: chunkID}
+	item["memory_sk"] = sk
+	return item
+}
+
+// memoryItem marshals a chunk together with its keys.
+func memoryItem(chunk *models.MemoryChunk) (map[string]types.AttributeValue, error) {
+	item, err := marshalItem(chunk)
 	if err != nil {
-		return fmt.Errorf("dynamo marshal memory: %w", err)
+		return nil, fmt.Errorf("dynamo marshal memory: %w", err)
 	}
 	item["pk"] = &types.AttributeValueMemberS{Value: "user#" + chunk.UserID}
-	item["sk"] = &types.AttributeValueMemberS{Value: "memory#" + chunk.CreatedAt.Format(time.RFC3339Nano)}
+	item["sk"] = &types.AttributeValueMemberS{Value: memorySK(chunk)}
+	// created_at is an RFC3339 string, which does not compare correctly across
+	// time zones or fractional-second widths; range filters use this instead.
+	item["created_ts"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(chunk.CreatedAt.UnixNano(), 10)}
+	return item, nil
+}
+
+func (s *DynamoStorage) StoreMemory(ctx context.Context, chunk *models.MemoryChunk) error {
+	item, err := memoryItem(chunk)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{TableName: aws.String(memoryTable), Item: item}},
+			{Put: &types.Put{TableName: aws.String(memoryTable), Item: chunkKeyItem(chunk.UserID, chunk.ChunkID, item["sk"])}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo store memory: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStorage) GetMemory(ctx context.Context, userID, chunkID string) (*models.MemoryChunk, error) {
+	keys, err := s.memoryKeys(ctx, userID, []string{chunkID})
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keys[chunkID]
+	if !ok {
+		return nil, nil // not found
+	}
+
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(memoryTable),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamo get memory: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+	var chunk models.MemoryChunk
+	if err := unmarshalItem(out.Item, &chunk); err != nil {
+		return nil, fmt.Errorf("dynamo unmarshal memory: %w", err)
+	}
+	return &chunk, nil
+}
+
+func (s *DynamoStorage) UpdateMemory(ctx context.Context, chunk *models.MemoryChunk) error {
+	keys, err := s.memoryKeys(ctx, chunk.UserID, []string{chunk.ChunkID})
+	if err != nil {
+		return err
+	}
+	key, ok := keys[chunk.ChunkID]
+	if !ok {
+		return nil // matches Mongo: updating a missing chunk is a no-op
+	}
+
+	item, err := memoryItem(chunk)
+	if err != nil {
+		return err
+	}
+	// Keep the original keys even if CreatedAt was altered
+	item["pk"], item["sk"] = key["pk"], key["sk"]
 
 	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: aws.String(memoryTable),
 		Item:      item,
 	})
 	if err != nil {
-		return fmt.Errorf("dynamo store memory: %w", err)
+		return fmt.Errorf("dynamo update memory: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStorage) DeleteMemory(ctx context.Context, userID, chunkID string) error {
+	keys, err := s.memoryKeys(ctx, userID, []string{chunkID})
+	if err != nil {
+		return err
+	}
+	key, ok := keys[chunkID]
+	if !ok {
+		return nil
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Delete: &types.Delete{TableName: aws.String(memoryTable), Key: key}},
+			{Delete: &types.Delete{TableName: aws.String(memoryTable), Key: chunkKey(userID, chunkID)}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo delete memory: %w", err)
 	}
 	return nil
 }
 
-func (s *DynamoStorage) SearchMemoryByTokens(ctx context.Context, userID, replicaID string, tokens []string) ([]models.MemoryChunk, error) {
-	// First, look up chunk IDs from the token index
+func (s *DynamoStorage) SearchMemoryByTokens(ctx context.Context, userID, replicaID string, tokens []string, filter models.MemoryFilter) ([]models.MemoryChunk, error) {
+	// Look up chunk IDs from the token index, then fetch just those chunks
 	chunkIDs, err := s.LookupTokens(ctx, userID, replicaID, tokens)
 	if err != nil {
 		return nil, err
@@ -121,47 +412,412 @@ func (s *DynamoStorage) SearchMemoryByTokens(ctx context.Context, userID, replic
 	if len(chunkIDs) == 0 {
 		return nil, nil
 	}
+	keys, err := s.memoryKeys(ctx, userID, chunkIDs)
+	if err != nil {
+		return nil, err
+	}
 
-	// Batch get the chunks — for simplicity, query user's memories and filter
-	out, err := s.client.Query(ctx, &dynamodb.QueryInput{
-		TableName:              aws.String(memoryTable),
-		KeyConditionExpression: aws.String("pk = :pk"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":pk": &types.AttributeValueMemberS{Value: "user#" + userID},
-		},
+	memKeys := make([]map[string]types.AttributeValue, 0, len(keys))
+	for _, key := range keys {
+		memKeys = append(memKeys, key)
+	}
+	var chunks []models.MemoryChunk
+	err = s.batchGet(ctx, memoryTable, memKeys, func(item map[string]types.AttributeValue) {
+		var chunk models.MemoryChunk
+		if err := unmarshalItem(item, &chunk); err != nil {
+			return
+		}
+		if replicaID != "" && chunk.ReplicaID != replicaID {
+			return
+		}
+		if filter.Matches(chunk) {
+			chunks = append(chunks, chunk)
+		}
 	})
 	if err != nil {
-		return nil, fmt.Errorf("dynamo query memory: %w", err)
+		return nil, fmt.Errorf("dynamo search memory: %w", err)
 	}
+	return chunks, nil
+}
+
+// dynamoPosition is the ListMemory cursor: the sort key of the last chunk
+// returned, used as ExclusiveStartKey for the next page.
+type dynamoPosition struct {
+	SK string `json:"sk"`
+}
 
-	chunkSet := make(map[string]bool, len(chunkIDs))
-	for _, id := range chunkIDs {
-		chunkSet[id] = true
+func (s *DynamoStorage) ListMemory(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, page models.Page) ([]models.MemoryChunk, string, error) {
+	input := memoryQuery(userID, replicaID, filter)
+	if page.Cursor != "" {
+		var pos dynamoPosition
+		if err := DecodeCursor(page.Cursor, &pos); err != nil {
+			return nil, "", err
+		}
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: "user#" + userID},
+			"sk": &types.AttributeValueMemberS{Value: pos.SK},
+		}
+	}
+	if page.Limit > 0 {
+		// Limit caps items evaluated per request, before the filter runs, so
+		// keep following LastEvaluatedKey until the page (+1) is full.
+		input.Limit = aws.Int32(int32(page.Limit) + 1)
 	}
 
 	var chunks []models.MemoryChunk
-	for _, item := range out.Items {
-		var chunk models.MemoryChunk
-		if err := attributevalue.UnmarshalMap(item, &chunk); err != nil {
-			continue
+	var sortKeys []string
+	for {
+		out, err := s.client.Query(ctx, input)
+		if err != nil {
+			return nil, "", fmt.Errorf("dynamo list memory: %w", err)
+		}
+		for _, item := range out.Items {
+			var chunk models.MemoryChunk
+			if err := unmarshalItem(item, &chunk); err != nil {
+				continue
+			}
+			sk, _ := item["sk"].(*types.AttributeValueMemberS)
+			if sk == nil {
+				continue
+			}
+			chunks = append(chunks, chunk)
+			sortKeys = append(sortKeys, sk.Value)
+		}
+		if out.LastEvaluatedKey == nil || (page.Limit > 0 && len(chunks) > page.Limit) {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+
+	if page.Limit <= 0 || len(chunks) <= page.Limit {
+		return chunks, "", nil
+	}
+	chunks = chunks[:page.Limit]
+	return chunks, EncodeCursor(dynamoPosition{SK: sortKeys[page.Limit-1]}), nil
+}
+
+func (s *DynamoStorage) RecentMemory(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, limit int) ([]models.MemoryChunk, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	// Sort keys are creation times, so reading the partition backwards
+	// yields the newest chunks first.
+	input := memoryQuery(userID, replicaID, filter)
+	input.ScanIndexForward = aws.Bool(false)
+	input.Limit = aws.Int32(int32(limit))
+
+	var chunks []models.MemoryChunk
+	for len(chunks) < limit {
+		out, err := s.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("dynamo recent memory: %w", err)
 		}
-		if chunkSet[chunk.ChunkID] {
+		for _, item := range out.Items {
+			var chunk models.MemoryChunk
+			if err := unmarshalItem(item, &chunk); err != nil {
+				continue
+			}
 			chunks = append(chunks, chunk)
 		}
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	if len(chunks) > limit {
+		chunks = chunks[:limit]
 	}
 	return chunks, nil
 }
 
+// memoryQuery builds a query over a user's memory partition with the replica
+// scope and structured filters rendered as a FilterExpression.
+func memoryQuery(userID, replicaID string, f models.MemoryFilter) *dynamodb.QueryInput {
+	var conds []string
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{
+		":pk":  &types.AttributeValueMemberS{Value: "user#" + userID},
+		":mem": &types.AttributeValueMemberS{Value: "memory#"},
+	}
+
+	if replicaID != "" {
+		conds = append(conds, "replica_id = :rid")
+		values[":rid"] = &types.AttributeValueMemberS{Value: replicaID}
+	}
+	if len(f.Sources) > 0 {
+		// "source" is a DynamoDB reserved word
+		names["#src"] = "source"
+		conds = append(conds, "#src IN ("+placeholders("src", f.Sources, values)+")")
+	}
+	if f.SessionID != "" {
+		conds = append(conds, "session_id = :sid")
+		values[":sid"] = &types.AttributeValueMemberS{Value: f.SessionID}
+	}
+	if f.CreatedAfter != nil {
+		conds = append(conds, "created_ts >= :cafter")
+		values[":cafter"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(f.CreatedAfter.UnixNano(), 10)}
+	}
+	if f.CreatedBefore != nil {
+		conds = append(conds, "created_ts < :cbefore")
+		values[":cbefore"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(f.CreatedBefore.UnixNano(), 10)}
+	}
+	if f.MinImportance > 0 {
+		conds = append(conds, "importance >= :minimp")
+		values[":minimp"] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(f.MinImportance, 'f', -1, 64)}
+	}
+	// IN accepts at most 100 operands, so long exclusion lists are split
+	for start := 0; start < len(f.ExcludeChunkIDs); start += 100 {
+		end := min(start+100, len(f.ExcludeChunkIDs))
+		prefix := "ex" + strconv.Itoa(start/100) + "_"
+		conds = append(conds, "NOT (chunk_id IN ("+placeholders(prefix, f.ExcludeChunkIDs[start:end], values)+"))")
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(memoryTable),
+		KeyConditionExpression:    aws.String("pk = :pk AND begins_with(sk, :mem)"),
+		ExpressionAttributeValues: values,
+	}
+	if len(conds) > 0 {
+		input.FilterExpression = aws.String(strings.Join(conds, " AND "))
+	}
+	if len(names) > 0 {
+		input.ExpressionAttributeNames = names
+	}
+	return input
+}
+
+// placeholders registers one string value per element and returns the
+// comma-separated placeholder list.
+func placeholders(prefix string, vals []string, values map[string]types.AttributeValue) string {
+	ph := make([]string, len(vals))
+	for i, v := range vals {
+		ph[i] = ":" + prefix + strconv.Itoa(i)
+		values[ph[i]] = &types.AttributeValueMemberS{Value: v}
+	}
+	return strings.Join(ph, ", ")
+}
+
+// memoryKeys resolves chunk IDs to the primary keys of their memory items
+// through the chunks' key items. Unknown IDs are left out.
+func (s *DynamoStorage) memoryKeys(ctx context.Context, userID string, chunkIDs []string) (map[string]map[string]types.AttributeValue, error) {
+	ids := sortedUnique(chunkIDs)
+	lookup := make([]map[string]types.AttributeValue, len(ids))
+	for i, id := range ids {
+		lookup[i] = chunkKey(userID, id)
+	}
+
+	keys := make(map[string]map[string]types.AttributeValue, len(ids))
+	err := s.batchGet(ctx, memoryTable, lookup, func(item map[string]types.AttributeValue) {
+		id, ok := item["chunk_id"].(*types.AttributeValueMemberS)
+		if !ok || item["memory_sk"] == nil {
+			return
+		}
+		keys[id.Value] = map[string]types.AttributeValue{"pk": item["pk"], "sk": item["memory_sk"]}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamo resolve memory keys: %w", err)
+	}
+	return keys, nil
+}
+
+// batchGet reads items by primary key with consistent reads, in batches of
+// the 100 keys BatchGetItem accepts, and passes each to fn in no
+// particular order. Missing items are skipped.
+func (s *DynamoStorage) batchGet(ctx context.Context, table string, keys []map[string]types.AttributeValue, fn func(map[string]types.AttributeValue)) error {
+	const batchSize = 100
+	for start := 0; start < len(keys); start += batchSize {
+		end := min(start+batchSize, len(keys))
+		request := map[string]types.KeysAndAttributes{
+			table: {Keys: keys[start:end], ConsistentRead: aws.Bool(true)},
+		}
+		for len(request) > 0 {
+			batch, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+			if err != nil {
+				return err
+			}
+			for _, item := range batch.Responses[table] {
+				fn(item)
+			}
+			request = batch.UnprocessedKeys
+		}
+	}
+	return nil
+}
+
+// BackfillMemoryKeys writes the key item of every chunk stored before
+// chunks had one. It scans the whole memory table, so it is meant to be
+// run once after upgrading; rerunning it is harmless.
+func (s *DynamoStorage) BackfillMemoryKeys(ctx context.Context) (int, error) {
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:                 aws.String(memoryTable),
+		FilterExpression:          aws.String("begins_with(sk, :mem)"),
+		ProjectionExpression:      aws.String("pk, sk, user_id, chunk_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":mem": &types.AttributeValueMemberS{Value: "memory#"}},
+		Limit:                     s.pageLimit(),
+	})
+	written := 0
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return written, fmt.Errorf("dynamo scan memory: %w", err)
+		}
+		for _, item := range out.Items {
+			userID, okU := item["user_id"].(*types.AttributeValueMemberS)
+			chunkID, okC := item["chunk_id"].(*types.AttributeValueMemberS)
+			if !okU || !okC {
+				continue
+			}
+			_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName:           aws.String(memoryTable),
+				Item:                chunkKeyItem(userID.Value, chunkID.Value, item["sk"]),
+				ConditionExpression: aws.String("attribute_not_exists(pk)"),
+			})
+			var exists *types.ConditionalCheckFailedException
+			if errors.As(err, &exists) {
+				continue
+			}
+			if err != nil {
+				return written, fmt.Errorf("dynamo backfill memory key %q: %w", chunkID.Value, err)
+			}
+			written++
+		}
+	}
+	return written, nil
+}
+
+// MigrateAttributeNames rewrites items stored before attribute names followed
+// the json tags. Those items carry Go field names (UserID, Value, ...) and the
+// old sort keys, so the queries here cannot see them. Each one is decoded with
+// the default names and written back through the current layout; items whose
+// key changed are then deleted. It scans every table it touches, so it is
+// meant to be run once after upgrading; rerunning it is harmless.
+func (s *DynamoStorage) MigrateAttributeNames(ctx context.Context) (int, error) {
+	migrate := map[string]func(map[string]types.AttributeValue) error{
+		identityTable: func(item map[string]types.AttributeValue) error {
+			var fact models.IdentityFact
+			if err := attributevalue.UnmarshalMap(item, &fact); err != nil {
+				return err
+			}
+			return s.SetIdentity(ctx, &fact)
+		},
+		memoryTable: func(item map[string]types.AttributeValue) error {
+			var chunk models.MemoryChunk
+			if err := attributevalue.UnmarshalMap(item, &chunk); err != nil {
+				return err
+			}
+			return s.StoreMemory(ctx, &chunk)
+		},
+		tokenTable: func(item map[string]types.AttributeValue) error {
+			var entry models.TokenEntry
+			if err := attributevalue.UnmarshalMap(item, &entry); err != nil {
+				return err
+			}
+			return s.IndexTokens(ctx, []models.TokenEntry{entry})
+		},
+		reviewTable: func(item map[string]types.AttributeValue) error {
+			var review models.ReviewItem
+			if err := attributevalue.UnmarshalMap(item, &review); err != nil {
+				return err
+			}
+			// Not StoreReview, which would reset CreatedAt
+			av, err := marshalItem(&review)
+			if err != nil {
+				return err
+			}
+			av["pk"] = item["pk"]
+			_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName: aws.String(reviewTable),
+				Item:      av,
+			})
+			return err
+		},
+	}
+
+	migrated := 0
+	for _, table := range []string{identityTable, memoryTable, tokenTable, reviewTable} {
+		paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+			TableName:        aws.String(table),
+			FilterExpression: aws.String("attribute_exists(UserID)"),
+			Limit:            s.pageLimit(),
+		})
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return migrated, fmt.Errorf("dynamo scan %s: %w", table, err)
+			}
+			for _, item := range out.Items {
+				if err := migrate[table](item); err != nil {
+					return migrated, fmt.Errorf("dynamo migrate %s item: %w", table, err)
+				}
+				// The rewrite leaves an item at the same key without the old
+				// names; anything still carrying them sits at an old key.
+				if err := s.dropLegacyItem(ctx, table, item); err != nil {
+					return migrated, err
+				}
+				migrated++
+			}
+		}
+	}
+	return migrated, nil
+}
+
+// dropLegacyItem deletes a migrated item unless the rewrite replaced it.
+func (s *DynamoStorage) dropLegacyItem(ctx context.Context, table string, item map[string]types.AttributeValue) error {
+	key := map[string]types.AttributeValue{"pk": item["pk"]}
+	if sk, ok := item["sk"]; ok {
+		key["sk"] = sk
+	}
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(table),
+		Key:                 key,
+		ConditionExpression: aws.String("attribute_exists(UserID)"),
+	})
+	var replaced *types.ConditionalCheckFailedException
+	if errors.As(err, &replaced) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("dynamo delete legacy %s item: %w", table, err)
+	}
+	return nil
+}
+
+func (s *DynamoStorage) RecordAccess(ctx context.Context, userID string, chunkIDs []string, at time.Time) error {
+	if len(chunkIDs) == 0 {
+		return nil
+	}
+	keys, err := s.memoryKeys(ctx, userID, chunkIDs)
+	if err != nil {
+		return err
+	}
+	for id, key := range keys {
+		_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:        aws.String(memoryTable),
+			Key:              key,
+			UpdateExpression: aws.String("SET last_accessed_at = :at ADD access_count :one"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":at":  &types.AttributeValueMemberS{Value: at.Format(time.RFC3339Nano)},
+				":one": &types.AttributeValueMemberN{Value: "1"},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("dynamo record access %q: %w", id, err)
+		}
+	}
+	return nil
+}
+
 // --- Token Index ---
 
 func (s *DynamoStorage) IndexTokens(ctx context.Context, entries []models.TokenEntry) error {
 	for _, e := range entries {
-		item, err := attributevalue.MarshalMap(e)
+		item, err := marshalItem(e)
 		if err != nil {
 			return fmt.Errorf("dynamo marshal token: %w", err)
 		}
 		item["pk"] = &types.AttributeValueMemberS{Value: "token#" + e.Token}
-		item["sk"] = &types.AttributeValueMemberS{Value: "memory#" + e.Timestamp.Format(time.RFC3339Nano)}
+		// The chunk ID keeps entries written at the same instant apart
+		item["sk"] = &types.AttributeValueMemberS{Value: "memory#" + e.Timestamp.Format(time.RFC3339Nano) + "#" + e.ChunkID}
 
 		_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
 			TableName: aws.String(tokenTable),
@@ -175,41 +831,200 @@ func (s *DynamoStorage) IndexTokens(ctx context.Context, entries []models.TokenE
 }
 
 func (s *DynamoStorage) LookupTokens(ctx context.Context, userID, replicaID string, tokens []string) ([]string, error) {
+	filter := "user_id = :uid"
+	if replicaID != "" {
+		filter += " AND replica_id = :rid"
+	}
+
 	chunkSet := make(map[string]bool)
 	for _, t := range tokens {
-		out, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		values := map[string]types.AttributeValue{
+			":pk":  &types.AttributeValueMemberS{Value: "token#" + t},
+			":uid": &types.AttributeValueMemberS{Value: userID},
+		}
+		if replicaID != "" {
+			values[":rid"] = &types.AttributeValueMemberS{Value: replicaID}
+		}
+		// A popular token's entries span many 1 MB pages
+		paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+			TableName:                 aws.String(tokenTable),
+			KeyConditionExpression:    aws.String("pk = :pk"),
+			FilterExpression:          aws.String(filter),
+			ProjectionExpression:      aws.String("chunk_id"),
+			ExpressionAttributeValues: values,
+			Limit:                     s.pageLimit(),
+		})
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("dynamo lookup token %q: %w", t, err)
+			}
+			for _, item := range out.Items {
+				if id, ok := item["chunk_id"].(*types.AttributeValueMemberS); ok {
+					chunkSet[id.Value] = true
+				}
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(chunkSet))
+	for id := range chunkSet {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *DynamoStorage) RemoveTokens(ctx context.Context, userID, chunkID string, tokens []string) error {
+	for _, t := range tokens {
+		paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
 			TableName:              aws.String(tokenTable),
 			KeyConditionExpression: aws.String("pk = :pk"),
-			FilterExpression:       aws.String("user_id = :uid"),
+			FilterExpression:       aws.String("user_id = :uid AND chunk_id = :cid"),
+			ProjectionExpression:   aws.String("pk, sk"),
 			ExpressionAttributeValues: map[string]types.AttributeValue{
 				":pk":  &types.AttributeValueMemberS{Value: "token#" + t},
 				":uid": &types.AttributeValueMemberS{Value: userID},
+				":cid": &types.AttributeValueMemberS{Value: chunkID},
 			},
+			Limit: s.pageLimit(),
 		})
-		if err != nil {
-			return nil, fmt.Errorf("dynamo lookup token %q: %w", t, err)
-		}
-		for _, item := range out.Items {
-			var entry models.TokenEntry
-			if err := attributevalue.UnmarshalMap(item, &entry); err != nil {
-				continue
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("dynamo find token %q: %w", t, err)
+			}
+			for _, item := range out.Items {
+				_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+					TableName: aws.String(tokenTable),
+					Key:       map[string]types.AttributeValue{"pk": item["pk"], "sk": item["sk"]},
+				})
+				if err != nil {
+					return fmt.Errorf("dynamo remove token %q: %w", t, err)
+				}
 			}
-			chunkSet[entry.ChunkID] = true
 		}
 	}
+	return nil
+}
 
-	ids := make([]string, 0, len(chunkSet))
-	for id := range chunkSet {
-		ids = append(ids, id)
+// --- Corpus Stats ---
+
+// Corpus stats live in one partition per user/replica scope: a "totals" item
+// holding doc_count and total_length, plus one "token#<t>" item per token
+// holding doc_freq. Atomic ADD updates keep concurrent stores consistent.
+
+func statsPK(userID, replicaID string) string {
+	return "stats#" + userID + "#" + replicaID
+}
+
+func (s *DynamoStorage) UpdateCorpusStats(ctx context.Context, userID, replicaID string, tokens []string, length, delta int) error {
+	pk := &types.AttributeValueMemberS{Value: statsPK(userID, replicaID)}
+
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(statsTable),
+		Key: map[string]types.AttributeValue{
+			"pk": pk,
+			"sk": &types.AttributeValueMemberS{Value: "totals"},
+		},
+		UpdateExpression: aws.String("SET user_id = :uid, replica_id = :rid ADD doc_count :d, total_length :l"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid": &types.AttributeValueMemberS{Value: userID},
+			":rid": &types.AttributeValueMemberS{Value: replicaID},
+			":d":   &types.AttributeValueMemberN{Value: strconv.Itoa(delta)},
+			":l":   &types.AttributeValueMemberN{Value: strconv.Itoa(delta * length)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo update corpus totals: %w", err)
 	}
-	return ids, nil
+
+	for _, t := range tokens {
+		_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(statsTable),
+			Key: map[string]types.AttributeValue{
+				"pk": pk,
+				"sk": &types.AttributeValueMemberS{Value: "token#" + t},
+			},
+			UpdateExpression: aws.String("ADD doc_freq :d"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":d": &types.AttributeValueMemberN{Value: strconv.Itoa(delta)},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("dynamo update doc freq %q: %w", t, err)
+		}
+	}
+	return nil
+}
+
+func (s *DynamoStorage) GetCorpusStats(ctx context.Context, userID, replicaID string, tokens []string) (*models.CorpusStats, error) {
+	pk := statsPK(userID, replicaID)
+	st := &models.CorpusStats{UserID: userID, ReplicaID: replicaID, DocFreq: make(map[string]int, len(tokens))}
+
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(statsTable),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: pk},
+			"sk": &types.AttributeValueMemberS{Value: "totals"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamo get corpus totals: %w", err)
+	}
+	if out.Item == nil {
+		return st, nil
+	}
+	var totals struct {
+		DocCount    int `json:"doc_count"`
+		TotalLength int `json:"total_length"`
+	}
+	if err := unmarshalItem(out.Item, &totals); err != nil {
+		return nil, fmt.Errorf("dynamo unmarshal corpus totals: %w", err)
+	}
+	st.DocCount = totals.DocCount
+	st.TotalLength = totals.TotalLength
+
+	// BatchGetItem accepts at most 100 keys per call
+	const batchSize = 100
+	for start := 0; start < len(tokens); start += batchSize {
+		end := min(start+batchSize, len(tokens))
+		keys := make([]map[string]types.AttributeValue, 0, end-start)
+		for _, t := range tokens[start:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"pk": &types.AttributeValueMemberS{Value: pk},
+				"sk": &types.AttributeValueMemberS{Value: "token#" + t},
+			})
+		}
+
+		request := map[string]types.KeysAndAttributes{statsTable: {Keys: keys}}
+		for len(request) > 0 {
+			batch, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+			if err != nil {
+				return nil, fmt.Errorf("dynamo get doc freqs: %w", err)
+			}
+			for _, item := range batch.Responses[statsTable] {
+				var row struct {
+					SK      string `json:"sk"`
+					DocFreq int    `json:"doc_freq"`
+				}
+				if err := unmarshalItem(item, &row); err != nil {
+					continue
+				}
+				if row.DocFreq > 0 {
+					st.DocFreq[strings.TrimPrefix(row.SK, "token#")] = row.DocFreq
+				}
+			}
+			request = batch.UnprocessedKeys
+		}
+	}
+	return st, nil
 }
 
 // --- Review Queue ---
 
 func (s *DynamoStorage) StoreReview(ctx context.Context, item *models.ReviewItem) error {
 	item.CreatedAt = time.Now()
-	av, err := attributevalue.MarshalMap(item)
+	av, err := marshalItem(item)
 	if err != nil {
 		return fmt.Errorf("dynamo marshal review: %w", err)
 	}
@@ -240,7 +1055,7 @@ func (s *DynamoStorage) GetReview(ctx context.Context, sessionID string) (*model
 	}
 
 	var review models.ReviewItem
-	if err := attributevalue.UnmarshalMap(out.Item, &review); err != nil {
+	if err := unmarshalItem(out.Item, &review); err != nil {
 		return nil, fmt.Errorf("dynamo unmarshal review: %w", err)
 	}
 	return &review, nil
@@ -248,7 +1063,7 @@ func (s *DynamoStorage) GetReview(ctx context.Context, sessionID string) (*model
 
 func (s *DynamoStorage) ListPendingReviews(ctx context.Context, userID string) ([]models.ReviewItem, error) {
 	// Scan with filter — acceptable for review queue sizes
-	out, err := s.client.Scan(ctx, &dynamodb.ScanInput{
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
 		TableName:        aws.String(reviewTable),
 		FilterExpression: aws.String("user_id = :uid AND #s = :status"),
 		ExpressionAttributeNames: map[string]string{
@@ -258,24 +1073,34 @@ func (s *DynamoStorage) ListPendingReviews(ctx context.Context, userID string) (
 			":uid":    &types.AttributeValueMemberS{Value: userID},
 			":status": &types.AttributeValueMemberS{Value: string(models.ReviewPending)},
 		},
+		Limit: s.pageLimit(),
 	})
-	if err != nil {
-		return nil, fmt.Errorf("dynamo list pending reviews: %w", err)
-	}
 
 	var items []models.ReviewItem
-	for _, item := range out.Items {
-		var review models.ReviewItem
-		if err := attributevalue.UnmarshalMap(item, &review); err != nil {
-			continue
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("dynamo list pending reviews: %w", err)
+		}
+		for _, item := range out.Items {
+			var review models.ReviewItem
+			if err := unmarshalItem(item, &review); err != nil {
+				continue
+			}
+			items = append(items, review)
 		}
-		items = append(items, review)
 	}
+	// A scan returns items in hash order
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
 	return items, nil
 }
 
 func (s *DynamoStorage) UpdateReviewStatus(ctx context.Context, sessionID string, status models.ReviewStatus) error {
-	now := time.Now().Format(time.RFC3339)
+	// Same format the encoder uses for time.Time, so reviewed_at decodes
+	// identically whether written here or by StoreReview.
+	now := time.Now().Format(time.RFC3339Nano)
 	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: aws.String(reviewTable),
 		Key: map[string]types.AttributeValue{
@@ -289,13 +1114,332 @@ func (s *DynamoStorage) UpdateReviewStatus(ctx context.Context, sessionID string
 			":status": &types.AttributeValueMemberS{Value: string(status)},
 			":rat":    &types.AttributeValueMemberS{Value: now},
 		},
+		ConditionExpression: aws.String("attribute_exists(pk)"),
+	})
+	var missing *types.ConditionalCheckFailedException
+	if errors.As(err, &missing) {
+		return nil // matches Mongo: updating a missing review is a no-op
+	}
+	if err != nil {
+		return fmt.Errorf("dynamo update review: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStorage) UpdateReview(ctx context.Context, item *models.ReviewItem) error {
+	av, err := marshalItem(item)
+	if err != nil {
+		return fmt.Errorf("dynamo marshal review: %w", err)
+	}
+	av["pk"] = &types.AttributeValueMemberS{Value: "review#" + item.SessionID}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(reviewTable),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_exists(pk)"),
 	})
+	var missing *types.ConditionalCheckFailedException
+	if errors.As(err, &missing) {
+		return nil // matches Mongo: updating a missing review is a no-op
+	}
 	if err != nil {
 		return fmt.Errorf("dynamo update review: %w", err)
 	}
 	return nil
 }
 
+// --- Documents ---
+
+func (s *DynamoStorage) StoreDocument(ctx context.Context, doc *models.Document) error {
+	item, err := marshalItem(doc)
+	if err != nil {
+		return fmt.Errorf("dynamo marshal document: %w", err)
+	}
+	item["pk"] = &types.AttributeValueMemberS{Value: "document#" + doc.DocumentID}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(documentTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo store document: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStorage) GetDocument(ctx context.Context, documentID string) (*models.Document, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(documentTable),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: "document#" + documentID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamo get document: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var doc models.Document
+	if err := unmarshalItem(out.Item, &doc); err != nil {
+		return nil, fmt.Errorf("dynamo unmarshal document: %w", err)
+	}
+	return &doc, nil
+}
+
+func (s *DynamoStorage) DeleteDocument(ctx context.Context, documentID string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(documentTable),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: "document#" + documentID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo delete document: %w", err)
+	}
+	return nil
+}
+
+// --- Job Queue ---
+
+// Jobs carry due_ts only while queued (RunAt) or running (LeaseUntil), so
+// the jobDueIndex GSI (partition status, sort due_ts) holds just the jobs a
+// worker may claim. Finished jobs leave the index and get an expires_at for
+// the table's TTL to delete them once jobRetention has passed.
+const (
+	jobDueIndex  = "status-due_ts"
+	jobRetention = 30 * 24 * time.Hour
+)
+
+// jobItem marshals a job with its key and the numeric timestamps ClaimJob
+// queries on (the RFC3339 strings don't compare reliably, as for memory).
+func jobItem(job *models.Job) (map[string]types.AttributeValue, error) {
+	item, err := marshalItem(job)
+	if err != nil {
+		return nil, fmt.Errorf("dynamo marshal job: %w", err)
+	}
+	item["pk"] = &types.AttributeValueMemberS{Value: "job#" + job.JobID}
+	switch job.Status {
+	case models.JobQueued:
+		item["due_ts"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(job.RunAt.UnixNano(), 10)}
+	case models.JobRunning:
+		item["due_ts"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(job.LeaseUntil.UnixNano(), 10)}
+	default:
+		finished := job.UpdatedAt
+		if job.FinishedAt != nil {
+			finished = *job.FinishedAt
+		}
+		// DynamoDB TTL wants epoch seconds
+		item["expires_at"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(finished.Add(jobRetention).Unix(), 10)}
+	}
+	return item, nil
+}
+
+func (s *DynamoStorage) CreateJob(ctx context.Context, job *models.Job) error {
+	item, err := jobItem(job)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(jobTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo create job: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStorage) GetJob(ctx context.Context, jobID string) (*models.Job, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(jobTable),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: "job#" + jobID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamo get job: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var job models.Job
+	if err := unmarshalItem(out.Item, &job); err != nil {
+		return nil, fmt.Errorf("dynamo unmarshal job: %w", err)
+	}
+	return &job, nil
+}
+
+// ClaimJob queries jobDueIndex for due jobs and claims the earliest with a
+// conditional write on status and attempts, moving on to the next candidate
+// if another worker got there first. The index is eventually consistent, so a
+// candidate may already be claimed or finished; the condition catches that.
+func (s *DynamoStorage) ClaimJob(ctx context.Context, now time.Time, lease time.Duration) (*models.Job, error) {
+	nowTS := &types.AttributeValueMemberN{Value: strconv.FormatInt(now.UnixNano(), 10)}
+	var due []models.Job
+	for _, q := range []struct {
+		status models.JobStatus
+		cond   string
+	}{
+		{models.JobQueued, "#s = :status AND due_ts <= :now"},
+		{models.JobRunning, "#s = :status AND due_ts < :now"},
+	} {
+		paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+			TableName:              aws.String(jobTable),
+			IndexName:              aws.String(jobDueIndex),
+			KeyConditionExpression: aws.String(q.cond),
+			ExpressionAttributeNames: map[string]string{
+				"#s": "status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":status": &types.AttributeValueMemberS{Value: string(q.status)},
+				":now":    nowTS,
+			},
+			Limit: s.pageLimit(),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("dynamo query %s jobs: %w", q.status, err)
+			}
+			for _, item := range page.Items {
+				var job models.Job
+				if err := unmarshalItem(item, &job); err != nil {
+					return nil, fmt.Errorf("dynamo unmarshal job: %w", err)
+				}
+				due = append(due, job)
+			}
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+
+	for _, job := range due {
+		status, attempts := job.Status, job.Attempts
+		claimJob(&job, now, lease)
+		item, err := jobItem(&job)
+		if err != nil {
+			return nil, err
+		}
+		_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:           aws.String(jobTable),
+			Item:                item,
+			ConditionExpression: aws.String("#s = :status AND attempts = :attempts"),
+			ExpressionAttributeNames: map[string]string{
+				"#s": "status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":status":   &types.AttributeValueMemberS{Value: string(status)},
+				":attempts": &types.AttributeValueMemberN{Value: strconv.Itoa(attempts)},
+			},
+		})
+		var taken *types.ConditionalCheckFailedException
+		if errors.As(err, &taken) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dynamo claim job: %w", err)
+		}
+		return &job, nil
+	}
+	return nil, nil
+}
+
+func (s *DynamoStorage) UpdateJob(ctx context.Context, job *models.Job) error {
+	item, err := jobItem(job)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(jobTable),
+		Item:                item,
+		ConditionExpression: aws.String("attempts = :attempts"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":attempts": &types.AttributeValueMemberN{Value: strconv.Itoa(job.Attempts)},
+		},
+	})
+	var lost *types.ConditionalCheckFailedException
+	if errors.As(err, &lost) {
+		return ErrJobLost
+	}
+	if err != nil {
+		return fmt.Errorf("dynamo update job: %w", err)
+	}
+	return nil
+}
+
+// --- Idempotency Keys ---
+
+func idemKey(userID, key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "user#" + userID},
+		"sk": &types.AttributeValueMemberS{Value: "idem#" + key},
+	}
+}
+
+func (s *DynamoStorage) ReserveIdempotencyKey(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	item, err := marshalItem(rec)
+	if err != nil {
+		return nil, fmt.Errorf("dynamo marshal idempotency key: %w", err)
+	}
+	for k, v := range idemKey(rec.UserID, rec.Key) {
+		item[k] = v
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                           aws.String(idemTable),
+		Item:                                item,
+		ConditionExpression:                 aws.String("attribute_not_exists(pk)"),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	var taken *types.ConditionalCheckFailedException
+	if errors.As(err, &taken) {
+		var existing models.IdempotencyRecord
+		if err := unmarshalItem(taken.Item, &existing); err != nil {
+			return nil, fmt.Errorf("dynamo unmarshal idempotency key: %w", err)
+		}
+		return &existing, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dynamo reserve idempotency key: %w", err)
+	}
+	return nil, nil
+}
+
+func (s *DynamoStorage) CompleteIdempotencyKey(ctx context.Context, userID, key, resultID string) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(idemTable),
+		Key:                 idemKey(userID, key),
+		UpdateExpression:    aws.String("SET result_id = :rid"),
+		ConditionExpression: aws.String("attribute_exists(pk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":rid": &types.AttributeValueMemberS{Value: resultID},
+		},
+	})
+	var missing *types.ConditionalCheckFailedException
+	if errors.As(err, &missing) {
+		return nil // matches Mongo: completing a released key is a no-op
+	}
+	if err != nil {
+		return fmt.Errorf("dynamo complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStorage) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(idemTable),
+		Key:       idemKey(userID, key),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo release idempotency key: %w", err)
+	}
+	return nil
+}
+
 // --- Health & Lifecycle ---
 
 func (s *DynamoStorage) Ping(ctx context.Context) error {
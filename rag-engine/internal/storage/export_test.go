@@ -0,0 +1,6 @@
+package storage
+
+// SetDynamoPageSize caps the items s reads per query page.
+func SetDynamoPageSize(s *DynamoStorage, n int32) {
+	s.pageSize = n
+}
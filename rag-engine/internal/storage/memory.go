@@ -0,0 +1,617 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+)
+
+// MemoryStorage implements Storage entirely in process memory.
+// Nothing is persisted; it is intended for tests and local development.
+type MemoryStorage struct {
+	mu         sync.RWMutex
+	identities map[string]models.IdentityFact       // key: userID + "\x00" + key
+	history    map[string][]models.IdentityRevision // key: userID + "\x00" + key, by version
+	chunks     []models.MemoryChunk                 // insertion order
+	tokens     []models.TokenEntry
+	reviews    map[string]models.ReviewItem        // key: sessionID
+	stats      map[string]*models.CorpusStats      // key: userID + "\x00" + replicaID
+	documents  map[string]models.Document          // key: documentID
+	jobs       map[string]models.Job               // key: jobID
+	idem       map[string]models.IdempotencyRecord // key: userID + "\x00" + key
+}
+
+// NewMemoryStorage returns an empty in-memory storage backend.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		identities: make(map[string]models.IdentityFact),
+		history:    make(map[string][]models.IdentityRevision),
+		reviews:    make(map[string]models.ReviewItem),
+		stats:      make(map[string]*models.CorpusStats),
+		documents:  make(map[string]models.Document),
+		jobs:       make(map[string]models.Job),
+		idem:       make(map[string]models.IdempotencyRecord),
+	}
+}
+
+// scopeKey joins two IDs into a single map key.
+func scopeKey(userID, key string) string {
+	return userID + "\x00" + key
+}
+
+// --- Identity ---
+
+func (s *MemoryStorage) GetIdentity(ctx context.Context, userID, key string) (*models.IdentityFact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fact, ok := s.identities[scopeKey(userID, key)]
+	if !ok {
+		return nil, nil // not found
+	}
+	return &fact, nil
+}
+
+func (s *MemoryStorage) SetIdentity(ctx context.Context, fact *models.IdentityFact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.identities[scopeKey(fact.UserID, fact.Key)] = *fact
+	return nil
+}
+
+func (s *MemoryStorage) DeleteIdentity(ctx context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.identities, scopeKey(userID, key))
+	return nil
+}
+
+func (s *MemoryStorage) ListIdentity(ctx context.Context, userID string) ([]models.IdentityFact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var facts []models.IdentityFact
+	for _, f := range s.identities {
+		if f.UserID == userID {
+			facts = append(facts, f)
+		}
+	}
+	sort.Slice(facts, func(i, j int) bool { return facts[i].Key < facts[j].Key })
+	return facts, nil
+}
+
+func (s *MemoryStorage) GetIdentities(ctx context.Context, userID string, keys []string) ([]models.IdentityFact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var facts []models.IdentityFact
+	for _, k := range sortedUnique(keys) {
+		if f, ok := s.identities[scopeKey(userID, k)]; ok {
+			facts = append(facts, f)
+		}
+	}
+	return facts, nil
+}
+
+func (s *MemoryStorage) AppendIdentityRevision(ctx context.Context, rev *models.IdentityRevision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := scopeKey(rev.UserID, rev.Key)
+	revs := s.history[k]
+	for _, r := range revs {
+		if r.Version == rev.Version {
+			return fmt.Errorf("append identity revision: duplicate version %d of %q", rev.Version, rev.Key)
+		}
+	}
+	revs = append(revs, *rev)
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Version < revs[j].Version })
+	s.history[k] = revs
+	return nil
+}
+
+func (s *MemoryStorage) ListIdentityRevisions(ctx context.Context, userID, key string) ([]models.IdentityRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.IdentityRevision(nil), s.history[scopeKey(userID, key)]...), nil
+}
+
+// --- Memory ---
+
+func (s *MemoryStorage) StoreMemory(ctx context.Context, chunk *models.MemoryChunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Mirror the unique (user_id, chunk_id) index of the Mongo backend
+	for _, c := range s.chunks {
+		if c.UserID == chunk.UserID && c.ChunkID == chunk.ChunkID {
+			return fmt.Errorf("store memory: duplicate chunk %q", chunk.ChunkID)
+		}
+	}
+
+	s.chunks = append(s.chunks, copyChunk(*chunk))
+	return nil
+}
+
+func (s *MemoryStorage) GetMemory(ctx context.Context, userID, chunkID string) (*models.MemoryChunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, c := range s.chunks {
+		if c.UserID == userID && c.ChunkID == chunkID {
+			c = copyChunk(c)
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStorage) UpdateMemory(ctx context.Context, chunk *models.MemoryChunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range s.chunks {
+		if c.UserID == chunk.UserID && c.ChunkID == chunk.ChunkID {
+			s.chunks[i] = copyChunk(*chunk)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStorage) DeleteMemory(ctx context.Context, userID, chunkID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chunks = slices.DeleteFunc(s.chunks, func(c models.MemoryChunk) bool {
+		return c.UserID == userID && c.ChunkID == chunkID
+	})
+	return nil
+}
+
+func (s *MemoryStorage) SearchMemoryByTokens(ctx context.Context, userID, replicaID string, tokens []string, filter models.MemoryFilter) ([]models.MemoryChunk, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	want := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		want[t] = true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var chunks []models.MemoryChunk
+	for _, c := range s.chunks {
+		if c.UserID != userID {
+			continue
+		}
+		if replicaID != "" && c.ReplicaID != replicaID {
+			continue
+		}
+		if !filter.Matches(c) {
+			continue
+		}
+		for _, t := range c.Tokens {
+			if want[t] {
+				chunks = append(chunks, copyChunk(c))
+				break
+			}
+		}
+	}
+	return chunks, nil
+}
+
+func (s *MemoryStorage) ListMemory(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, page models.Page) ([]models.MemoryChunk, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var chunks []models.MemoryChunk
+	for _, c := range s.chunks {
+		if c.UserID != userID {
+			continue
+		}
+		if replicaID != "" && c.ReplicaID != replicaID {
+			continue
+		}
+		if !filter.Matches(c) {
+			continue
+		}
+		chunks = append(chunks, copyChunk(c))
+	}
+	return pageChunks(chunks, page)
+}
+
+func (s *MemoryStorage) RecentMemory(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, limit int) ([]models.MemoryChunk, error) {
+	chunks, _, err := s.ListMemory(ctx, userID, replicaID, filter, models.Page{})
+	if err != nil {
+		return nil, err
+	}
+	return newestChunks(chunks, limit), nil
+}
+
+func (s *MemoryStorage) RecordAccess(ctx context.Context, userID string, chunkIDs []string, at time.Time) error {
+	want := make(map[string]bool, len(chunkIDs))
+	for _, id := range chunkIDs {
+		want[id] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.chunks {
+		c := &s.chunks[i]
+		if c.UserID == userID && want[c.ChunkID] {
+			t := at
+			c.AccessCount++
+			c.LastAccessedAt = &t
+		}
+	}
+	return nil
+}
+
+// copyChunk returns a chunk whose slices do not alias the stored copy.
+func copyChunk(c models.MemoryChunk) models.MemoryChunk {
+	c.Tokens = append([]string(nil), c.Tokens...)
+	if c.TermFreq != nil {
+		tf := make(map[string]int, len(c.TermFreq))
+		for k, v := range c.TermFreq {
+			tf[k] = v
+		}
+		c.TermFreq = tf
+	}
+	c.Embedding = append([]float32(nil), c.Embedding...)
+	if c.LastAccessedAt != nil {
+		t := *c.LastAccessedAt
+		c.LastAccessedAt = &t
+	}
+	return c
+}
+
+// --- Token Index ---
+
+func (s *MemoryStorage) IndexTokens(ctx context.Context, entries []models.TokenEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = append(s.tokens, entries...)
+	return nil
+}
+
+func (s *MemoryStorage) LookupTokens(ctx context.Context, userID, replicaID string, tokens []string) ([]string, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	want := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		want[t] = true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	chunkSet := make(map[string]bool)
+	for _, e := range s.tokens {
+		if e.UserID != userID || !want[e.Token] {
+			continue
+		}
+		if replicaID != "" && e.ReplicaID != replicaID {
+			continue
+		}
+		chunkSet[e.ChunkID] = true
+	}
+
+	ids := make([]string, 0, len(chunkSet))
+	for id := range chunkSet {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *MemoryStorage) RemoveTokens(ctx context.Context, userID, chunkID string, tokens []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = slices.DeleteFunc(s.tokens, func(e models.TokenEntry) bool {
+		return e.UserID == userID && e.ChunkID == chunkID
+	})
+	return nil
+}
+
+// --- Corpus Stats ---
+
+func (s *MemoryStorage) UpdateCorpusStats(ctx context.Context, userID, replicaID string, tokens []string, length, delta int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := scopeKey(userID, replicaID)
+	st, ok := s.stats[k]
+	if !ok {
+		st = &models.CorpusStats{UserID: userID, ReplicaID: replicaID, DocFreq: make(map[string]int)}
+		s.stats[k] = st
+	}
+	st.DocCount += delta
+	st.TotalLength += delta * length
+	for _, t := range tokens {
+		st.DocFreq[t] += delta
+		if st.DocFreq[t] <= 0 {
+			delete(st.DocFreq, t)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStorage) GetCorpusStats(ctx context.Context, userID, replicaID string, tokens []string) (*models.CorpusStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := &models.CorpusStats{UserID: userID, ReplicaID: replicaID, DocFreq: make(map[string]int, len(tokens))}
+	st, ok := s.stats[scopeKey(userID, replicaID)]
+	if !ok {
+		return out, nil
+	}
+	out.DocCount = st.DocCount
+	out.TotalLength = st.TotalLength
+	for _, t := range tokens {
+		if df := st.DocFreq[t]; df != 0 {
+			out.DocFreq[t] = df
+		}
+	}
+	return out, nil
+}
+
+// --- Review Queue ---
+
+func (s *MemoryStorage) StoreReview(ctx context.Context, item *models.ReviewItem) error {
+	item.CreatedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.reviews[item.SessionID]; exists {
+		return fmt.Errorf("store review: duplicate session %q", item.SessionID)
+	}
+	s.reviews[item.SessionID] = copyReview(*item)
+	return nil
+}
+
+func (s *MemoryStorage) GetReview(ctx context.Context, sessionID string) (*models.ReviewItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.reviews[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	item = copyReview(item)
+	return &item, nil
+}
+
+func (s *MemoryStorage) ListPendingReviews(ctx context.Context, userID string) ([]models.ReviewItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.ReviewItem
+	for _, item := range s.reviews {
+		if item.UserID == userID && item.Status == models.ReviewPending {
+			items = append(items, copyReview(item))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+func (s *MemoryStorage) UpdateReviewStatus(ctx context.Context, sessionID string, status models.ReviewStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.reviews[sessionID]
+	if !ok {
+		return nil // matches Mongo: updating a missing review is a no-op
+	}
+	now := time.Now()
+	item.Status = status
+	item.ReviewedAt = &now
+	s.reviews[sessionID] = item
+	return nil
+}
+
+func (s *MemoryStorage) UpdateReview(ctx context.Context, item *models.ReviewItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reviews[item.SessionID]; !ok {
+		return nil
+	}
+	s.reviews[item.SessionID] = copyReview(*item)
+	return nil
+}
+
+// copyReview returns a review whose slices and pointers do not alias the stored copy.
+func copyReview(item models.ReviewItem) models.ReviewItem {
+	item.ProposedIdentityUpdates = append([]models.IdentityProposal(nil), item.ProposedIdentityUpdates...)
+	item.ProposedMemories = append([]models.MemoryProposal(nil), item.ProposedMemories...)
+	item.Results = append([]models.ProposalResult(nil), item.Results...)
+	if item.ReviewedAt != nil {
+		t := *item.ReviewedAt
+		item.ReviewedAt = &t
+	}
+	return item
+}
+
+// --- Documents ---
+
+func (s *MemoryStorage) StoreDocument(ctx context.Context, doc *models.Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.documents[doc.DocumentID]; exists {
+		return fmt.Errorf("store document: duplicate document %q", doc.DocumentID)
+	}
+	s.documents[doc.DocumentID] = copyDocument(*doc)
+	return nil
+}
+
+func (s *MemoryStorage) GetDocument(ctx context.Context, documentID string) (*models.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc, ok := s.documents[documentID]
+	if !ok {
+		return nil, nil
+	}
+	doc = copyDocument(doc)
+	return &doc, nil
+}
+
+func (s *MemoryStorage) DeleteDocument(ctx context.Context, documentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.documents, documentID)
+	return nil
+}
+
+// copyDocument returns a document whose slices and maps do not alias the stored copy.
+func copyDocument(doc models.Document) models.Document {
+	doc.People = slices.Clone(doc.People)
+	doc.Tags = slices.Clone(doc.Tags)
+	doc.ChunkIDs = slices.Clone(doc.ChunkIDs)
+	if doc.Metadata != nil {
+		meta := make(map[string]string, len(doc.Metadata))
+		for k, v := range doc.Metadata {
+			meta[k] = v
+		}
+		doc.Metadata = meta
+	}
+	return doc
+}
+
+// --- Job Queue ---
+
+func (s *MemoryStorage) CreateJob(ctx context.Context, job *models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[job.JobID]; exists {
+		return fmt.Errorf("create job: duplicate job %q", job.JobID)
+	}
+	s.jobs[job.JobID] = copyJob(*job)
+	return nil
+}
+
+func (s *MemoryStorage) GetJob(ctx context.Context, jobID string) (*models.Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return nil, nil
+	}
+	job = copyJob(job)
+	return &job, nil
+}
+
+func (s *MemoryStorage) ClaimJob(ctx context.Context, now time.Time, lease time.Duration) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next *models.Job
+	for _, job := range s.jobs {
+		if jobDue(&job, now) && (next == nil || job.RunAt.Before(next.RunAt)) {
+			next = &job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	claimJob(next, now, lease)
+	s.jobs[next.JobID] = copyJob(*next)
+	return next, nil
+}
+
+func (s *MemoryStorage) UpdateJob(ctx context.Context, job *models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.jobs[job.JobID]
+	if !ok || stored.Attempts != job.Attempts {
+		return ErrJobLost
+	}
+	s.jobs[job.JobID] = copyJob(*job)
+	return nil
+}
+
+// copyJob returns a job whose slices and pointers do not alias the stored copy.
+func copyJob(job models.Job) models.Job {
+	job.Messages = append([]models.TranscriptLine(nil), job.Messages...)
+	if job.FinishedAt != nil {
+		t := *job.FinishedAt
+		job.FinishedAt = &t
+	}
+	return job
+}
+
+// --- Idempotency Keys ---
+
+func (s *MemoryStorage) ReserveIdempotencyKey(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := scopeKey(rec.UserID, rec.Key)
+	if existing, ok := s.idem[k]; ok {
+		return &existing, nil
+	}
+	s.idem[k] = *rec
+	return nil, nil
+}
+
+func (s *MemoryStorage) CompleteIdempotencyKey(ctx context.Context, userID, key, resultID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := scopeKey(userID, key)
+	if rec, ok := s.idem[k]; ok {
+		rec.ResultID = resultID
+		s.idem[k] = rec
+	}
+	return nil
+}
+
+func (s *MemoryStorage) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.idem, scopeKey(userID, key))
+	return nil
+}
+
+// --- Health & Lifecycle ---
+
+func (s *MemoryStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStorage) BackendName() string {
+	return "memory"
+}
+
+func (s *MemoryStorage) Close(ctx context.Context) error {
+	return nil
+}
@@ -16,9 +16,14 @@ import (
 const (
 	dbName             = "sensay"
 	identityCollection = "identity_core"
+	historyCollection  = "identity_history"
 	memoryCollection   = "memory_chunks"
 	tokenCollection    = "token_index"
 	reviewCollection   = "review_queue"
+	statsCollection    = "corpus_stats"
+	documentCollection = "documents"
+	jobCollection      = "jobs"
+	idemCollection     = "idempotency_keys"
 )
 
 // MongoStorage implements Storage using MongoDB.
@@ -91,18 +96,40 @@ func (s *MongoStorage) ensureIndexes(ctx context.Context) error {
 		return err
 	}
 
-	// MemoryChunks: compound index on user_id + replica_id + chunk_id
+	// IdentityHistory: one revision per user_id + key + version (unique)
+	_, err = s.db.Collection(historyCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// MemoryChunks: compound index on user_id + replica_id + chunk_id,
+	// plus user_id + created_at + chunk_id for paginated listing
 	_, err = s.db.Collection(memoryCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "replica_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "chunk_id", Value: 1}}},
 		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "chunk_id", Value: 1}}, Options: options.Index().SetUnique(true)},
 	})
 	if err != nil {
 		return err
 	}
 
-	// TokenIndex: compound on user_id + replica_id + token
-	_, err = s.db.Collection(tokenCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "replica_id", Value: 1}, {Key: "token", Value: 1}},
+	// TokenIndex: compound on user_id + replica_id + token, plus
+	// user_id + chunk_id to drop a chunk's entries
+	_, err = s.db.Collection(tokenCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "replica_id", Value: 1}, {Key: "token", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "chunk_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// CorpusStats: one document per user_id + replica_id scope
+	_, err = s.db.Collection(statsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "replica_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
 	})
 	if err != nil {
 		return err
@@ -112,6 +139,33 @@ func (s *MongoStorage) ensureIndexes(ctx context.Context) error {
 	_, err = s.db.Collection(reviewCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}},
 	})
+	if err != nil {
+		return err
+	}
+
+	// Documents: unique document_id
+	_, err = s.db.Collection(documentCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "document_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Jobs: unique job_id, plus status + run_at for claiming
+	_, err = s.db.Collection(jobCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "job_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// IdempotencyKeys: one record per user_id + key (unique)
+	_, err = s.db.Collection(idemCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
 	return err
 }
 
@@ -127,6 +181,7 @@ func (s *MongoStorage) GetIdentity(ctx context.Context, userID, key string) (*mo
 		}
 		return nil, fmt.Errorf("get identity: %w", err)
 	}
+	fact.Value = plainValue(fact.Value)
 	return &fact, nil
 }
 
@@ -141,6 +196,113 @@ func (s *MongoStorage) SetIdentity(ctx context.Context, fact *models.IdentityFac
 	return nil
 }
 
+func (s *MongoStorage) DeleteIdentity(ctx context.Context, userID, key string) error {
+	filter := bson.M{"user_id": userID, "key": key}
+	_, err := s.db.Collection(identityCollection).DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("delete identity: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStorage) ListIdentity(ctx context.Context, userID string) ([]models.IdentityFact, error) {
+	return s.findIdentity(ctx, bson.M{"user_id": userID})
+}
+
+func (s *MongoStorage) GetIdentities(ctx context.Context, userID string, keys []string) ([]models.IdentityFact, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return s.findIdentity(ctx, bson.M{"user_id": userID, "key": bson.M{"$in": keys}})
+}
+
+func (s *MongoStorage) findIdentity(ctx context.Context, filter bson.M) ([]models.IdentityFact, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "key", Value: 1}})
+	cursor, err := s.db.Collection(identityCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list identity: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var facts []models.IdentityFact
+	if err := cursor.All(ctx, &facts); err != nil {
+		return nil, fmt.Errorf("decode identity: %w", err)
+	}
+	for i := range facts {
+		facts[i].Value = plainValue(facts[i].Value)
+	}
+	return facts, nil
+}
+
+func (s *MongoStorage) AppendIdentityRevision(ctx context.Context, rev *models.IdentityRevision) error {
+	_, err := s.db.Collection(historyCollection).InsertOne(ctx, rev)
+	if err != nil {
+		return fmt.Errorf("append identity revision: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStorage) ListIdentityRevisions(ctx context.Context, userID, key string) ([]models.IdentityRevision, error) {
+	filter := bson.M{"user_id": userID, "key": key}
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+	cursor, err := s.db.Collection(historyCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list identity revisions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var revs []models.IdentityRevision
+	if err := cursor.All(ctx, &revs); err != nil {
+		return nil, fmt.Errorf("decode identity revisions: %w", err)
+	}
+	for i := range revs {
+		revs[i].Value = plainValue(revs[i].Value)
+	}
+	return revs, nil
+}
+
+// plainValue converts the bson.A, bson.D and bson.M the driver decodes
+// "any" fields into back to the []any and map[string]any the other
+// backends return, so schema validation and value comparisons see the
+// same types whichever backend stored the value.
+func plainValue(v any) any {
+	switch t := v.(type) {
+	case bson.A:
+		return plainValue([]any(t))
+	case []any:
+		out := make([]any, len(t))
+		for i, item := range t {
+			out[i] = plainValue(item)
+		}
+		return out
+	case bson.D:
+		out := make(map[string]any, len(t))
+		for _, e := range t {
+			out[e.Key] = plainValue(e.Value)
+		}
+		return out
+	case bson.M:
+		return plainValue(map[string]any(t))
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, item := range t {
+			out[k] = plainValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// plainReview applies plainValue to the values of a review's proposals.
+func plainReview(item *models.ReviewItem) {
+	for i := range item.ProposedIdentityUpdates {
+		p := &item.ProposedIdentityUpdates[i]
+		p.Value = plainValue(p.Value)
+		p.EditedValue = plainValue(p.EditedValue)
+		p.ExistingValue = plainValue(p.ExistingValue)
+	}
+}
+
 // --- Memory ---
 
 func (s *MongoStorage) StoreMemory(ctx context.Context, chunk *models.MemoryChunk) error {
@@ -151,7 +313,38 @@ func (s *MongoStorage) StoreMemory(ctx context.Context, chunk *models.MemoryChun
 	return nil
 }
 
-func (s *MongoStorage) SearchMemoryByTokens(ctx context.Context, userID, replicaID string, tokens []string) ([]models.MemoryChunk, error) {
+func (s *MongoStorage) GetMemory(ctx context.Context, userID, chunkID string) (*models.MemoryChunk, error) {
+	var chunk models.MemoryChunk
+	filter := bson.M{"user_id": userID, "chunk_id": chunkID}
+	err := s.db.Collection(memoryCollection).FindOne(ctx, filter).Decode(&chunk)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // not found
+		}
+		return nil, fmt.Errorf("get memory: %w", err)
+	}
+	return &chunk, nil
+}
+
+func (s *MongoStorage) UpdateMemory(ctx context.Context, chunk *models.MemoryChunk) error {
+	filter := bson.M{"user_id": chunk.UserID, "chunk_id": chunk.ChunkID}
+	_, err := s.db.Collection(memoryCollection).ReplaceOne(ctx, filter, chunk)
+	if err != nil {
+		return fmt.Errorf("update memory: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStorage) DeleteMemory(ctx context.Context, userID, chunkID string) error {
+	filter := bson.M{"user_id": userID, "chunk_id": chunkID}
+	_, err := s.db.Collection(memoryCollection).DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("delete memory: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStorage) SearchMemoryByTokens(ctx context.Context, userID, replicaID string, tokens []string, memFilter models.MemoryFilter) ([]models.MemoryChunk, error) {
 	if len(tokens) == 0 {
 		return nil, nil
 	}
@@ -164,6 +357,7 @@ func (s *MongoStorage) SearchMemoryByTokens(ctx context.Context, userID, replica
 	if replicaID != "" {
 		filter["replica_id"] = replicaID
 	}
+	applyMemoryFilter(filter, memFilter)
 
 	cursor, err := s.db.Collection(memoryCollection).Find(ctx, filter)
 	if err != nil {
@@ -178,6 +372,117 @@ func (s *MongoStorage) SearchMemoryByTokens(ctx context.Context, userID, replica
 	return chunks, nil
 }
 
+func (s *MongoStorage) ListMemory(ctx context.Context, userID, replicaID string, memFilter models.MemoryFilter, page models.Page) ([]models.MemoryChunk, string, error) {
+	filter := bson.M{"user_id": userID}
+	if replicaID != "" {
+		filter["replica_id"] = replicaID
+	}
+	applyMemoryFilter(filter, memFilter)
+
+	// Keyset pagination on (created_at, chunk_id)
+	if page.Cursor != "" {
+		var pos chunkPosition
+		if err := DecodeCursor(page.Cursor, &pos); err != nil {
+			return nil, "", err
+		}
+		filter = bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+			bson.M{"created_at": bson.M{"$gt": pos.CreatedAt}},
+			bson.M{"created_at": pos.CreatedAt, "chunk_id": bson.M{"$gt": pos.ChunkID}},
+		}}}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "chunk_id", Value: 1}})
+	if page.Limit > 0 {
+		opts.SetLimit(int64(page.Limit) + 1) // one extra to detect a next page
+	}
+
+	cursor, err := s.db.Collection(memoryCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("list memory: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var chunks []models.MemoryChunk
+	if err := cursor.All(ctx, &chunks); err != nil {
+		return nil, "", fmt.Errorf("decode memory chunks: %w", err)
+	}
+
+	if page.Limit <= 0 || len(chunks) <= page.Limit {
+		return chunks, "", nil
+	}
+	chunks = chunks[:page.Limit]
+	last := chunks[len(chunks)-1]
+	return chunks, EncodeCursor(chunkPosition{CreatedAt: last.CreatedAt, ChunkID: last.ChunkID}), nil
+}
+
+func (s *MongoStorage) RecentMemory(ctx context.Context, userID, replicaID string, memFilter models.MemoryFilter, limit int) ([]models.MemoryChunk, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	filter := bson.M{"user_id": userID}
+	if replicaID != "" {
+		filter["replica_id"] = replicaID
+	}
+	applyMemoryFilter(filter, memFilter)
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "chunk_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := s.db.Collection(memoryCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("recent memory: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var chunks []models.MemoryChunk
+	if err := cursor.All(ctx, &chunks); err != nil {
+		return nil, fmt.Errorf("decode memory chunks: %w", err)
+	}
+	return chunks, nil
+}
+
+// applyMemoryFilter adds the structured search filters to a query document.
+func applyMemoryFilter(filter bson.M, f models.MemoryFilter) {
+	if len(f.Sources) > 0 {
+		filter["source"] = bson.M{"$in": f.Sources}
+	}
+	if f.SessionID != "" {
+		filter["session_id"] = f.SessionID
+	}
+	if f.CreatedAfter != nil || f.CreatedBefore != nil {
+		created := bson.M{}
+		if f.CreatedAfter != nil {
+			created["$gte"] = *f.CreatedAfter
+		}
+		if f.CreatedBefore != nil {
+			created["$lt"] = *f.CreatedBefore
+		}
+		filter["created_at"] = created
+	}
+	if f.MinImportance > 0 {
+		filter["importance"] = bson.M{"$gte": f.MinImportance}
+	}
+	if len(f.ExcludeChunkIDs) > 0 {
+		filter["chunk_id"] = bson.M{"$nin": f.ExcludeChunkIDs}
+	}
+}
+
+func (s *MongoStorage) RecordAccess(ctx context.Context, userID string, chunkIDs []string, at time.Time) error {
+	if len(chunkIDs) == 0 {
+		return nil
+	}
+	filter := bson.M{"user_id": userID, "chunk_id": bson.M{"$in": chunkIDs}}
+	update := bson.M{
+		"$inc": bson.M{"access_count": 1},
+		"$set": bson.M{"last_accessed_at": at},
+	}
+	_, err := s.db.Collection(memoryCollection).UpdateMany(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("record access: %w", err)
+	}
+	return nil
+}
+
 // --- Token Index ---
 
 func (s *MongoStorage) IndexTokens(ctx context.Context, entries []models.TokenEntry) error {
@@ -228,6 +533,57 @@ func (s *MongoStorage) LookupTokens(ctx context.Context, userID, replicaID strin
 	return ids, nil
 }
 
+func (s *MongoStorage) RemoveTokens(ctx context.Context, userID, chunkID string, tokens []string) error {
+	filter := bson.M{"user_id": userID, "chunk_id": chunkID}
+	_, err := s.db.Collection(tokenCollection).DeleteMany(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("remove tokens: %w", err)
+	}
+	return nil
+}
+
+// --- Corpus Stats ---
+
+func (s *MongoStorage) UpdateCorpusStats(ctx context.Context, userID, replicaID string, tokens []string, length, delta int) error {
+	inc := bson.M{
+		"doc_count":    delta,
+		"total_length": delta * length,
+	}
+	for _, t := range tokens {
+		inc["doc_freq."+t] = delta
+	}
+	filter := bson.M{"user_id": userID, "replica_id": replicaID}
+	update := bson.M{"$inc": inc}
+	opts := options.UpdateOne().SetUpsert(true)
+	_, err := s.db.Collection(statsCollection).UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return fmt.Errorf("update corpus stats: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStorage) GetCorpusStats(ctx context.Context, userID, replicaID string, tokens []string) (*models.CorpusStats, error) {
+	// Only project the document frequencies we were asked for
+	projection := bson.M{"user_id": 1, "replica_id": 1, "doc_count": 1, "total_length": 1}
+	for _, t := range tokens {
+		projection["doc_freq."+t] = 1
+	}
+
+	var st models.CorpusStats
+	filter := bson.M{"user_id": userID, "replica_id": replicaID}
+	opts := options.FindOne().SetProjection(projection)
+	err := s.db.Collection(statsCollection).FindOne(ctx, filter, opts).Decode(&st)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("get corpus stats: %w", err)
+	}
+	st.UserID = userID
+	st.ReplicaID = replicaID
+	if st.DocFreq == nil {
+		st.DocFreq = make(map[string]int)
+	}
+	return &st, nil
+}
+
 // --- Review Queue ---
 
 func (s *MongoStorage) StoreReview(ctx context.Context, item *models.ReviewItem) error {
@@ -249,12 +605,14 @@ func (s *MongoStorage) GetReview(ctx context.Context, sessionID string) (*models
 		}
 		return nil, fmt.Errorf("get review: %w", err)
 	}
+	plainReview(&item)
 	return &item, nil
 }
 
 func (s *MongoStorage) ListPendingReviews(ctx context.Context, userID string) ([]models.ReviewItem, error) {
 	filter := bson.M{"user_id": userID, "status": models.ReviewPending}
-	cursor, err := s.db.Collection(reviewCollection).Find(ctx, filter)
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := s.db.Collection(reviewCollection).Find(ctx, filter, opts)
 	if err != nil {
 		return nil, fmt.Errorf("list pending reviews: %w", err)
 	}
@@ -264,6 +622,9 @@ func (s *MongoStorage) ListPendingReviews(ctx context.Context, userID string) ([
 	if err := cursor.All(ctx, &items); err != nil {
 		return nil, fmt.Errorf("decode reviews: %w", err)
 	}
+	for i := range items {
+		plainReview(&items[i])
+	}
 	return items, nil
 }
 
@@ -278,6 +639,143 @@ func (s *MongoStorage) UpdateReviewStatus(ctx context.Context, sessionID string,
 	return nil
 }
 
+func (s *MongoStorage) UpdateReview(ctx context.Context, item *models.ReviewItem) error {
+	filter := bson.M{"session_id": item.SessionID}
+	_, err := s.db.Collection(reviewCollection).ReplaceOne(ctx, filter, item)
+	if err != nil {
+		return fmt.Errorf("update review: %w", err)
+	}
+	return nil
+}
+
+// --- Documents ---
+
+func (s *MongoStorage) StoreDocument(ctx context.Context, doc *models.Document) error {
+	_, err := s.db.Collection(documentCollection).InsertOne(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("store document: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStorage) GetDocument(ctx context.Context, documentID string) (*models.Document, error) {
+	var doc models.Document
+	err := s.db.Collection(documentCollection).FindOne(ctx, bson.M{"document_id": documentID}).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get document: %w", err)
+	}
+	return &doc, nil
+}
+
+func (s *MongoStorage) DeleteDocument(ctx context.Context, documentID string) error {
+	_, err := s.db.Collection(documentCollection).DeleteOne(ctx, bson.M{"document_id": documentID})
+	if err != nil {
+		return fmt.Errorf("delete document: %w", err)
+	}
+	return nil
+}
+
+// --- Job Queue ---
+
+func (s *MongoStorage) CreateJob(ctx context.Context, job *models.Job) error {
+	_, err := s.db.Collection(jobCollection).InsertOne(ctx, job)
+	if err != nil {
+		return fmt.Errorf("create job: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStorage) GetJob(ctx context.Context, jobID string) (*models.Job, error) {
+	var job models.Job
+	err := s.db.Collection(jobCollection).FindOne(ctx, bson.M{"job_id": jobID}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get job: %w", err)
+	}
+	return &job, nil
+}
+
+func (s *MongoStorage) ClaimJob(ctx context.Context, now time.Time, lease time.Duration) (*models.Job, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.JobQueued, "run_at": bson.M{"$lte": now}},
+		bson.M{"status": models.JobRunning, "lease_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{"status": models.JobRunning, "lease_until": now.Add(lease), "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.Job
+	err := s.db.Collection(jobCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claim job: %w", err)
+	}
+	return &job, nil
+}
+
+func (s *MongoStorage) UpdateJob(ctx context.Context, job *models.Job) error {
+	filter := bson.M{"job_id": job.JobID, "attempts": job.Attempts}
+	res, err := s.db.Collection(jobCollection).ReplaceOne(ctx, filter, job)
+	if err != nil {
+		return fmt.Errorf("update job: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrJobLost
+	}
+	return nil
+}
+
+// --- Idempotency Keys ---
+
+// ReserveIdempotencyKey relies on the unique index: a duplicate insert
+// means the key is taken, so the existing record is read back.
+func (s *MongoStorage) ReserveIdempotencyKey(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	coll := s.db.Collection(idemCollection)
+	_, err := coll.InsertOne(ctx, rec)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+
+	var existing models.IdempotencyRecord
+	filter := bson.M{"user_id": rec.UserID, "key": rec.Key}
+	if err := coll.FindOne(ctx, filter).Decode(&existing); err != nil {
+		return nil, fmt.Errorf("get idempotency key: %w", err)
+	}
+	return &existing, nil
+}
+
+func (s *MongoStorage) CompleteIdempotencyKey(ctx context.Context, userID, key, resultID string) error {
+	filter := bson.M{"user_id": userID, "key": key}
+	update := bson.M{"$set": bson.M{"result_id": resultID}}
+	_, err := s.db.Collection(idemCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStorage) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	_, err := s.db.Collection(idemCollection).DeleteOne(ctx, bson.M{"user_id": userID, "key": key})
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
 // --- Health & Lifecycle ---
 
 func (s *MongoStorage) Ping(ctx context.Context) error {
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// The driver decodes "any" fields as bson.A and bson.D; a round trip
+// through bson shows what GetReview hands back without a server.
+func TestPlainReviewValues(t *testing.T) {
+	children := []any{
+		map[string]any{"name": "Sarah", "relation": "daughter"},
+		map[string]any{"name": "Tom"},
+	}
+	data, err := bson.Marshal(models.ReviewItem{
+		ProposedIdentityUpdates: []models.IdentityProposal{{Key: "children", Value: children}},
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var item models.ReviewItem
+	if err := bson.Unmarshal(data, &item); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if _, ok := item.ProposedIdentityUpdates[0].Value.(bson.A); !ok {
+		t.Fatalf("decoded value is %T, want the driver's bson.A", item.ProposedIdentityUpdates[0].Value)
+	}
+
+	plainReview(&item)
+	if got := item.ProposedIdentityUpdates[0].Value; !reflect.DeepEqual(got, children) {
+		t.Errorf("plain value = %#v, want %#v", got, children)
+	}
+}
@@ -2,30 +2,92 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"sort"
+	"time"
 
 	"github.com/memory-lane/rag-engine/internal/models"
 )
 
+// ErrJobLost is returned by UpdateJob when the job was claimed again after
+// its lease expired, so the caller's copy is stale.
+var ErrJobLost = errors.New("job lease lost")
+
 // Storage defines the interface for all persistence operations.
 // Both MongoDB and DynamoDB backends implement this interface.
 type Storage interface {
 	// Identity operations
 	GetIdentity(ctx context.Context, userID, key string) (*models.IdentityFact, error)
 	SetIdentity(ctx context.Context, fact *models.IdentityFact) error
+	DeleteIdentity(ctx context.Context, userID, key string) error // no-op when missing
+	// ListIdentity returns all of a user's facts; GetIdentities returns the
+	// facts for the given keys, omitting missing ones. Both order by key.
+	ListIdentity(ctx context.Context, userID string) ([]models.IdentityFact, error)
+	GetIdentities(ctx context.Context, userID string, keys []string) ([]models.IdentityFact, error)
+
+	// Identity history: an append-only log of revisions per key.
+	// ListIdentityRevisions returns them ordered by version.
+	AppendIdentityRevision(ctx context.Context, rev *models.IdentityRevision) error
+	ListIdentityRevisions(ctx context.Context, userID, key string) ([]models.IdentityRevision, error)
 
 	// Memory operations
 	StoreMemory(ctx context.Context, chunk *models.MemoryChunk) error
-	SearchMemoryByTokens(ctx context.Context, userID, replicaID string, tokens []string) ([]models.MemoryChunk, error)
+	GetMemory(ctx context.Context, userID, chunkID string) (*models.MemoryChunk, error) // nil when not found
+	UpdateMemory(ctx context.Context, chunk *models.MemoryChunk) error                  // replaces a chunk in place; no-op when missing
+	DeleteMemory(ctx context.Context, userID, chunkID string) error                     // no-op when missing
+	SearchMemoryByTokens(ctx context.Context, userID, replicaID string, tokens []string, filter models.MemoryFilter) ([]models.MemoryChunk, error)
+	// ListMemory returns chunks in a stable order, one page at a time, along
+	// with the cursor of the next page ("" when there are no more).
+	// An empty replicaID lists all replicas.
+	ListMemory(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, page models.Page) ([]models.MemoryChunk, string, error)
+	// RecentMemory returns up to limit chunks, newest first.
+	RecentMemory(ctx context.Context, userID, replicaID string, filter models.MemoryFilter, limit int) ([]models.MemoryChunk, error)
+	RecordAccess(ctx context.Context, userID string, chunkIDs []string, at time.Time) error // bumps access_count, sets last_accessed_at
 
 	// Token index operations
 	IndexTokens(ctx context.Context, entries []models.TokenEntry) error
 	LookupTokens(ctx context.Context, userID, replicaID string, tokens []string) ([]string, error) // returns chunk IDs
+	// RemoveTokens deletes a chunk's index entries. tokens are the chunk's
+	// indexed tokens, which backends partitioned by token need to find them.
+	RemoveTokens(ctx context.Context, userID, chunkID string, tokens []string) error
+
+	// Corpus statistics for BM25 ranking. UpdateCorpusStats adds delta (+1 on
+	// store, -1 on removal) to the document count, delta*length to the total
+	// length and delta to the document frequency of each token.
+	// GetCorpusStats returns zero-valued stats when nothing has been recorded.
+	UpdateCorpusStats(ctx context.Context, userID, replicaID string, tokens []string, length, delta int) error
+	GetCorpusStats(ctx context.Context, userID, replicaID string, tokens []string) (*models.CorpusStats, error)
 
 	// Review queue operations
 	StoreReview(ctx context.Context, item *models.ReviewItem) error
 	GetReview(ctx context.Context, sessionID string) (*models.ReviewItem, error)
-	ListPendingReviews(ctx context.Context, userID string) ([]models.ReviewItem, error)
-	UpdateReviewStatus(ctx context.Context, sessionID string, status models.ReviewStatus) error
+	ListPendingReviews(ctx context.Context, userID string) ([]models.ReviewItem, error)         // oldest first
+	UpdateReviewStatus(ctx context.Context, sessionID string, status models.ReviewStatus) error // no-op when missing
+	UpdateReview(ctx context.Context, item *models.ReviewItem) error                            // replaces a review; no-op when missing
+
+	// Document operations. A document's text lives in memory chunks; this
+	// is the record that ties them together.
+	StoreDocument(ctx context.Context, doc *models.Document) error
+	GetDocument(ctx context.Context, documentID string) (*models.Document, error) // nil when not found
+	DeleteDocument(ctx context.Context, documentID string) error                  // no-op when missing
+
+	// Job queue operations. ClaimJob atomically takes the due job with the
+	// earliest RunAt (queued, or running with an expired lease), marks it
+	// running until now+lease and counts the attempt; it returns nil when
+	// nothing is due. UpdateJob replaces a claimed job, failing with
+	// ErrJobLost once someone else has claimed it since.
+	CreateJob(ctx context.Context, job *models.Job) error
+	GetJob(ctx context.Context, jobID string) (*models.Job, error) // nil when not found
+	ClaimJob(ctx context.Context, now time.Time, lease time.Duration) (*models.Job, error)
+	UpdateJob(ctx context.Context, job *models.Job) error
+
+	// Idempotency keys. ReserveIdempotencyKey stores rec unless the user
+	// already has its key, in which case it stores nothing and returns the
+	// existing record. A record without a ResultID is still in progress.
+	ReserveIdempotencyKey(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, userID, key, resultID string) error
+	ReleaseIdempotencyKey(ctx context.Context, userID, key string) error // no-op when missing
 
 	// Health
 	Ping(ctx context.Context) error
@@ -34,3 +96,34 @@ type Storage interface {
 	// Cleanup
 	Close(ctx context.Context) error
 }
+
+// jobDue reports whether a job may be claimed at now.
+func jobDue(job *models.Job, now time.Time) bool {
+	switch job.Status {
+	case models.JobQueued:
+		return !job.RunAt.After(now)
+	case models.JobRunning:
+		return job.LeaseUntil.Before(now)
+	}
+	return false
+}
+
+// claimJob marks job as taken by a worker until now+lease.
+func claimJob(job *models.Job, now time.Time, lease time.Duration) {
+	job.Status = models.JobRunning
+	job.Attempts++
+	job.LeaseUntil = now.Add(lease)
+	job.UpdatedAt = now
+}
+
+// sortedUnique returns keys sorted with duplicates and empty keys removed.
+func sortedUnique(keys []string) []string {
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k != "" {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return slices.Compact(out)
+}
@@ -0,0 +1,68 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/memory-lane/rag-engine/internal/storage"
+	"github.com/memory-lane/rag-engine/internal/storage/storagetest"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return storage.NewMemoryStorage()
+	})
+}
+
+func TestBoltConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "rag.db"))
+		if err != nil {
+			t.Fatalf("NewBoltStorage: %v", err)
+		}
+		t.Cleanup(func() { s.Close(context.Background()) })
+		return s
+	})
+}
+
+// The networked backends only run when a test instance is configured, e.g.
+//
+//	RAG_TEST_MONGODB_URL=mongodb://localhost:27017/rag_test go test ./...
+//	RAG_TEST_DYNAMODB_ENDPOINT=http://localhost:8000 go test ./...
+
+func TestMongoConformance(t *testing.T) {
+	uri := os.Getenv("RAG_TEST_MONGODB_URL")
+	if uri == "" {
+		t.Skip("RAG_TEST_MONGODB_URL not set")
+	}
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.NewMongoStorage(context.Background(), uri)
+		if err != nil {
+			t.Fatalf("NewMongoStorage: %v", err)
+		}
+		t.Cleanup(func() { s.Close(context.Background()) })
+		return s
+	})
+}
+
+func TestDynamoConformance(t *testing.T) {
+	endpoint := os.Getenv("RAG_TEST_DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("RAG_TEST_DYNAMODB_ENDPOINT not set")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.NewDynamoStorage(context.Background(), region, endpoint)
+		if err != nil {
+			t.Fatalf("NewDynamoStorage: %v", err)
+		}
+		// Small pages make the suite cross page boundaries
+		storage.SetDynamoPageSize(s, 10)
+		return s
+	})
+}
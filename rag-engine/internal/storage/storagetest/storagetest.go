@@ -0,0 +1,1286 @@
+// Package storagetest provides a behavioral conformance suite for
+// storage.Storage implementations.
+//
+// Every backend is expected to pass the same table of tests:
+//
+//	func TestMongoConformance(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storage.Storage {
+//			return newTestMongo(t)
+//		})
+//	}
+//
+// Tests use unique user and session IDs so a factory may hand out a shared,
+// long-lived database (e.g. DynamoDB Local) without cross-test interference.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/memory-lane/rag-engine/internal/models"
+	"github.com/memory-lane/rag-engine/internal/retrieval"
+	"github.com/memory-lane/rag-engine/internal/review"
+	"github.com/memory-lane/rag-engine/internal/schema"
+	"github.com/memory-lane/rag-engine/internal/storage"
+)
+
+// Factory returns a ready-to-use storage backend for a single test.
+// The factory is responsible for registering any cleanup with t.Cleanup.
+type Factory func(t *testing.T) storage.Storage
+
+// timeTolerance absorbs precision loss in backends that truncate timestamps
+// (Mongo stores milliseconds, RFC3339 strings may drop sub-second digits).
+const timeTolerance = 2 * time.Second
+
+var seq atomic.Int64
+
+// uniq returns an ID that is unique across the whole test run.
+func uniq(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d", prefix, time.Now().UnixNano(), seq.Add(1))
+}
+
+type conformanceTest struct {
+	name string
+	fn   func(t *testing.T, ctx context.Context, s storage.Storage)
+}
+
+var tests = []conformanceTest{
+	{"IdentityNotFoundReturnsNil", testIdentityNotFound},
+	{"IdentityUpsert", testIdentityUpsert},
+	{"IdentityScopedByUser", testIdentityScopedByUser},
+	{"IdentityDelete", testIdentityDelete},
+	{"ListIdentity", testListIdentity},
+	{"GetIdentities", testGetIdentities},
+	{"IdentityHistoryOrdering", testIdentityHistoryOrdering},
+	{"IdentityHistoryDuplicateVersion", testIdentityHistoryDuplicateVersion},
+	{"SearchEmptyTokens", testSearchEmptyTokens},
+	{"SearchReplicaScoping", testSearchReplicaScoping},
+	{"SearchUserScoping", testSearchUserScoping},
+	{"ListMemoryScoping", testListMemoryScoping},
+	{"MemoryFilters", testMemoryFilters},
+	{"ListMemoryPagination", testListMemoryPagination},
+	{"ListMemoryInvalidCursor", testListMemoryInvalidCursor},
+	{"RecentMemory", testRecentMemory},
+	{"RecordAccess", testRecordAccess},
+	{"GetMemory", testGetMemory},
+	{"UpdateMemory", testUpdateMemory},
+	{"DeleteMemory", testDeleteMemory},
+	{"RemoveTokens", testRemoveTokens},
+	{"LookupTokensDeduplicates", testLookupTokensDeduplicates},
+	{"LookupTokensReplicaScoping", testLookupTokensReplicaScoping},
+	{"ManyTokenMatches", testManyTokenMatches},
+	{"CorpusStatsEmpty", testCorpusStatsEmpty},
+	{"CorpusStatsAccumulate", testCorpusStatsAccumulate},
+	{"ReviewNotFoundReturnsNil", testReviewNotFound},
+	{"ReviewRoundTrip", testReviewRoundTrip},
+	{"ReviewCommitsStructuredValues", testReviewCommitsStructuredValues},
+	{"ReviewPendingFilter", testReviewPendingFilter},
+	{"ReviewPendingOrder", testReviewPendingOrder},
+	{"ReviewStatusTransitions", testReviewStatusTransitions},
+	{"ReviewUpdate", testReviewUpdate},
+	{"DocumentRoundTrip", testDocumentRoundTrip},
+	{"JobRoundTrip", testJobRoundTrip},
+	{"ClaimJobOrder", testClaimJobOrder},
+	{"ClaimJobExpiredLease", testClaimJobExpiredLease},
+	{"IdempotencyKeyLifecycle", testIdempotencyKeyLifecycle},
+	{"Ping", testPing},
+}
+
+// Run executes the conformance suite against storage produced by newStore.
+func Run(t *testing.T, newStore Factory) {
+	t.Helper()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newStore(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			tc.fn(t, ctx, s)
+		})
+	}
+}
+
+// --- Identity ---
+
+func testIdentityNotFound(t *testing.T, ctx context.Context, s storage.Storage) {
+	fact, err := s.GetIdentity(ctx, uniq("user"), "name")
+	if err != nil {
+		t.Fatalf("GetIdentity: %v", err)
+	}
+	if fact != nil {
+		t.Fatalf("GetIdentity on missing key = %+v, want nil", fact)
+	}
+}
+
+func testIdentityUpsert(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	first := &models.IdentityFact{UserID: userID, Key: "name", Value: "Margaret", Version: 1, UpdatedAt: time.Now()}
+	if err := s.SetIdentity(ctx, first); err != nil {
+		t.Fatalf("SetIdentity: %v", err)
+	}
+	second := &models.IdentityFact{UserID: userID, Key: "name", Value: "Peggy", Version: 2, Immutable: true, UpdatedAt: time.Now()}
+	if err := s.SetIdentity(ctx, second); err != nil {
+		t.Fatalf("SetIdentity (update): %v", err)
+	}
+
+	got, err := s.GetIdentity(ctx, userID, "name")
+	if err != nil {
+		t.Fatalf("GetIdentity: %v", err)
+	}
+	if got == nil {
+		t.Fatal("GetIdentity = nil after SetIdentity")
+	}
+	if got.UserID != userID || got.Key != "name" {
+		t.Errorf("GetIdentity scope = (%q, %q), want (%q, %q)", got.UserID, got.Key, userID, "name")
+	}
+	if got.Value != "Peggy" || got.Version != 2 || !got.Immutable {
+		t.Errorf("GetIdentity = %+v, want the second write", got)
+	}
+	assertTimeNear(t, "UpdatedAt", got.UpdatedAt, second.UpdatedAt)
+}
+
+func testIdentityScopedByUser(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	mustSetIdentity(t, ctx, s, &models.IdentityFact{UserID: alice, Key: "hometown", Value: "Leeds", Version: 1})
+
+	got, err := s.GetIdentity(ctx, bob, "hometown")
+	if err != nil {
+		t.Fatalf("GetIdentity: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("GetIdentity leaked another user's fact: %+v", got)
+	}
+}
+
+func testIdentityDelete(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	mustSetIdentity(t, ctx, s, &models.IdentityFact{UserID: alice, Key: "pet", Value: "Biscuit", Version: 1})
+	mustSetIdentity(t, ctx, s, &models.IdentityFact{UserID: bob, Key: "pet", Value: "Rex", Version: 1})
+
+	if err := s.DeleteIdentity(ctx, alice, "pet"); err != nil {
+		t.Fatalf("DeleteIdentity: %v", err)
+	}
+	if err := s.DeleteIdentity(ctx, alice, "missing"); err != nil {
+		t.Fatalf("DeleteIdentity(missing): %v", err)
+	}
+
+	if got, err := s.GetIdentity(ctx, alice, "pet"); err != nil || got != nil {
+		t.Errorf("GetIdentity after delete = %+v, %v; want nil", got, err)
+	}
+	if got, err := s.GetIdentity(ctx, bob, "pet"); err != nil || got == nil {
+		t.Errorf("DeleteIdentity removed another user's fact: %+v, %v", got, err)
+	}
+}
+
+func testListIdentity(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	for _, k := range []string{"pet", "name", "hometown"} {
+		mustSetIdentity(t, ctx, s, &models.IdentityFact{UserID: alice, Key: k, Value: k + "-value", Version: 1})
+	}
+	mustSetIdentity(t, ctx, s, &models.IdentityFact{UserID: bob, Key: "name", Value: "Bob", Version: 1})
+
+	facts, err := s.ListIdentity(ctx, alice)
+	if err != nil {
+		t.Fatalf("ListIdentity: %v", err)
+	}
+	if got := identityKeys(facts); fmt.Sprint(got) != "[hometown name pet]" {
+		t.Fatalf("ListIdentity keys = %v, want [hometown name pet] in order", got)
+	}
+	if facts[1].UserID != alice || facts[1].Value != "name-value" {
+		t.Errorf("fact = %+v", facts[1])
+	}
+
+	if facts, err := s.ListIdentity(ctx, uniq("nobody")); err != nil || len(facts) != 0 {
+		t.Errorf("ListIdentity(unknown) = %+v, %v; want empty", facts, err)
+	}
+}
+
+func testGetIdentities(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	mustSetIdentity(t, ctx, s, &models.IdentityFact{UserID: alice, Key: "pet", Value: "Biscuit", Version: 1})
+	mustSetIdentity(t, ctx, s, &models.IdentityFact{UserID: alice, Key: "name", Value: "Alice", Version: 2})
+	mustSetIdentity(t, ctx, s, &models.IdentityFact{UserID: bob, Key: "hometown", Value: "Leeds", Version: 1})
+
+	facts, err := s.GetIdentities(ctx, alice, []string{"pet", "missing", "hometown", "name", "pet"})
+	if err != nil {
+		t.Fatalf("GetIdentities: %v", err)
+	}
+	if got := identityKeys(facts); fmt.Sprint(got) != "[name pet]" {
+		t.Fatalf("GetIdentities keys = %v, want [name pet] in order", got)
+	}
+	if facts[0].Version != 2 {
+		t.Errorf("name version = %d, want 2", facts[0].Version)
+	}
+
+	if facts, err := s.GetIdentities(ctx, alice, nil); err != nil || len(facts) != 0 {
+		t.Errorf("GetIdentities(no keys) = %+v, %v; want empty", facts, err)
+	}
+}
+
+func testIdentityHistoryOrdering(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	now := time.Now().UTC()
+	// Appended out of order; versions past 9 catch lexical sorting.
+	for _, v := range []int{2, 10, 1} {
+		rev := &models.IdentityRevision{UserID: alice, Key: "pet", Version: v, Value: fmt.Sprintf("v%d", v), Source: "api", CreatedAt: now}
+		if err := s.AppendIdentityRevision(ctx, rev); err != nil {
+			t.Fatalf("AppendIdentityRevision(%d): %v", v, err)
+		}
+	}
+	other := &models.IdentityRevision{UserID: alice, Key: "petName", Version: 1, Value: "x", CreatedAt: now}
+	if err := s.AppendIdentityRevision(ctx, other); err != nil {
+		t.Fatalf("AppendIdentityRevision(other key): %v", err)
+	}
+
+	revs, err := s.ListIdentityRevisions(ctx, alice, "pet")
+	if err != nil {
+		t.Fatalf("ListIdentityRevisions: %v", err)
+	}
+	var got []int
+	for _, r := range revs {
+		got = append(got, r.Version)
+	}
+	if fmt.Sprint(got) != "[1 2 10]" {
+		t.Fatalf("versions = %v, want [1 2 10]", got)
+	}
+	if revs[2].Value != "v10" || revs[2].Source != "api" {
+		t.Errorf("revision 10 = %+v", revs[2])
+	}
+	assertTimeNear(t, "CreatedAt", revs[0].CreatedAt, now)
+
+	if revs, err := s.ListIdentityRevisions(ctx, bob, "pet"); err != nil || len(revs) != 0 {
+		t.Errorf("ListIdentityRevisions(bob) = %+v, %v; want empty", revs, err)
+	}
+}
+
+func testIdentityHistoryDuplicateVersion(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice := uniq("alice")
+	rev := &models.IdentityRevision{UserID: alice, Key: "pet", Version: 1, Value: "Biscuit", CreatedAt: time.Now().UTC()}
+	if err := s.AppendIdentityRevision(ctx, rev); err != nil {
+		t.Fatalf("AppendIdentityRevision: %v", err)
+	}
+	dup := *rev
+	dup.Value = "Rex"
+	if err := s.AppendIdentityRevision(ctx, &dup); err == nil {
+		t.Fatal("duplicate version accepted")
+	}
+	revs, err := s.ListIdentityRevisions(ctx, alice, "pet")
+	if err != nil || len(revs) != 1 || revs[0].Value != "Biscuit" {
+		t.Errorf("history after duplicate = %+v, %v", revs, err)
+	}
+}
+
+// --- Memory & token index ---
+
+func testSearchEmptyTokens(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	storeChunk(t, ctx, s, userID, "r1", "gardening roses")
+
+	chunks, err := s.SearchMemoryByTokens(ctx, userID, "r1", nil, models.MemoryFilter{})
+	if err != nil {
+		t.Fatalf("SearchMemoryByTokens: %v", err)
+	}
+	if len(chunks) != 0 {
+		t.Fatalf("SearchMemoryByTokens(no tokens) returned %d chunks, want 0", len(chunks))
+	}
+}
+
+func testSearchReplicaScoping(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	c1 := storeChunk(t, ctx, s, userID, "r1", "wedding sarah church")
+	c2 := storeChunk(t, ctx, s, userID, "r2", "wedding joan seaside")
+
+	cases := []struct {
+		replica string
+		want    []string
+	}{
+		{"r1", []string{c1}},
+		{"r2", []string{c2}},
+		{"", []string{c1, c2}},
+		{"r3", nil},
+	}
+	for _, c := range cases {
+		chunks, err := s.SearchMemoryByTokens(ctx, userID, c.replica, []string{"wedding"}, models.MemoryFilter{})
+		if err != nil {
+			t.Fatalf("SearchMemoryByTokens(%q): %v", c.replica, err)
+		}
+		assertIDs(t, fmt.Sprintf("SearchMemoryByTokens(replica=%q)", c.replica), chunkIDs(chunks), c.want)
+	}
+}
+
+func testSearchUserScoping(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	storeChunk(t, ctx, s, alice, "r1", "knitting scarves")
+
+	chunks, err := s.SearchMemoryByTokens(ctx, bob, "", []string{"knitting"}, models.MemoryFilter{})
+	if err != nil {
+		t.Fatalf("SearchMemoryByTokens: %v", err)
+	}
+	if len(chunks) != 0 {
+		t.Fatalf("SearchMemoryByTokens leaked %d chunks from another user", len(chunks))
+	}
+
+	got, err := s.SearchMemoryByTokens(ctx, alice, "r1", []string{"knitting", "unrelated"}, models.MemoryFilter{})
+	if err != nil {
+		t.Fatalf("SearchMemoryByTokens: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("SearchMemoryByTokens returned %d chunks, want 1", len(got))
+	}
+	if got[0].Content != "knitting scarves" || got[0].ReplicaID != "r1" {
+		t.Errorf("SearchMemoryByTokens chunk = %+v", got[0])
+	}
+}
+
+func testListMemoryScoping(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	c1 := storeChunk(t, ctx, s, alice, "r1", "first memory")
+	c2 := storeChunk(t, ctx, s, alice, "r2", "second memory")
+	storeChunk(t, ctx, s, bob, "r1", "someone else")
+
+	for _, c := range []struct {
+		replica string
+		want    []string
+	}{
+		{"r1", []string{c1}},
+		{"", []string{c1, c2}},
+	} {
+		chunks, _, err := s.ListMemory(ctx, alice, c.replica, models.MemoryFilter{}, models.Page{})
+		if err != nil {
+			t.Fatalf("ListMemory(%q): %v", c.replica, err)
+		}
+		assertIDs(t, fmt.Sprintf("ListMemory(replica=%q)", c.replica), chunkIDs(chunks), c.want)
+	}
+}
+
+func testMemoryFilters(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	base := time.Now().Add(-time.Hour)
+	mk := func(source, session string, importance float64, age time.Duration) string {
+		return storeChunkWith(t, ctx, s, &models.MemoryChunk{
+			UserID: userID, ReplicaID: "r1", ChunkID: uniq(userID),
+			Content: "family picnic", Tokens: []string{"family", "picnic"},
+			Source: source, SessionID: session, Importance: importance,
+			CreatedAt: base.Add(-age),
+		})
+	}
+	conv := mk("conversation", "s1", 0.9, 0)
+	file := mk("file", "", 0.4, 48*time.Hour)
+	manual := mk("manual", "s2", 0.6, 10*24*time.Hour)
+
+	after := base.Add(-72 * time.Hour)
+	before := base.Add(-time.Minute)
+	cases := []struct {
+		name   string
+		filter models.MemoryFilter
+		want   []string
+	}{
+		{"none", models.MemoryFilter{}, []string{conv, file, manual}},
+		{"sources", models.MemoryFilter{Sources: []string{"conversation", "manual"}}, []string{conv, manual}},
+		{"session", models.MemoryFilter{SessionID: "s2"}, []string{manual}},
+		{"created after", models.MemoryFilter{CreatedAfter: &after}, []string{conv, file}},
+		{"created before", models.MemoryFilter{CreatedBefore: &before}, []string{file, manual}},
+		{"time range", models.MemoryFilter{CreatedAfter: &after, CreatedBefore: &before}, []string{file}},
+		{"min importance", models.MemoryFilter{MinImportance: 0.5}, []string{conv, manual}},
+		{"exclude", models.MemoryFilter{ExcludeChunkIDs: []string{conv, "unknown"}}, []string{file, manual}},
+		{"combined", models.MemoryFilter{Sources: []string{"file", "manual"}, MinImportance: 0.5}, []string{manual}},
+	}
+	for _, c := range cases {
+		chunks, err := s.SearchMemoryByTokens(ctx, userID, "r1", []string{"picnic"}, c.filter)
+		if err != nil {
+			t.Fatalf("SearchMemoryByTokens(%s): %v", c.name, err)
+		}
+		assertIDs(t, "SearchMemoryByTokens("+c.name+")", chunkIDs(chunks), c.want)
+
+		chunks, _, err = s.ListMemory(ctx, userID, "r1", c.filter, models.Page{})
+		if err != nil {
+			t.Fatalf("ListMemory(%s): %v", c.name, err)
+		}
+		assertIDs(t, "ListMemory("+c.name+")", chunkIDs(chunks), c.want)
+	}
+}
+
+func testListMemoryPagination(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	var want []string
+	for i := range 7 {
+		want = append(want, storeChunk(t, ctx, s, userID, "r1", fmt.Sprintf("memory number%d", i)))
+	}
+	storeChunk(t, ctx, s, userID, "r2", "other replica")
+
+	var got []string
+	seen := make(map[string]bool)
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatalf("pagination did not terminate, got %v", got)
+		}
+		chunks, next, err := s.ListMemory(ctx, userID, "r1", models.MemoryFilter{}, models.Page{Limit: 3, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("ListMemory(page %d): %v", pages, err)
+		}
+		if len(chunks) > 3 {
+			t.Fatalf("page %d has %d chunks, limit is 3", pages, len(chunks))
+		}
+		for _, c := range chunks {
+			if seen[c.ChunkID] {
+				t.Fatalf("chunk %s returned twice", c.ChunkID)
+			}
+			seen[c.ChunkID] = true
+			got = append(got, c.ChunkID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assertIDs(t, "paginated ListMemory", got, want)
+
+	// An exact multiple of the limit must not leave a dangling empty page.
+	chunks, next, err := s.ListMemory(ctx, userID, "r2", models.MemoryFilter{}, models.Page{Limit: 1})
+	if err != nil {
+		t.Fatalf("ListMemory: %v", err)
+	}
+	if len(chunks) != 1 || next != "" {
+		t.Errorf("ListMemory(limit=1, one chunk) = %d chunks, next %q; want 1 chunk and no cursor", len(chunks), next)
+	}
+}
+
+func testListMemoryInvalidCursor(t *testing.T, ctx context.Context, s storage.Storage) {
+	_, _, err := s.ListMemory(ctx, uniq("user"), "", models.MemoryFilter{}, models.Page{Limit: 2, Cursor: "%%not-a-cursor%%"})
+	if !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("ListMemory with garbage cursor: err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func testRecentMemory(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	var ids []string
+	for i := range 4 {
+		ids = append(ids, storeChunk(t, ctx, s, userID, "r1", fmt.Sprintf("memory number%d", i)))
+	}
+	other := storeChunk(t, ctx, s, userID, "r2", "other replica")
+	storeChunk(t, ctx, s, uniq("user"), "r1", "other user")
+
+	chunks, err := s.RecentMemory(ctx, userID, "r1", models.MemoryFilter{}, 3)
+	if err != nil {
+		t.Fatalf("RecentMemory: %v", err)
+	}
+	if got := strings.Join(chunkIDs(chunks), ","); got != strings.Join([]string{ids[3], ids[2], ids[1]}, ",") {
+		t.Errorf("RecentMemory(r1, 3) = %v, want the newest three, newest first", chunkIDs(chunks))
+	}
+
+	chunks, err = s.RecentMemory(ctx, userID, "", models.MemoryFilter{ExcludeChunkIDs: []string{other}}, 10)
+	if err != nil {
+		t.Fatalf("RecentMemory: %v", err)
+	}
+	assertIDs(t, "RecentMemory(all replicas, filtered)", chunkIDs(chunks), ids)
+}
+
+func testRecordAccess(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	hit := storeChunk(t, ctx, s, userID, "r1", "picnic hit")
+	storeChunk(t, ctx, s, userID, "r1", "picnic miss")
+
+	at := time.Now()
+	for range 2 {
+		if err := s.RecordAccess(ctx, userID, []string{hit, "missing-chunk"}, at); err != nil {
+			t.Fatalf("RecordAccess: %v", err)
+		}
+	}
+
+	chunks, _, err := s.ListMemory(ctx, userID, "r1", models.MemoryFilter{}, models.Page{})
+	if err != nil {
+		t.Fatalf("ListMemory: %v", err)
+	}
+	for _, c := range chunks {
+		if c.ChunkID != hit {
+			if c.AccessCount != 0 || c.LastAccessedAt != nil {
+				t.Errorf("untouched chunk has access data: %d %v", c.AccessCount, c.LastAccessedAt)
+			}
+			continue
+		}
+		if c.AccessCount != 2 {
+			t.Errorf("AccessCount = %d, want 2", c.AccessCount)
+		}
+		if c.LastAccessedAt == nil {
+			t.Fatal("LastAccessedAt not set")
+		}
+		assertTimeNear(t, "LastAccessedAt", *c.LastAccessedAt, at)
+	}
+}
+
+func testGetMemory(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	id := storeChunk(t, ctx, s, alice, "r1", "orchard apples")
+
+	got, err := s.GetMemory(ctx, alice, id)
+	if err != nil {
+		t.Fatalf("GetMemory: %v", err)
+	}
+	if got == nil || got.Content != "orchard apples" || got.ReplicaID != "r1" {
+		t.Fatalf("GetMemory = %+v, want the stored chunk", got)
+	}
+
+	for _, c := range []struct{ user, id string }{{alice, "missing-chunk"}, {bob, id}} {
+		got, err := s.GetMemory(ctx, c.user, c.id)
+		if err != nil {
+			t.Fatalf("GetMemory(%q, %q): %v", c.user, c.id, err)
+		}
+		if got != nil {
+			t.Errorf("GetMemory(%q, %q) = %+v, want nil", c.user, c.id, got)
+		}
+	}
+}
+
+func testUpdateMemory(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	id := storeChunk(t, ctx, s, userID, "r1", "orchard apples")
+	orig, err := s.GetMemory(ctx, userID, id)
+	if err != nil || orig == nil {
+		t.Fatalf("GetMemory = %v, %v", orig, err)
+	}
+
+	updated := *orig
+	updated.Content = "orchard pears"
+	updated.Tokens = []string{"orchard", "pears"}
+	updated.Importance = 0.9
+	if err := s.UpdateMemory(ctx, &updated); err != nil {
+		t.Fatalf("UpdateMemory: %v", err)
+	}
+
+	got, err := s.GetMemory(ctx, userID, id)
+	if err != nil || got == nil {
+		t.Fatalf("GetMemory after update = %v, %v", got, err)
+	}
+	if got.Content != "orchard pears" || got.Importance != 0.9 || len(got.Tokens) != 2 || got.Tokens[1] != "pears" {
+		t.Errorf("GetMemory after update = %+v", got)
+	}
+	assertTimeNear(t, "CreatedAt", got.CreatedAt, orig.CreatedAt)
+
+	chunks, _, err := s.ListMemory(ctx, userID, "", models.MemoryFilter{}, models.Page{})
+	if err != nil {
+		t.Fatalf("ListMemory: %v", err)
+	}
+	assertIDs(t, "ListMemory after update", chunkIDs(chunks), []string{id})
+
+	missing := updated
+	missing.ChunkID = "missing-chunk"
+	if err := s.UpdateMemory(ctx, &missing); err != nil {
+		t.Fatalf("UpdateMemory(missing): %v", err)
+	}
+	if got, _ := s.GetMemory(ctx, userID, "missing-chunk"); got != nil {
+		t.Errorf("UpdateMemory created a missing chunk: %+v", got)
+	}
+}
+
+func testDeleteMemory(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	gone := storeChunk(t, ctx, s, alice, "r1", "orchard apples")
+	kept := storeChunk(t, ctx, s, alice, "r1", "orchard pears")
+	storeChunkWith(t, ctx, s, &models.MemoryChunk{
+		UserID: bob, ReplicaID: "r1", ChunkID: gone, Content: "orchard plums",
+		Tokens: []string{"orchard", "plums"}, CreatedAt: time.Now(),
+	})
+
+	if err := s.DeleteMemory(ctx, alice, gone); err != nil {
+		t.Fatalf("DeleteMemory: %v", err)
+	}
+	if err := s.DeleteMemory(ctx, alice, "missing-chunk"); err != nil {
+		t.Fatalf("DeleteMemory(missing): %v", err)
+	}
+
+	chunks, _, err := s.ListMemory(ctx, alice, "r1", models.MemoryFilter{}, models.Page{})
+	if err != nil {
+		t.Fatalf("ListMemory: %v", err)
+	}
+	assertIDs(t, "ListMemory after delete", chunkIDs(chunks), []string{kept})
+
+	other, err := s.GetMemory(ctx, bob, gone)
+	if err != nil || other == nil {
+		t.Errorf("DeleteMemory removed another user's chunk with the same ID: %v, %v", other, err)
+	}
+}
+
+func testRemoveTokens(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	gone := storeChunk(t, ctx, s, userID, "r1", "lighthouse keeper")
+	kept := storeChunk(t, ctx, s, userID, "r2", "lighthouse visit")
+
+	if err := s.RemoveTokens(ctx, userID, gone, []string{"lighthouse", "keeper"}); err != nil {
+		t.Fatalf("RemoveTokens: %v", err)
+	}
+
+	ids, err := s.LookupTokens(ctx, userID, "", []string{"lighthouse", "keeper"})
+	if err != nil {
+		t.Fatalf("LookupTokens: %v", err)
+	}
+	assertIDs(t, "LookupTokens after RemoveTokens", ids, []string{kept})
+}
+
+func testLookupTokensDeduplicates(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	c1 := storeChunk(t, ctx, s, userID, "r1", "sarah wedding")
+
+	ids, err := s.LookupTokens(ctx, userID, "r1", []string{"sarah", "wedding"})
+	if err != nil {
+		t.Fatalf("LookupTokens: %v", err)
+	}
+	assertIDs(t, "LookupTokens", ids, []string{c1})
+
+	ids, err = s.LookupTokens(ctx, userID, "r1", nil)
+	if err != nil {
+		t.Fatalf("LookupTokens(no tokens): %v", err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("LookupTokens(no tokens) = %v, want empty", ids)
+	}
+}
+
+// testManyTokenMatches shares one token across more chunks than a backend
+// reads in one page or batch (DynamoDB batches 100 keys per BatchGetItem).
+func testManyTokenMatches(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	var want []string
+	for i := range 120 {
+		want = append(want, storeChunk(t, ctx, s, userID, "r1", fmt.Sprintf("allotment day%d", i)))
+	}
+	storeChunk(t, ctx, s, userID, "r2", "allotment other replica")
+	storeChunk(t, ctx, s, uniq("user"), "r1", "allotment other user")
+
+	ids, err := s.LookupTokens(ctx, userID, "r1", []string{"allotment"})
+	if err != nil {
+		t.Fatalf("LookupTokens: %v", err)
+	}
+	assertIDs(t, "LookupTokens", ids, want)
+
+	chunks, err := s.SearchMemoryByTokens(ctx, userID, "r1", []string{"allotment"}, models.MemoryFilter{})
+	if err != nil {
+		t.Fatalf("SearchMemoryByTokens: %v", err)
+	}
+	assertIDs(t, "SearchMemoryByTokens", chunkIDs(chunks), want)
+}
+
+func testLookupTokensReplicaScoping(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	c1 := storeChunk(t, ctx, s, alice, "r1", "nurse hospital")
+	c2 := storeChunk(t, ctx, s, alice, "r2", "nurse training")
+	storeChunk(t, ctx, s, bob, "r1", "nurse uniform")
+
+	cases := []struct {
+		replica string
+		want    []string
+	}{
+		{"r1", []string{c1}},
+		{"r2", []string{c2}},
+		{"", []string{c1, c2}},
+	}
+	for _, c := range cases {
+		ids, err := s.LookupTokens(ctx, alice, c.replica, []string{"nurse"})
+		if err != nil {
+			t.Fatalf("LookupTokens(%q): %v", c.replica, err)
+		}
+		assertIDs(t, fmt.Sprintf("LookupTokens(replica=%q)", c.replica), ids, c.want)
+	}
+}
+
+// --- Corpus stats ---
+
+func testCorpusStatsEmpty(t *testing.T, ctx context.Context, s storage.Storage) {
+	st, err := s.GetCorpusStats(ctx, uniq("user"), "r1", []string{"garden"})
+	if err != nil {
+		t.Fatalf("GetCorpusStats: %v", err)
+	}
+	if st == nil {
+		t.Fatal("GetCorpusStats = nil, want zero-valued stats")
+	}
+	if st.DocCount != 0 || st.TotalLength != 0 || st.DocFreq["garden"] != 0 {
+		t.Errorf("GetCorpusStats on empty scope = %+v", st)
+	}
+}
+
+func testCorpusStatsAccumulate(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	updates := []struct {
+		replica string
+		tokens  []string
+		length  int
+		delta   int
+	}{
+		{"r1", []string{"garden", "roses"}, 3, 1},
+		{"r1", []string{"garden", "tomatoes"}, 5, 1},
+		{"r2", []string{"garden"}, 7, 1},
+		{"r1", []string{"garden", "roses"}, 3, -1},
+	}
+	for _, u := range updates {
+		if err := s.UpdateCorpusStats(ctx, userID, u.replica, u.tokens, u.length, u.delta); err != nil {
+			t.Fatalf("UpdateCorpusStats: %v", err)
+		}
+	}
+
+	st, err := s.GetCorpusStats(ctx, userID, "r1", []string{"garden", "roses", "tomatoes", "absent"})
+	if err != nil {
+		t.Fatalf("GetCorpusStats: %v", err)
+	}
+	if st.DocCount != 1 || st.TotalLength != 5 {
+		t.Errorf("totals = (%d, %d), want (1, 5)", st.DocCount, st.TotalLength)
+	}
+	want := map[string]int{"garden": 1, "roses": 0, "tomatoes": 1, "absent": 0}
+	for tok, n := range want {
+		if st.DocFreq[tok] != n {
+			t.Errorf("DocFreq[%q] = %d, want %d", tok, st.DocFreq[tok], n)
+		}
+	}
+}
+
+// --- Review queue ---
+
+func testReviewNotFound(t *testing.T, ctx context.Context, s storage.Storage) {
+	item, err := s.GetReview(ctx, uniq("session"))
+	if err != nil {
+		t.Fatalf("GetReview: %v", err)
+	}
+	if item != nil {
+		t.Fatalf("GetReview on missing session = %+v, want nil", item)
+	}
+}
+
+func testReviewRoundTrip(t *testing.T, ctx context.Context, s storage.Storage) {
+	item := &models.ReviewItem{
+		SessionID: uniq("session"),
+		UserID:    uniq("user"),
+		Status:    models.ReviewPending,
+		ProposedIdentityUpdates: []models.IdentityProposal{
+			{Key: "name", Value: "Peggy", Confidence: 0.8},
+		},
+		ProposedMemories: []models.MemoryProposal{
+			{Content: "I love gardening", Importance: 0.5, Source: "conversation"},
+		},
+	}
+	before := time.Now()
+	if err := s.StoreReview(ctx, item); err != nil {
+		t.Fatalf("StoreReview: %v", err)
+	}
+	if item.CreatedAt.Before(before) {
+		t.Errorf("StoreReview did not stamp CreatedAt")
+	}
+
+	got, err := s.GetReview(ctx, item.SessionID)
+	if err != nil {
+		t.Fatalf("GetReview: %v", err)
+	}
+	if got == nil {
+		t.Fatal("GetReview = nil after StoreReview")
+	}
+	if got.UserID != item.UserID || got.Status != models.ReviewPending {
+		t.Errorf("GetReview = %+v", got)
+	}
+	if len(got.ProposedIdentityUpdates) != 1 || got.ProposedIdentityUpdates[0].Value != "Peggy" {
+		t.Errorf("ProposedIdentityUpdates = %+v", got.ProposedIdentityUpdates)
+	}
+	if len(got.ProposedMemories) != 1 || got.ProposedMemories[0].Content != "I love gardening" {
+		t.Errorf("ProposedMemories = %+v", got.ProposedMemories)
+	}
+	if got.ReviewedAt != nil {
+		t.Errorf("ReviewedAt = %v on a pending review, want nil", got.ReviewedAt)
+	}
+	assertTimeNear(t, "CreatedAt", got.CreatedAt, item.CreatedAt)
+}
+
+// testReviewCommitsStructuredValues approves a stored review holding a
+// list of person references, as decoded from a JSON request. The values
+// must read back in a form the schema still accepts.
+func testReviewCommitsStructuredValues(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	children := []any{
+		map[string]any{"name": "Sarah", "relation": "daughter"},
+		map[string]any{"name": "Tom"},
+	}
+	item := &models.ReviewItem{
+		SessionID: uniq("session"),
+		UserID:    userID,
+		Status:    models.ReviewPending,
+		ProposedIdentityUpdates: []models.IdentityProposal{
+			{Key: "children", Value: children, Confidence: 0.9},
+		},
+	}
+	if err := s.StoreReview(ctx, item); err != nil {
+		t.Fatalf("StoreReview: %v", err)
+	}
+
+	identity := retrieval.NewIdentityService(s, schema.Default())
+	svc := review.NewService(s, identity, retrieval.NewMemoryService(s, nil, nil))
+	approved, err := svc.Approve(ctx, userID, item.SessionID)
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if r := approved.Results; len(r) != 1 || r[0].Outcome != models.ProposalApplied {
+		t.Fatalf("Approve results = %+v, want the children applied", r)
+	}
+
+	fact, err := s.GetIdentity(ctx, userID, "children")
+	if err != nil || fact == nil {
+		t.Fatalf("GetIdentity = %+v, %v", fact, err)
+	}
+	if !reflect.DeepEqual(fact.Value, children) {
+		t.Errorf("children = %#v, want %#v", fact.Value, children)
+	}
+}
+
+func testReviewPendingFilter(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	p1 := storeReview(t, ctx, s, alice)
+	p2 := storeReview(t, ctx, s, alice)
+	done := storeReview(t, ctx, s, alice)
+	storeReview(t, ctx, s, bob)
+
+	if err := s.UpdateReviewStatus(ctx, done, models.ReviewApproved); err != nil {
+		t.Fatalf("UpdateReviewStatus: %v", err)
+	}
+
+	items, err := s.ListPendingReviews(ctx, alice)
+	if err != nil {
+		t.Fatalf("ListPendingReviews: %v", err)
+	}
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = it.SessionID
+		if it.UserID != alice || it.Status != models.ReviewPending {
+			t.Errorf("ListPendingReviews returned %+v", it)
+		}
+	}
+	assertIDs(t, "ListPendingReviews", ids, []string{p1, p2})
+}
+
+func testReviewPendingOrder(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	// StoreReview stamps CreatedAt. The IDs sort opposite to the order they
+	// are stored in, so key order cannot pass for creation order.
+	want := []string{uniq("session-c"), uniq("session-b"), uniq("session-a")}
+	for _, id := range want {
+		if err := s.StoreReview(ctx, &models.ReviewItem{SessionID: id, UserID: userID, Status: models.ReviewPending}); err != nil {
+			t.Fatalf("StoreReview: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	items, err := s.ListPendingReviews(ctx, userID)
+	if err != nil {
+		t.Fatalf("ListPendingReviews: %v", err)
+	}
+	got := make([]string, len(items))
+	for i, it := range items {
+		got[i] = it.SessionID
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ListPendingReviews = %v, want %v (oldest first)", got, want)
+	}
+}
+
+func testReviewStatusTransitions(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	for _, status := range []models.ReviewStatus{models.ReviewApproved, models.ReviewRejected} {
+		sessionID := storeReview(t, ctx, s, userID)
+		before := time.Now()
+		if err := s.UpdateReviewStatus(ctx, sessionID, status); err != nil {
+			t.Fatalf("UpdateReviewStatus(%s): %v", status, err)
+		}
+
+		got, err := s.GetReview(ctx, sessionID)
+		if err != nil {
+			t.Fatalf("GetReview: %v", err)
+		}
+		if got == nil {
+			t.Fatal("GetReview = nil after UpdateReviewStatus")
+		}
+		if got.Status != status {
+			t.Errorf("Status = %q, want %q", got.Status, status)
+		}
+		if got.ReviewedAt == nil {
+			t.Fatalf("ReviewedAt not set after %s", status)
+		}
+		assertTimeNear(t, "ReviewedAt", *got.ReviewedAt, before)
+	}
+
+	missing := uniq("session")
+	if err := s.UpdateReviewStatus(ctx, missing, models.ReviewApproved); err != nil {
+		t.Fatalf("UpdateReviewStatus(missing): %v", err)
+	}
+	if got, _ := s.GetReview(ctx, missing); got != nil {
+		t.Errorf("UpdateReviewStatus created a missing review: %+v", got)
+	}
+}
+
+func testReviewUpdate(t *testing.T, ctx context.Context, s storage.Storage) {
+	userID := uniq("user")
+	sessionID := storeReview(t, ctx, s, userID)
+	item, err := s.GetReview(ctx, sessionID)
+	if err != nil || item == nil {
+		t.Fatalf("GetReview = %v, %v", item, err)
+	}
+
+	now := time.Now()
+	item.Status = models.ReviewApproved
+	item.ReviewedAt = &now
+	item.Results = []models.ProposalResult{
+		{Kind: "memory", Index: 0, Outcome: models.ProposalApplied, ChunkID: "chunk-1"},
+		{Kind: "identity", Index: 0, Outcome: models.ProposalSkipped, Reason: "immutable", Key: "name"},
+	}
+	if err := s.UpdateReview(ctx, item); err != nil {
+		t.Fatalf("UpdateReview: %v", err)
+	}
+
+	got, err := s.GetReview(ctx, sessionID)
+	if err != nil || got == nil {
+		t.Fatalf("GetReview after update = %v, %v", got, err)
+	}
+	if got.Status != models.ReviewApproved || got.UserID != userID {
+		t.Errorf("GetReview after update = %+v", got)
+	}
+	if len(got.Results) != 2 || got.Results[0].ChunkID != "chunk-1" || got.Results[1].Reason != "immutable" {
+		t.Errorf("Results = %+v", got.Results)
+	}
+	assertTimeNear(t, "CreatedAt", got.CreatedAt, item.CreatedAt)
+
+	missing := &models.ReviewItem{SessionID: uniq("session"), UserID: userID, Status: models.ReviewApproved}
+	if err := s.UpdateReview(ctx, missing); err != nil {
+		t.Fatalf("UpdateReview(missing): %v", err)
+	}
+	if got, _ := s.GetReview(ctx, missing.SessionID); got != nil {
+		t.Errorf("UpdateReview created a missing review: %+v", got)
+	}
+}
+
+// --- Documents ---
+
+func testDocumentRoundTrip(t *testing.T, ctx context.Context, s storage.Storage) {
+	if got, err := s.GetDocument(ctx, uniq("doc")); err != nil || got != nil {
+		t.Fatalf("GetDocument on missing document = %+v, %v; want nil", got, err)
+	}
+
+	doc := &models.Document{
+		DocumentID: uniq("doc"),
+		UserID:     uniq("alice"),
+		ReplicaID:  "r1",
+		Title:      "Letter from Joan",
+		Format:     "markdown",
+		Date:       "1962-06",
+		People:     []string{"Joan", "Frank"},
+		Metadata:   map[string]string{"place": "Leeds"},
+		ChunkIDs:   []string{"c1", "c2"},
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+	if err := s.StoreDocument(ctx, doc); err != nil {
+		t.Fatalf("StoreDocument: %v", err)
+	}
+	if err := s.StoreDocument(ctx, doc); err == nil {
+		t.Error("duplicate document accepted")
+	}
+
+	got, err := s.GetDocument(ctx, doc.DocumentID)
+	if err != nil || got == nil {
+		t.Fatalf("GetDocument = %v, %v", got, err)
+	}
+	if got.UserID != doc.UserID || got.Title != doc.Title || got.Date != doc.Date || got.Metadata["place"] != "Leeds" {
+		t.Errorf("GetDocument = %+v", got)
+	}
+	assertIDs(t, "People", got.People, doc.People)
+	if strings.Join(got.ChunkIDs, ",") != "c1,c2" {
+		t.Errorf("ChunkIDs = %v, want [c1 c2] in order", got.ChunkIDs)
+	}
+	assertTimeNear(t, "CreatedAt", got.CreatedAt, doc.CreatedAt)
+
+	if err := s.DeleteDocument(ctx, doc.DocumentID); err != nil {
+		t.Fatalf("DeleteDocument: %v", err)
+	}
+	if got, err := s.GetDocument(ctx, doc.DocumentID); err != nil || got != nil {
+		t.Errorf("GetDocument after delete = %+v, %v; want nil", got, err)
+	}
+	if err := s.DeleteDocument(ctx, doc.DocumentID); err != nil {
+		t.Errorf("DeleteDocument(missing): %v", err)
+	}
+}
+
+// --- Job queue ---
+
+// jobBase returns a distinct whole-second instant in the past for each job
+// test to claim at. Jobs are not scoped by user, so every test finishes the
+// jobs it creates to keep them out of later tests on a shared database.
+func jobBase() time.Time {
+	return time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(seq.Add(1)) * time.Hour)
+}
+
+func testJobRoundTrip(t *testing.T, ctx context.Context, s storage.Storage) {
+	if got, err := s.GetJob(ctx, uniq("job")); err != nil || got != nil {
+		t.Fatalf("GetJob on missing job = %+v, %v; want nil", got, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	job := createJob(t, ctx, s, now.Add(time.Hour))
+	if err := s.CreateJob(ctx, job); err == nil {
+		t.Error("duplicate job accepted")
+	}
+
+	got, err := s.GetJob(ctx, job.JobID)
+	if err != nil || got == nil {
+		t.Fatalf("GetJob = %v, %v", got, err)
+	}
+	if got.UserID != job.UserID || got.Status != models.JobQueued || got.MaxAttempts != 3 {
+		t.Errorf("GetJob = %+v", got)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Content != "I grew up in Leeds" {
+		t.Errorf("Messages = %+v", got.Messages)
+	}
+	assertTimeNear(t, "RunAt", got.RunAt, job.RunAt)
+	if got.FinishedAt != nil {
+		t.Errorf("FinishedAt = %v on a queued job, want nil", got.FinishedAt)
+	}
+	finishJob(t, ctx, s, got)
+}
+
+func testClaimJobOrder(t *testing.T, ctx context.Context, s storage.Storage) {
+	base := jobBase()
+	later := createJob(t, ctx, s, base.Add(-time.Minute))
+	earlier := createJob(t, ctx, s, base.Add(-2*time.Minute))
+	future := createJob(t, ctx, s, base.Add(time.Minute))
+
+	for _, want := range []*models.Job{earlier, later} {
+		got, err := s.ClaimJob(ctx, base, time.Minute)
+		if err != nil || got == nil {
+			t.Fatalf("ClaimJob = %v, %v", got, err)
+		}
+		if got.JobID != want.JobID {
+			t.Fatalf("ClaimJob = %s, want %s", got.JobID, want.JobID)
+		}
+		if got.Status != models.JobRunning || got.Attempts != 1 {
+			t.Errorf("claimed job = %+v, want running on attempt 1", got)
+		}
+		assertTimeNear(t, "LeaseUntil", got.LeaseUntil, base.Add(time.Minute))
+		finishJob(t, ctx, s, got)
+	}
+	if got, err := s.ClaimJob(ctx, base, time.Minute); err != nil || got != nil {
+		t.Fatalf("ClaimJob with nothing due = %+v, %v; want nil", got, err)
+	}
+
+	got, err := s.ClaimJob(ctx, base.Add(time.Minute), time.Minute)
+	if err != nil || got == nil || got.JobID != future.JobID {
+		t.Fatalf("ClaimJob once due = %+v, %v; want %s", got, err, future.JobID)
+	}
+	finishJob(t, ctx, s, got)
+}
+
+func testClaimJobExpiredLease(t *testing.T, ctx context.Context, s storage.Storage) {
+	base := jobBase()
+	job := createJob(t, ctx, s, base)
+
+	first, err := s.ClaimJob(ctx, base, time.Minute)
+	if err != nil || first == nil || first.JobID != job.JobID {
+		t.Fatalf("ClaimJob = %+v, %v", first, err)
+	}
+	if got, err := s.ClaimJob(ctx, base.Add(30*time.Second), time.Minute); err != nil || got != nil {
+		t.Fatalf("ClaimJob during lease = %+v, %v; want nil", got, err)
+	}
+
+	second, err := s.ClaimJob(ctx, base.Add(2*time.Minute), time.Minute)
+	if err != nil || second == nil || second.JobID != job.JobID {
+		t.Fatalf("ClaimJob after lease expiry = %+v, %v", second, err)
+	}
+	if second.Attempts != 2 {
+		t.Errorf("Attempts = %d after reclaim, want 2", second.Attempts)
+	}
+
+	first.Status = models.JobSucceeded
+	if err := s.UpdateJob(ctx, first); !errors.Is(err, storage.ErrJobLost) {
+		t.Fatalf("UpdateJob with a stale claim = %v, want ErrJobLost", err)
+	}
+	finishJob(t, ctx, s, second)
+	got, err := s.GetJob(ctx, job.JobID)
+	if err != nil || got == nil || got.Status != models.JobSucceeded || got.SessionID != "session-1" {
+		t.Errorf("GetJob after UpdateJob = %+v, %v", got, err)
+	}
+}
+
+// --- Idempotency keys ---
+
+func testIdempotencyKeyLifecycle(t *testing.T, ctx context.Context, s storage.Storage) {
+	alice, bob := uniq("alice"), uniq("bob")
+	reserve := func(userID string) *models.IdempotencyRecord {
+		t.Helper()
+		rec := &models.IdempotencyRecord{UserID: userID, Key: "session:abc", CreatedAt: time.Now().UTC()}
+		existing, err := s.ReserveIdempotencyKey(ctx, rec)
+		if err != nil {
+			t.Fatalf("ReserveIdempotencyKey: %v", err)
+		}
+		return existing
+	}
+
+	if existing := reserve(alice); existing != nil {
+		t.Fatalf("first reservation returned %+v, want nil", existing)
+	}
+	if existing := reserve(alice); existing == nil || existing.ResultID != "" {
+		t.Fatalf("second reservation = %+v, want the in-progress record", existing)
+	}
+	if existing := reserve(bob); existing != nil {
+		t.Fatalf("reservation for another user = %+v, want nil", existing)
+	}
+
+	if err := s.CompleteIdempotencyKey(ctx, alice, "session:abc", "session-1"); err != nil {
+		t.Fatalf("CompleteIdempotencyKey: %v", err)
+	}
+	if existing := reserve(alice); existing == nil || existing.ResultID != "session-1" {
+		t.Fatalf("reservation after completion = %+v, want result session-1", existing)
+	}
+
+	if err := s.ReleaseIdempotencyKey(ctx, bob, "session:abc"); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey: %v", err)
+	}
+	if existing := reserve(bob); existing != nil {
+		t.Fatalf("reservation after release = %+v, want nil", existing)
+	}
+	if err := s.ReleaseIdempotencyKey(ctx, uniq("carol"), "session:abc"); err != nil {
+		t.Errorf("ReleaseIdempotencyKey(missing): %v", err)
+	}
+	if err := s.CompleteIdempotencyKey(ctx, uniq("carol"), "session:abc", "session-2"); err != nil {
+		t.Errorf("CompleteIdempotencyKey(missing): %v", err)
+	}
+}
+
+func testPing(t *testing.T, ctx context.Context, s storage.Storage) {
+	if err := s.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if s.BackendName() == "" {
+		t.Error("BackendName is empty")
+	}
+}
+
+// --- helpers ---
+
+func mustSetIdentity(t *testing.T, ctx context.Context, s storage.Storage, fact *models.IdentityFact) {
+	t.Helper()
+	if err := s.SetIdentity(ctx, fact); err != nil {
+		t.Fatalf("SetIdentity: %v", err)
+	}
+}
+
+// storeChunk writes a chunk and its token index entries the same way
+// retrieval.MemoryService does, returning the chunk ID.
+func storeChunk(t *testing.T, ctx context.Context, s storage.Storage, userID, replicaID, content string) string {
+	t.Helper()
+	return storeChunkWith(t, ctx, s, &models.MemoryChunk{
+		UserID:     userID,
+		ReplicaID:  replicaID,
+		ChunkID:    uniq(userID),
+		Content:    content,
+		Tokens:     strings.Fields(content),
+		Importance: 0.5,
+		Source:     "manual",
+		CreatedAt:  time.Now(),
+	})
+}
+
+// storeChunkWith writes a fully specified chunk and indexes its tokens.
+func storeChunkWith(t *testing.T, ctx context.Context, s storage.Storage, chunk *models.MemoryChunk) string {
+	t.Helper()
+	if err := s.StoreMemory(ctx, chunk); err != nil {
+		t.Fatalf("StoreMemory: %v", err)
+	}
+
+	entries := make([]models.TokenEntry, len(chunk.Tokens))
+	for i, tok := range chunk.Tokens {
+		entries[i] = models.TokenEntry{Token: tok, UserID: chunk.UserID, ReplicaID: chunk.ReplicaID, ChunkID: chunk.ChunkID, Timestamp: chunk.CreatedAt}
+	}
+	if err := s.IndexTokens(ctx, entries); err != nil {
+		t.Fatalf("IndexTokens: %v", err)
+	}
+
+	// Some backends key chunks by timestamp; keep them distinct.
+	time.Sleep(time.Millisecond)
+	return chunk.ChunkID
+}
+
+func storeReview(t *testing.T, ctx context.Context, s storage.Storage, userID string) string {
+	t.Helper()
+	item := &models.ReviewItem{SessionID: uniq("session"), UserID: userID, Status: models.ReviewPending}
+	if err := s.StoreReview(ctx, item); err != nil {
+		t.Fatalf("StoreReview: %v", err)
+	}
+	return item.SessionID
+}
+
+func createJob(t *testing.T, ctx context.Context, s storage.Storage, runAt time.Time) *models.Job {
+	t.Helper()
+	job := &models.Job{
+		JobID:       uniq("job"),
+		UserID:      uniq("user"),
+		Status:      models.JobQueued,
+		Messages:    []models.TranscriptLine{{Role: "user", Content: "I grew up in Leeds"}},
+		MaxAttempts: 3,
+		RunAt:       runAt,
+		CreatedAt:   runAt,
+		UpdatedAt:   runAt,
+	}
+	if err := s.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	return job
+}
+
+// finishJob marks a job succeeded so it is never claimed again.
+func finishJob(t *testing.T, ctx context.Context, s storage.Storage, job *models.Job) {
+	t.Helper()
+	now := time.Now().UTC()
+	job.Status = models.JobSucceeded
+	job.SessionID = "session-1"
+	job.FinishedAt = &now
+	if err := s.UpdateJob(ctx, job); err != nil {
+		t.Fatalf("UpdateJob: %v", err)
+	}
+}
+
+func identityKeys(facts []models.IdentityFact) []string {
+	keys := make([]string, len(facts))
+	for i, f := range facts {
+		keys[i] = f.Key
+	}
+	return keys
+}
+
+func chunkIDs(chunks []models.MemoryChunk) []string {
+	ids := make([]string, len(chunks))
+	for i, c := range chunks {
+		ids[i] = c.ChunkID
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	got = append([]string(nil), got...)
+	want = append([]string(nil), want...)
+	sort.Strings(got)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", what, got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s = %v, want %v", what, got, want)
+			return
+		}
+	}
+}
+
+func assertTimeNear(t *testing.T, what string, got, want time.Time) {
+	t.Helper()
+	if d := got.Sub(want); d > timeTolerance || d < -timeTolerance {
+		t.Errorf("%s = %v, want within %v of %v", what, got, timeTolerance, want)
+	}
+}